@@ -2,10 +2,12 @@ package auth_server
 
 import (
 	"fmt"
+	"github.com/gomodule/redigo/redis"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"konami_backend/auth/pkg/session"
 	sessionDeliveryPkg "konami_backend/auth/pkg/session/delivery/grpc"
 	sessionRepoPkg "konami_backend/auth/pkg/session/repository"
 	sessionUseCasePkg "konami_backend/auth/pkg/session/usecase"
@@ -14,11 +16,33 @@ import (
 	"log"
 	"net"
 	"os"
+	"time"
 )
 
-func InitDelivery(db *gorm.DB) (sessionDeliveryPkg.SessionHandler, error) {
-	sessionRepo := sessionRepoPkg.NewSessionGormRepo(db)
-	sessionUC := sessionUseCasePkg.NewSessionUseCase(sessionRepo)
+func durationEnv(name string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+// InitDelivery keeps sessions in Redis when a pool is given and in Postgres otherwise
+func InitDelivery(db *gorm.DB, rconn *redis.Pool) (sessionDeliveryPkg.SessionHandler, error) {
+	absoluteTTL := durationEnv("SESSION_TTL", sessionUseCasePkg.DefAbsoluteTTL)
+	idleTTL := durationEnv("SESSION_IDLE_TTL", sessionUseCasePkg.DefIdleTTL)
+	var sessionRepo session.Repository
+	if rconn != nil {
+		sessionRepo = sessionRepoPkg.NewSessionRedisRepo(rconn, absoluteTTL, idleTTL)
+	} else {
+		sessionRepo = sessionRepoPkg.NewSessionGormRepo(db)
+	}
+	sessionUC := &sessionUseCasePkg.SessionUseCase{
+		SessionRepo: sessionRepo,
+		AbsoluteTTL: absoluteTTL,
+		IdleTTL:     idleTTL,
+		Now:         time.Now,
+	}
 	sessionDelivery := sessionDeliveryPkg.NewSessionHandler(sessionUC)
 	return sessionDelivery, nil
 }
@@ -39,7 +63,27 @@ func Start() {
 	if err := dbdb.Ping(); err != nil {
 		logger.Fatalf("failed to launch db: %v", err)
 	}
-	sessionHandler, err := InitDelivery(db)
+	var redisConn *redis.Pool
+	switch store := os.Getenv("SESSION_STORE"); store {
+	case "redis":
+		redisAddr := os.Getenv("REDIS_CONN")
+		if redisAddr == "" {
+			redisAddr = "redis://user:@localhost:6379/0"
+		}
+		redisConn = &redis.Pool{
+			MaxIdle:   80,
+			MaxActive: 12000,
+			Wait:      true,
+			Dial: func() (redis.Conn, error) {
+				return redis.DialURL(redisAddr)
+			},
+		}
+		defer redisConn.Close()
+	case "", "postgres":
+	default:
+		logger.Fatalf("unknown session store %q", store)
+	}
+	sessionHandler, err := InitDelivery(db, redisConn)
 	if err != nil {
 		logger.Fatalf("failed to init delivery: %v", err)
 		return
@@ -84,6 +128,12 @@ func Migrate() {
 	if err != nil {
 		log.Fatalf("failed to migrate db: %v", err)
 	}
+	// Sessions created before expiry tracking have no timestamps and would be treated as long expired
+	err = db.Exec(`UPDATE sessions SET created_at = now(), last_seen_at = now()
+WHERE created_at IS NULL OR created_at < '1970-01-01' OR last_seen_at IS NULL OR last_seen_at < '1970-01-01'`).Error
+	if err != nil {
+		log.Fatalf("failed to migrate db: %v", err)
+	}
 }
 
 func Truncate() {
@@ -1,6 +1,14 @@
 package models
 
+import "time"
+
 type Session struct {
-	UserId int64  `json:"userId"`
-	Token  string `json:"-"`
+	Id         int64     `json:"id"`
+	UserId     int64     `json:"userId"`
+	Token      string    `json:"-"`
+	UserAgent  string    `json:"userAgent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
 }
@@ -1,7 +1,7 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: konami_backend/proto/auth (interfaces: AuthCheckerClient)
 
-// Package mock_auth is a generated GoMock package.
+// Package session is a generated GoMock package.
 package session
 
 import (
@@ -94,3 +94,63 @@ func (mr *MockAuthCheckerClientMockRecorder) Delete(arg0, arg1 interface{}, arg2
 	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAuthCheckerClient)(nil).Delete), varargs...)
 }
+
+// List mocks base method
+func (m *MockAuthCheckerClient) List(arg0 context.Context, arg1 *auth.SessionToken, arg2 ...grpc.CallOption) (*auth.SessionList, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "List", varargs...)
+	ret0, _ := ret[0].(*auth.SessionList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockAuthCheckerClientMockRecorder) List(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAuthCheckerClient)(nil).List), varargs...)
+}
+
+// Revoke mocks base method
+func (m *MockAuthCheckerClient) Revoke(arg0 context.Context, arg1 *auth.RevokeRequest, arg2 ...grpc.CallOption) (*auth.Nothing, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Revoke", varargs...)
+	ret0, _ := ret[0].(*auth.Nothing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke
+func (mr *MockAuthCheckerClientMockRecorder) Revoke(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAuthCheckerClient)(nil).Revoke), varargs...)
+}
+
+// RevokeAll mocks base method
+func (m *MockAuthCheckerClient) RevokeAll(arg0 context.Context, arg1 *auth.RevokeAllRequest, arg2 ...grpc.CallOption) (*auth.Nothing, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeAll", varargs...)
+	ret0, _ := ret[0].(*auth.Nothing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAll indicates an expected call of RevokeAll
+func (mr *MockAuthCheckerClientMockRecorder) RevokeAll(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAll", reflect.TypeOf((*MockAuthCheckerClient)(nil).RevokeAll), varargs...)
+}
@@ -18,7 +18,7 @@ func NewSessionHandler(sessionUC session.UseCase) SessionHandler {
 }
 
 func (uc *SessionHandler) Create(_ context.Context, in *auth.Session) (*auth.SessionToken, error) {
-	sid, err := uc.SessionUC.CreateSession(in.UserId)
+	sid, err := uc.SessionUC.CreateSession(in.UserId, in.UserAgent, in.Ip)
 	if err != nil {
 		return nil, status.Error(codes.Aborted, err.Error())
 	}
@@ -27,7 +27,7 @@ func (uc *SessionHandler) Create(_ context.Context, in *auth.Session) (*auth.Ses
 
 func (uc *SessionHandler) Check(_ context.Context, in *auth.SessionToken) (*auth.Session, error) {
 	userId, err := uc.SessionUC.GetUserId(in.Token)
-	if errors.Is(err, session.ErrSessionNotFound) {
+	if errors.Is(err, session.ErrSessionNotFound) || errors.Is(err, session.ErrSessionExpired) {
 		return nil, status.Error(codes.NotFound, err.Error())
 	}
 	if err != nil {
@@ -45,3 +45,44 @@ func (uc *SessionHandler) Delete(_ context.Context, in *auth.SessionToken) (*aut
 	}
 	return &auth.Nothing{Dummy: true}, nil
 }
+
+func (uc *SessionHandler) List(_ context.Context, in *auth.SessionToken) (*auth.SessionList, error) {
+	sessions, err := uc.SessionUC.ListSessions(in.Token)
+	if errors.Is(err, session.ErrSessionNotFound) || errors.Is(err, session.ErrSessionExpired) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	res := &auth.SessionList{Sessions: make([]*auth.SessionInfo, len(sessions))}
+	for i, s := range sessions {
+		res.Sessions[i] = &auth.SessionInfo{
+			Id:         s.Id,
+			UserAgent:  s.UserAgent,
+			Ip:         s.Ip,
+			CreatedAt:  s.CreatedAt.Unix(),
+			LastSeenAt: s.LastSeenAt.Unix(),
+			Current:    s.Current,
+		}
+	}
+	return res, nil
+}
+
+func (uc *SessionHandler) Revoke(_ context.Context, in *auth.RevokeRequest) (*auth.Nothing, error) {
+	err := uc.SessionUC.RevokeSession(in.UserId, in.SessionId)
+	if errors.Is(err, session.ErrSessionNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	return &auth.Nothing{Dummy: true}, nil
+}
+
+func (uc *SessionHandler) RevokeAll(_ context.Context, in *auth.RevokeAllRequest) (*auth.Nothing, error) {
+	err := uc.SessionUC.RevokeAll(in.UserId, in.ExceptToken)
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	return &auth.Nothing{Dummy: true}, nil
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"konami_backend/auth/pkg/models"
 	"konami_backend/auth/pkg/session"
 	"konami_backend/proto/auth"
 	"testing"
+	"time"
 )
 
 var testHandler SessionHandler
@@ -22,8 +27,8 @@ func TestGRPC(t *testing.T) {
 		var id int64 = 123
 
 		testStr := "TOK"
-		m.EXPECT().CreateSession(id).Return(testStr, nil)
-		_, _ = testHandler.Create(context.Background(), &auth.Session{UserId: id})
+		m.EXPECT().CreateSession(id, "Firefox", "10.0.0.1").Return(testStr, nil)
+		_, _ = testHandler.Create(context.Background(), &auth.Session{UserId: id, UserAgent: "Firefox", Ip: "10.0.0.1"})
 	})
 
 	t.Run("GRPCCreateBad", func(t *testing.T) {
@@ -36,8 +41,8 @@ func TestGRPC(t *testing.T) {
 		var id int64 = 123
 
 		testStr := "TOK"
-		m.EXPECT().CreateSession(id).Return(testStr, errors.New("err"))
-		_, _ = testHandler.Create(context.Background(), &auth.Session{UserId: id})
+		m.EXPECT().CreateSession(id, "Firefox", "10.0.0.1").Return(testStr, errors.New("err"))
+		_, _ = testHandler.Create(context.Background(), &auth.Session{UserId: id, UserAgent: "Firefox", Ip: "10.0.0.1"})
 	})
 
 	t.Run("GRPCCheak", func(t *testing.T) {
@@ -117,4 +122,54 @@ func TestGRPC(t *testing.T) {
 		m.EXPECT().RemoveSession(testStr).Return(nil)
 		_, _ = testHandler.Delete(context.Background(), &auth.SessionToken{Token: "TOK"})
 	})
+
+	t.Run("GRPCList", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := session.NewMockUseCase(ctrl)
+		testHandler = NewSessionHandler(m)
+
+		created := time.Unix(1600000000, 0)
+		m.EXPECT().ListSessions("TOK").Return([]models.Session{
+			{Id: 1, UserAgent: "Firefox", Ip: "10.0.0.1", CreatedAt: created, LastSeenAt: created, Current: true},
+		}, nil)
+		res, err := testHandler.List(context.Background(), &auth.SessionToken{Token: "TOK"})
+		assert.NoError(t, err)
+		assert.Len(t, res.Sessions, 1)
+		assert.Equal(t, int64(1600000000), res.Sessions[0].CreatedAt)
+		assert.True(t, res.Sessions[0].Current)
+
+		m.EXPECT().ListSessions("TOK").Return(nil, session.ErrSessionExpired)
+		_, err = testHandler.List(context.Background(), &auth.SessionToken{Token: "TOK"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		m.EXPECT().ListSessions("TOK").Return(nil, errors.New("Err"))
+		_, err = testHandler.List(context.Background(), &auth.SessionToken{Token: "TOK"})
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
+
+	t.Run("GRPCRevoke", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := session.NewMockUseCase(ctrl)
+		testHandler = NewSessionHandler(m)
+
+		m.EXPECT().RevokeSession(int64(4), int64(2)).Return(nil)
+		_, err := testHandler.Revoke(context.Background(), &auth.RevokeRequest{UserId: 4, SessionId: 2})
+		assert.NoError(t, err)
+
+		m.EXPECT().RevokeSession(int64(4), int64(3)).Return(session.ErrSessionNotFound)
+		_, err = testHandler.Revoke(context.Background(), &auth.RevokeRequest{UserId: 4, SessionId: 3})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		m.EXPECT().RevokeAll(int64(4), "TOK").Return(nil)
+		_, err = testHandler.RevokeAll(context.Background(), &auth.RevokeAllRequest{UserId: 4, ExceptToken: "TOK"})
+		assert.NoError(t, err)
+
+		m.EXPECT().RevokeAll(int64(4), "TOK").Return(errors.New("Err"))
+		_, err = testHandler.RevokeAll(context.Background(), &auth.RevokeAllRequest{UserId: 4, ExceptToken: "TOK"})
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
 }
@@ -1,12 +1,23 @@
+//go:generate mockgen -source=repository.go -destination=./repositoty_mock.go -package=session
 package session
 
-import "errors"
+import (
+	"errors"
+	"konami_backend/auth/pkg/models"
+	"time"
+)
 
 var ErrInvalidToken = errors.New("invalid token")
 var ErrSessionNotFound = errors.New("session not found")
+var ErrSessionExpired = errors.New("session expired")
 
 type Repository interface {
-	GetUserId(token string) (userId int64, err error)
-	CreateSession(userId int64) (token string, err error)
+	GetSession(token string) (models.Session, error)
+	CreateSession(s models.Session) (token string, err error)
+	TouchSession(token string, lastSeen time.Time) error
 	RemoveSession(token string) error
+	GetUserSessions(userId int64) ([]models.Session, error)
+	// RemoveSessionById returns ErrSessionNotFound unless the session belongs to the user
+	RemoveSessionById(userId int64, sessionId int64) error
+	RemoveUserSessions(userId int64, exceptToken string) error
 }
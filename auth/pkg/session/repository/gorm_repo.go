@@ -4,7 +4,9 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"konami_backend/auth/pkg/models"
 	"konami_backend/auth/pkg/session"
+	"time"
 )
 
 type SessionGormRepo struct {
@@ -16,32 +18,43 @@ func NewSessionGormRepo(db *gorm.DB) session.Repository {
 }
 
 type Session struct {
-	Id     int `gorm:"primaryKey;autoIncrement;"`
-	UserId int64
-	Token  string
+	Id         int    `gorm:"primaryKey;autoIncrement;"`
+	UserId     int64  `gorm:"index"`
+	Token      string `gorm:"uniqueIndex"`
+	UserAgent  string
+	Ip         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
 }
 
 func (s *Session) TableName() string {
 	return "sessions"
 }
 
-/*
-NOT USED NOW
-func ToDbObject(data ModelsPkg.Session) Session {
+func ToDbObject(data models.Session) Session {
 	return Session{
-		UserId: data.UserId,
-		Token:  data.Token,
+		UserId:     data.UserId,
+		Token:      data.Token,
+		UserAgent:  data.UserAgent,
+		Ip:         data.Ip,
+		CreatedAt:  data.CreatedAt,
+		LastSeenAt: data.LastSeenAt,
 	}
 }
 
-func ToModel(obj Session) ModelsPkg.Session {
-	return ModelsPkg.Session{
-		UserId: obj.UserId,
-		Token:  obj.Token,
+func ToModel(obj Session) models.Session {
+	return models.Session{
+		Id:         int64(obj.Id),
+		UserId:     obj.UserId,
+		Token:      obj.Token,
+		UserAgent:  obj.UserAgent,
+		Ip:         obj.Ip,
+		CreatedAt:  obj.CreatedAt,
+		LastSeenAt: obj.LastSeenAt,
 	}
 }
-*/
-func (h SessionGormRepo) GetUserId(token string) (int64, error) {
+
+func (h SessionGormRepo) GetSession(token string) (models.Session, error) {
 	var s Session
 	db := h.db.
 		Table("sessions").
@@ -49,19 +62,17 @@ func (h SessionGormRepo) GetUserId(token string) (int64, error) {
 		First(&s)
 	err := db.Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return 0, session.ErrSessionNotFound
+		return models.Session{}, session.ErrSessionNotFound
 	}
 	if err != nil {
-		return 0, err
+		return models.Session{}, err
 	}
-	return s.UserId, nil
+	return ToModel(s), nil
 }
 
-func (h SessionGormRepo) CreateSession(userId int64) (string, error) {
-	s := Session{
-		UserId: userId,
-		Token:  uuid.New().String(),
-	}
+func (h SessionGormRepo) CreateSession(data models.Session) (string, error) {
+	s := ToDbObject(data)
+	s.Token = uuid.New().String()
 	db := h.db.Create(&s)
 	err := db.Error
 	if err != nil {
@@ -70,7 +81,42 @@ func (h SessionGormRepo) CreateSession(userId int64) (string, error) {
 	return s.Token, nil
 }
 
+func (h SessionGormRepo) TouchSession(token string, lastSeen time.Time) error {
+	return h.db.Model(&Session{}).
+		Where("token = ?", token).
+		Update("last_seen_at", lastSeen).Error
+}
+
 func (h SessionGormRepo) RemoveSession(token string) error {
 	db := h.db.Delete(Session{}, "token = ?", token)
 	return db.Error
 }
+
+func (h SessionGormRepo) GetUserSessions(userId int64) ([]models.Session, error) {
+	var sessions []Session
+	err := h.db.Where("user_id = ?", userId).Order("last_seen_at DESC").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.Session, len(sessions))
+	for i, s := range sessions {
+		res[i] = ToModel(s)
+	}
+	return res, nil
+}
+
+func (h SessionGormRepo) RemoveSessionById(userId int64, sessionId int64) error {
+	db := h.db.Delete(Session{}, "id = ? AND user_id = ?", sessionId, userId)
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return session.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (h SessionGormRepo) RemoveUserSessions(userId int64, exceptToken string) error {
+	db := h.db.Delete(Session{}, "user_id = ? AND token <> ?", userId, exceptToken)
+	return db.Error
+}
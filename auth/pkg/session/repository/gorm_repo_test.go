@@ -11,6 +11,7 @@ import (
 	"konami_backend/auth/pkg/models"
 	"konami_backend/auth/pkg/session"
 	"testing"
+	"time"
 )
 
 type Suite struct {
@@ -55,12 +56,15 @@ func (s *Suite) TestGetSessions() {
 
 	s.mock.ExpectQuery("SELECT").
 		WithArgs(testId).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "userid", "token"}).
-			AddRow(1, testSession.UserId, testSession.Token))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token", "user_agent", "ip"}).
+			AddRow(1, testSession.UserId, testSession.Token, "Firefox", "10.0.0.1"))
 
-	_, err := s.repository.GetUserId(testSession.Token)
+	res, err := s.repository.GetSession(testSession.Token)
 
 	require.NoError(s.T(), err)
+	require.Equal(s.T(), testSession.UserId, res.UserId)
+	require.Equal(s.T(), "Firefox", res.UserAgent)
+	require.Equal(s.T(), "10.0.0.1", res.Ip)
 }
 
 func (s *Suite) TestCreateTagError() {
@@ -68,13 +72,94 @@ func (s *Suite) TestCreateTagError() {
 		WithArgs("LOL").
 		WillReturnError(s.bdError)
 
-	_, err := s.repository.GetUserId("LOL")
+	_, err := s.repository.GetSession("LOL")
 	require.Error(s.T(), err)
 	require.Equal(s.T(), err, s.bdError)
 }
 
+func (s *Suite) TestGetSessionNotFound() {
+	s.mock.ExpectQuery("SELECT").
+		WithArgs("LOL").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := s.repository.GetSession("LOL")
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+}
+
 func (s *Suite) TestCreateSession() {
-	// CANT TEST uuid.New().String()
+	now := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"sessions\"").
+		WithArgs(int64(1), sqlmock.AnyArg(), "Firefox", "10.0.0.1", now, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	token, err := s.repository.CreateSession(models.Session{
+		UserId:     1,
+		UserAgent:  "Firefox",
+		Ip:         "10.0.0.1",
+		CreatedAt:  now,
+		LastSeenAt: now,
+	})
+	require.NoError(s.T(), err)
+	require.NotEmpty(s.T(), token)
+}
+
+func (s *Suite) TestTouchSession() {
+	now := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"sessions\" SET \"last_seen_at\"").
+		WithArgs(now, "tokkken").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.TouchSession("tokkken", now)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestGetUserSessions() {
+	s.mock.ExpectQuery("SELECT (.+) ORDER BY last_seen_at DESC").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token"}).
+			AddRow(2, 1, "second").
+			AddRow(1, 1, "tokkken"))
+
+	res, err := s.repository.GetUserSessions(1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 2)
+	require.Equal(s.T(), int64(2), res[0].Id)
+	require.Equal(s.T(), "tokkken", res[1].Token)
+}
+
+func (s *Suite) TestRemoveSessionById() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM").
+		WithArgs(int64(2), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.RemoveSessionById(1, 2)
+	require.NoError(s.T(), err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM").
+		WithArgs(int64(2), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err = s.repository.RemoveSessionById(3, 2)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+}
+
+func (s *Suite) TestRemoveUserSessions() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM (.+) token <>").
+		WithArgs(int64(1), "tokkken").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mock.ExpectCommit()
+
+	err := s.repository.RemoveUserSessions(1, "tokkken")
+	require.NoError(s.T(), err)
 }
 
 func (s *Suite) TestDeleteSessions() {
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"konami_backend/auth/pkg/models"
+	"konami_backend/auth/pkg/session"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	sessionKeyPrefix = "session:"
+	userKeyPrefix    = "session:user:"
+	sessionSeqKey    = "session:seq"
+)
+
+// touchScript slides the expiry of an existing session only, so that a touch
+// racing with a logout does not bring a partial session back
+var touchScript = redis.NewScript(1, `
+local created = redis.call('HGET', KEYS[1], 'createdAt')
+if not created then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'lastSeenAt', ARGV[1])
+redis.call('PEXPIREAT', KEYS[1], math.min(tonumber(ARGV[1]) + tonumber(ARGV[2]), tonumber(created) + tonumber(ARGV[3])))
+return 1
+`)
+
+// SessionRedisRepo keeps every session in a hash that expires natively at the
+// earlier of its idle and absolute deadlines. A per-user hash maps session ids
+// to tokens, its stale entries are dropped on read.
+type SessionRedisRepo struct {
+	redisPool   *redis.Pool
+	AbsoluteTTL time.Duration
+	IdleTTL     time.Duration
+}
+
+func NewSessionRedisRepo(pool *redis.Pool, absoluteTTL, idleTTL time.Duration) session.Repository {
+	return &SessionRedisRepo{redisPool: pool, AbsoluteTTL: absoluteTTL, IdleTTL: idleTTL}
+}
+
+func sessionKey(token string) string {
+	return sessionKeyPrefix + token
+}
+
+func userKey(userId int64) string {
+	return userKeyPrefix + strconv.FormatInt(userId, 10)
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+func (h *SessionRedisRepo) expireAt(s models.Session) int64 {
+	idle := s.LastSeenAt.Add(h.IdleTTL)
+	absolute := s.CreatedAt.Add(h.AbsoluteTTL)
+	if absolute.Before(idle) {
+		return toMillis(absolute)
+	}
+	return toMillis(idle)
+}
+
+func parseSession(token string, fields map[string]string) (models.Session, error) {
+	var ints [4]int64
+	for i, name := range []string{"id", "userId", "createdAt", "lastSeenAt"} {
+		v, err := strconv.ParseInt(fields[name], 10, 64)
+		if err != nil {
+			return models.Session{}, session.ErrInvalidToken
+		}
+		ints[i] = v
+	}
+	return models.Session{
+		Id:         ints[0],
+		UserId:     ints[1],
+		Token:      token,
+		UserAgent:  fields["userAgent"],
+		Ip:         fields["ip"],
+		CreatedAt:  fromMillis(ints[2]),
+		LastSeenAt: fromMillis(ints[3]),
+	}, nil
+}
+
+func getSession(conn redis.Conn, token string) (models.Session, error) {
+	fields, err := redis.StringMap(conn.Do("HGETALL", sessionKey(token)))
+	if err != nil {
+		return models.Session{}, err
+	}
+	if len(fields) == 0 {
+		return models.Session{}, session.ErrSessionNotFound
+	}
+	return parseSession(token, fields)
+}
+
+func (h *SessionRedisRepo) GetSession(token string) (models.Session, error) {
+	conn := h.redisPool.Get()
+	defer conn.Close()
+	return getSession(conn, token)
+}
+
+func (h *SessionRedisRepo) CreateSession(data models.Session) (string, error) {
+	conn := h.redisPool.Get()
+	defer conn.Close()
+	id, err := redis.Int64(conn.Do("INCR", sessionSeqKey))
+	if err != nil {
+		return "", err
+	}
+	token := uuid.New().String()
+	key := sessionKey(token)
+	uKey := userKey(data.UserId)
+	_ = conn.Send("MULTI")
+	_ = conn.Send("HSET", key,
+		"id", id,
+		"userId", data.UserId,
+		"userAgent", data.UserAgent,
+		"ip", data.Ip,
+		"createdAt", toMillis(data.CreatedAt),
+		"lastSeenAt", toMillis(data.LastSeenAt))
+	_ = conn.Send("PEXPIREAT", key, h.expireAt(data))
+	_ = conn.Send("HSET", uKey, id, token)
+	// the index outlives every session it lists
+	_ = conn.Send("PEXPIREAT", uKey, toMillis(data.CreatedAt.Add(h.AbsoluteTTL)))
+	_, err = conn.Do("EXEC")
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (h *SessionRedisRepo) TouchSession(token string, lastSeen time.Time) error {
+	conn := h.redisPool.Get()
+	defer conn.Close()
+	ok, err := redis.Int(touchScript.Do(conn, sessionKey(token), toMillis(lastSeen),
+		h.IdleTTL.Milliseconds(), h.AbsoluteTTL.Milliseconds()))
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return session.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (h *SessionRedisRepo) RemoveSession(token string) error {
+	conn := h.redisPool.Get()
+	defer conn.Close()
+	s, err := getSession(conn, token)
+	if err == session.ErrSessionNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.Send("MULTI")
+	_ = conn.Send("DEL", sessionKey(token))
+	_ = conn.Send("HDEL", userKey(s.UserId), s.Id)
+	_, err = conn.Do("EXEC")
+	return err
+}
+
+func (h *SessionRedisRepo) GetUserSessions(userId int64) ([]models.Session, error) {
+	conn := h.redisPool.Get()
+	defer conn.Close()
+	tokens, err := redis.StringMap(conn.Do("HGETALL", userKey(userId)))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.Session, 0, len(tokens))
+	for id, token := range tokens {
+		s, err := getSession(conn, token)
+		if err == session.ErrSessionNotFound {
+			_, _ = conn.Do("HDEL", userKey(userId), id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].LastSeenAt.Equal(res[j].LastSeenAt) {
+			return res[i].Id > res[j].Id
+		}
+		return res[i].LastSeenAt.After(res[j].LastSeenAt)
+	})
+	return res, nil
+}
+
+func (h *SessionRedisRepo) RemoveSessionById(userId int64, sessionId int64) error {
+	conn := h.redisPool.Get()
+	defer conn.Close()
+	token, err := redis.String(conn.Do("HGET", userKey(userId), sessionId))
+	if err == redis.ErrNil {
+		return session.ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.Send("MULTI")
+	_ = conn.Send("DEL", sessionKey(token))
+	_ = conn.Send("HDEL", userKey(userId), sessionId)
+	res, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return err
+	}
+	deleted, err := redis.Int(res[0], nil)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return session.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (h *SessionRedisRepo) RemoveUserSessions(userId int64, exceptToken string) error {
+	conn := h.redisPool.Get()
+	defer conn.Close()
+	tokens, err := redis.StringMap(conn.Do("HGETALL", userKey(userId)))
+	if err != nil {
+		return err
+	}
+	_ = conn.Send("MULTI")
+	for id, token := range tokens {
+		if token == exceptToken {
+			continue
+		}
+		_ = conn.Send("DEL", sessionKey(token))
+		_ = conn.Send("HDEL", userKey(userId), id)
+	}
+	_, err = conn.Do("EXEC")
+	return err
+}
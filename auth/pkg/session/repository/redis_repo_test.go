@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"konami_backend/auth/pkg/models"
+	"konami_backend/auth/pkg/session"
+	"testing"
+	"time"
+)
+
+type RedisSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	repository  session.Repository
+	now         time.Time
+}
+
+func (s *RedisSuite) SetupTest() {
+	var err error
+	s.redisServer, err = miniredis.Run()
+	require.NoError(s.T(), err)
+	s.now = time.Now().Truncate(time.Millisecond)
+	s.redisServer.SetTime(s.now)
+
+	addr := s.redisServer.Addr()
+	redisConn := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr)
+		},
+	}
+	s.repository = NewSessionRedisRepo(redisConn, 48*time.Hour, time.Hour)
+}
+
+func (s *RedisSuite) TearDownTest() {
+	s.redisServer.Close()
+}
+
+// advance moves both the server clock used by PEXPIREAT and the remaining TTLs
+func (s *RedisSuite) advance(d time.Duration) {
+	s.now = s.now.Add(d)
+	s.redisServer.SetTime(s.now)
+	s.redisServer.FastForward(d)
+}
+
+func (s *RedisSuite) newSession(userId int64, ua string) string {
+	token, err := s.repository.CreateSession(models.Session{
+		UserId:     userId,
+		UserAgent:  ua,
+		Ip:         "10.0.0.1",
+		CreatedAt:  s.now,
+		LastSeenAt: s.now,
+	})
+	require.NoError(s.T(), err)
+	return token
+}
+
+func (s *RedisSuite) TestCreateAndGet() {
+	token := s.newSession(1, "Firefox")
+
+	res, err := s.repository.GetSession(token)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), models.Session{
+		Id:         1,
+		UserId:     1,
+		Token:      token,
+		UserAgent:  "Firefox",
+		Ip:         "10.0.0.1",
+		CreatedAt:  s.now,
+		LastSeenAt: s.now,
+	}, res)
+
+	_, err = s.repository.GetSession("unknown")
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+
+	s.redisServer.Close()
+	_, err = s.repository.GetSession(token)
+	require.Error(s.T(), err)
+}
+
+func (s *RedisSuite) TestIdleExpiry() {
+	token := s.newSession(1, "Firefox")
+
+	s.advance(50 * time.Minute)
+	err := s.repository.TouchSession(token, s.now)
+	require.NoError(s.T(), err)
+
+	// the touch moved the idle deadline forward
+	s.advance(50 * time.Minute)
+	_, err = s.repository.GetSession(token)
+	require.NoError(s.T(), err)
+
+	s.advance(11 * time.Minute)
+	_, err = s.repository.GetSession(token)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+
+	err = s.repository.TouchSession(token, s.now)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+	require.False(s.T(), s.redisServer.Exists(sessionKey(token)))
+}
+
+func (s *RedisSuite) TestAbsoluteExpiry() {
+	token := s.newSession(1, "Firefox")
+
+	// touching never extends a session past its absolute deadline
+	for i := 0; i < 48; i++ {
+		s.advance(time.Hour - time.Minute)
+		_ = s.repository.TouchSession(token, s.now)
+	}
+	s.advance(49 * time.Minute)
+	_, err := s.repository.GetSession(token)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+}
+
+func (s *RedisSuite) TestUserSessions() {
+	first := s.newSession(1, "Firefox")
+	s.advance(time.Minute)
+	second := s.newSession(1, "Safari")
+	s.newSession(2, "Chrome")
+
+	res, err := s.repository.GetUserSessions(1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 2)
+	require.Equal(s.T(), second, res[0].Token)
+	require.Equal(s.T(), first, res[1].Token)
+
+	err = s.repository.RemoveSessionById(2, res[0].Id)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+
+	err = s.repository.RemoveSessionById(1, res[0].Id)
+	require.NoError(s.T(), err)
+	_, err = s.repository.GetSession(second)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+
+	err = s.repository.RemoveSessionById(1, res[0].Id)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+
+	// expired sessions drop out of the index
+	s.advance(2 * time.Hour)
+	res, err = s.repository.GetUserSessions(1)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), res)
+	require.False(s.T(), s.redisServer.Exists(userKey(1)))
+}
+
+func (s *RedisSuite) TestRemove() {
+	first := s.newSession(1, "Firefox")
+	second := s.newSession(1, "Safari")
+	third := s.newSession(1, "Chrome")
+
+	err := s.repository.RemoveSession(first)
+	require.NoError(s.T(), err)
+	_, err = s.repository.GetSession(first)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+
+	err = s.repository.RemoveSession(first)
+	require.NoError(s.T(), err)
+
+	err = s.repository.RemoveUserSessions(1, third)
+	require.NoError(s.T(), err)
+	_, err = s.repository.GetSession(second)
+	require.Equal(s.T(), session.ErrSessionNotFound, err)
+
+	res, err := s.repository.GetUserSessions(1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 1)
+	require.Equal(s.T(), third, res[0].Token)
+}
+
+func TestRedisSessions(t *testing.T) {
+	suite.Run(t, new(RedisSuite))
+}
@@ -6,7 +6,9 @@ package session
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	models "konami_backend/auth/pkg/models"
 	reflect "reflect"
+	time "time"
 )
 
 // MockRepository is a mock of Repository interface
@@ -32,34 +34,48 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
-// GetUserId mocks base method
-func (m *MockRepository) GetUserId(token string) (int64, error) {
+// GetSession mocks base method
+func (m *MockRepository) GetSession(token string) (models.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserId", token)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "GetSession", token)
+	ret0, _ := ret[0].(models.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserId indicates an expected call of GetUserId
-func (mr *MockRepositoryMockRecorder) GetUserId(token interface{}) *gomock.Call {
+// GetSession indicates an expected call of GetSession
+func (mr *MockRepositoryMockRecorder) GetSession(token interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserId", reflect.TypeOf((*MockRepository)(nil).GetUserId), token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockRepository)(nil).GetSession), token)
 }
 
 // CreateSession mocks base method
-func (m *MockRepository) CreateSession(userId int64) (string, error) {
+func (m *MockRepository) CreateSession(s models.Session) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSession", userId)
+	ret := m.ctrl.Call(m, "CreateSession", s)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateSession indicates an expected call of CreateSession
-func (mr *MockRepositoryMockRecorder) CreateSession(userId interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateSession(s interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockRepository)(nil).CreateSession), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockRepository)(nil).CreateSession), s)
+}
+
+// TouchSession mocks base method
+func (m *MockRepository) TouchSession(token string, lastSeen time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", token, lastSeen)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession
+func (mr *MockRepositoryMockRecorder) TouchSession(token, lastSeen interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockRepository)(nil).TouchSession), token, lastSeen)
 }
 
 // RemoveSession mocks base method
@@ -75,3 +91,46 @@ func (mr *MockRepositoryMockRecorder) RemoveSession(token interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSession", reflect.TypeOf((*MockRepository)(nil).RemoveSession), token)
 }
+
+// GetUserSessions mocks base method
+func (m *MockRepository) GetUserSessions(userId int64) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSessions", userId)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSessions indicates an expected call of GetUserSessions
+func (mr *MockRepositoryMockRecorder) GetUserSessions(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSessions", reflect.TypeOf((*MockRepository)(nil).GetUserSessions), userId)
+}
+
+// RemoveSessionById mocks base method
+func (m *MockRepository) RemoveSessionById(userId, sessionId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveSessionById", userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveSessionById indicates an expected call of RemoveSessionById
+func (mr *MockRepositoryMockRecorder) RemoveSessionById(userId, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSessionById", reflect.TypeOf((*MockRepository)(nil).RemoveSessionById), userId, sessionId)
+}
+
+// RemoveUserSessions mocks base method
+func (m *MockRepository) RemoveUserSessions(userId int64, exceptToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveUserSessions", userId, exceptToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveUserSessions indicates an expected call of RemoveUserSessions
+func (mr *MockRepositoryMockRecorder) RemoveUserSessions(userId, exceptToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUserSessions", reflect.TypeOf((*MockRepository)(nil).RemoveUserSessions), userId, exceptToken)
+}
@@ -1,8 +1,15 @@
 //go:generate mockgen -source=usecase.go -destination=./usecase_mock.go -package=session
 package session
 
+import "konami_backend/auth/pkg/models"
+
 type UseCase interface {
 	GetUserId(token string) (userId int64, err error)
-	CreateSession(userId int64) (token string, err error)
+	CreateSession(userId int64, userAgent string, ip string) (token string, err error)
 	RemoveSession(token string) error
+	// ListSessions returns the active sessions of the token owner, marking the current one
+	ListSessions(token string) ([]models.Session, error)
+	RevokeSession(userId int64, sessionId int64) error
+	// RevokeAll ends every session of the user except the one with exceptToken
+	RevokeAll(userId int64, exceptToken string) error
 }
@@ -1,25 +1,109 @@
 package usecase
 
 import (
+	"konami_backend/auth/pkg/models"
 	"konami_backend/auth/pkg/session"
+	"time"
+)
+
+const (
+	// DefAbsoluteTTL matches the authToken cookie lifetime
+	DefAbsoluteTTL = 30 * 24 * time.Hour
+	DefIdleTTL     = 7 * 24 * time.Hour
+	// RenewInterval limits how often last-seen is written on Check
+	RenewInterval = time.Minute
 )
 
 type SessionUseCase struct {
 	SessionRepo session.Repository
+	AbsoluteTTL time.Duration
+	IdleTTL     time.Duration
+	Now         func() time.Time
 }
 
 func NewSessionUseCase(SessionRepo session.Repository) session.UseCase {
-	return &SessionUseCase{SessionRepo: SessionRepo}
+	return &SessionUseCase{
+		SessionRepo: SessionRepo,
+		AbsoluteTTL: DefAbsoluteTTL,
+		IdleTTL:     DefIdleTTL,
+		Now:         time.Now,
+	}
+}
+
+func (uc SessionUseCase) expired(s models.Session, now time.Time) bool {
+	return now.Sub(s.CreatedAt) > uc.AbsoluteTTL || now.Sub(s.LastSeenAt) > uc.IdleTTL
+}
+
+// activeSession removes an expired session and slides the idle deadline of an active one
+func (uc SessionUseCase) activeSession(token string) (models.Session, error) {
+	s, err := uc.SessionRepo.GetSession(token)
+	if err != nil {
+		return models.Session{}, err
+	}
+	now := uc.Now()
+	if uc.expired(s, now) {
+		_ = uc.SessionRepo.RemoveSession(token)
+		return models.Session{}, session.ErrSessionExpired
+	}
+	if now.Sub(s.LastSeenAt) >= RenewInterval {
+		// A failed renewal only shortens the idle deadline, the request is still valid
+		if uc.SessionRepo.TouchSession(token, now) == nil {
+			s.LastSeenAt = now
+		}
+	}
+	return s, nil
 }
 
 func (uc SessionUseCase) GetUserId(token string) (userId int64, err error) {
-	return uc.SessionRepo.GetUserId(token)
+	s, err := uc.activeSession(token)
+	if err != nil {
+		return 0, err
+	}
+	return s.UserId, nil
 }
 
-func (uc SessionUseCase) CreateSession(userId int64) (token string, err error) {
-	return uc.SessionRepo.CreateSession(userId)
+func (uc SessionUseCase) CreateSession(userId int64, userAgent string, ip string) (token string, err error) {
+	now := uc.Now()
+	return uc.SessionRepo.CreateSession(models.Session{
+		UserId:     userId,
+		UserAgent:  userAgent,
+		Ip:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	})
 }
 
 func (uc SessionUseCase) RemoveSession(token string) error {
 	return uc.SessionRepo.RemoveSession(token)
 }
+
+func (uc SessionUseCase) ListSessions(token string) ([]models.Session, error) {
+	current, err := uc.activeSession(token)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := uc.SessionRepo.GetUserSessions(current.UserId)
+	if err != nil {
+		return nil, err
+	}
+	now := uc.Now()
+	res := make([]models.Session, 0, len(sessions))
+	for _, s := range sessions {
+		if s.Token == token {
+			s = current
+			s.Current = true
+		} else if uc.expired(s, now) {
+			continue
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func (uc SessionUseCase) RevokeSession(userId int64, sessionId int64) error {
+	return uc.SessionRepo.RemoveSessionById(userId, sessionId)
+}
+
+func (uc SessionUseCase) RevokeAll(userId int64, exceptToken string) error {
+	return uc.SessionRepo.RemoveUserSessions(userId, exceptToken)
+}
@@ -1,31 +1,129 @@
 package usecase
 
 import (
+	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"konami_backend/auth/pkg/models"
 	"konami_backend/auth/pkg/session"
+	"time"
 
 	"testing"
 )
 
 func TestTag(t *testing.T) {
+	now := time.Date(2020, 12, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
 	t.Run("TestOnUsedToken", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 		tagRepo := session.NewMockRepository(ctrl)
 		ta := NewSessionUseCase(tagRepo)
+		ta.(*SessionUseCase).Now = clock
 
-		tagRepo.EXPECT().GetUserId("gg")
-		_, err := ta.GetUserId("gg")
+		tagRepo.EXPECT().GetSession("gg").
+			Return(models.Session{UserId: 4, CreatedAt: now, LastSeenAt: now}, nil)
+		userId, err := ta.GetUserId("gg")
 		assert.NoError(t, err)
+		assert.Equal(t, int64(4), userId)
 
 		var testNumber int64 = 134
-		tagRepo.EXPECT().CreateSession(testNumber)
-		_, err = ta.CreateSession(testNumber)
+		tagRepo.EXPECT().CreateSession(models.Session{
+			UserId:     testNumber,
+			UserAgent:  "Firefox",
+			Ip:         "10.0.0.1",
+			CreatedAt:  now,
+			LastSeenAt: now,
+		})
+		_, err = ta.CreateSession(testNumber, "Firefox", "10.0.0.1")
 		assert.NoError(t, err)
 
 		tagRepo.EXPECT().RemoveSession("ggor")
 		err = ta.RemoveSession("ggor")
 		assert.NoError(t, err)
 	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		tagRepo := session.NewMockRepository(ctrl)
+		ta := &SessionUseCase{SessionRepo: tagRepo, AbsoluteTTL: 48 * time.Hour, IdleTTL: time.Hour, Now: clock}
+
+		tagRepo.EXPECT().GetSession("idle").
+			Return(models.Session{UserId: 4, CreatedAt: now, LastSeenAt: now.Add(-2 * time.Hour)}, nil)
+		tagRepo.EXPECT().RemoveSession("idle")
+		_, err := ta.GetUserId("idle")
+		assert.Equal(t, session.ErrSessionExpired, err)
+
+		tagRepo.EXPECT().GetSession("old").
+			Return(models.Session{UserId: 4, CreatedAt: now.Add(-49 * time.Hour), LastSeenAt: now}, nil)
+		tagRepo.EXPECT().RemoveSession("old")
+		_, err = ta.GetUserId("old")
+		assert.Equal(t, session.ErrSessionExpired, err)
+
+		tagRepo.EXPECT().GetSession("none").Return(models.Session{}, session.ErrSessionNotFound)
+		_, err = ta.GetUserId("none")
+		assert.Equal(t, session.ErrSessionNotFound, err)
+	})
+
+	t.Run("Renewal", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		tagRepo := session.NewMockRepository(ctrl)
+		ta := &SessionUseCase{SessionRepo: tagRepo, AbsoluteTTL: 48 * time.Hour, IdleTTL: time.Hour, Now: clock}
+
+		// Recently seen sessions are not written on every check
+		tagRepo.EXPECT().GetSession("fresh").
+			Return(models.Session{UserId: 4, CreatedAt: now, LastSeenAt: now.Add(-time.Second)}, nil)
+		_, err := ta.GetUserId("fresh")
+		assert.NoError(t, err)
+
+		tagRepo.EXPECT().GetSession("stale").
+			Return(models.Session{UserId: 4, CreatedAt: now, LastSeenAt: now.Add(-30 * time.Minute)}, nil)
+		tagRepo.EXPECT().TouchSession("stale", now).Return(errors.New("err"))
+		userId, err := ta.GetUserId("stale")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), userId)
+	})
+
+	t.Run("ListSessions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		tagRepo := session.NewMockRepository(ctrl)
+		ta := &SessionUseCase{SessionRepo: tagRepo, AbsoluteTTL: 48 * time.Hour, IdleTTL: time.Hour, Now: clock}
+
+		current := models.Session{Id: 1, UserId: 4, Token: "cur", CreatedAt: now, LastSeenAt: now.Add(-10 * time.Minute)}
+		other := models.Session{Id: 2, UserId: 4, Token: "other", CreatedAt: now, LastSeenAt: now.Add(-20 * time.Minute)}
+		expired := models.Session{Id: 3, UserId: 4, Token: "exp", CreatedAt: now, LastSeenAt: now.Add(-2 * time.Hour)}
+
+		tagRepo.EXPECT().GetSession("cur").Return(current, nil)
+		tagRepo.EXPECT().TouchSession("cur", now).Return(nil)
+		tagRepo.EXPECT().GetUserSessions(int64(4)).Return([]models.Session{current, other, expired}, nil)
+
+		res, err := ta.ListSessions("cur")
+		assert.NoError(t, err)
+		current.LastSeenAt = now
+		current.Current = true
+		assert.Equal(t, []models.Session{current, other}, res)
+
+		tagRepo.EXPECT().GetSession("bad").Return(models.Session{}, session.ErrSessionNotFound)
+		_, err = ta.ListSessions("bad")
+		assert.Equal(t, session.ErrSessionNotFound, err)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		tagRepo := session.NewMockRepository(ctrl)
+		ta := NewSessionUseCase(tagRepo)
+
+		tagRepo.EXPECT().RemoveSessionById(int64(4), int64(2)).Return(session.ErrSessionNotFound)
+		err := ta.RevokeSession(4, 2)
+		assert.Equal(t, session.ErrSessionNotFound, err)
+
+		tagRepo.EXPECT().RemoveUserSessions(int64(4), "cur").Return(nil)
+		err = ta.RevokeAll(4, "cur")
+		assert.NoError(t, err)
+	})
 }
@@ -6,6 +6,7 @@ package session
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	models "konami_backend/auth/pkg/models"
 	reflect "reflect"
 )
 
@@ -48,18 +49,18 @@ func (mr *MockUseCaseMockRecorder) GetUserId(token interface{}) *gomock.Call {
 }
 
 // CreateSession mocks base method
-func (m *MockUseCase) CreateSession(userId int64) (string, error) {
+func (m *MockUseCase) CreateSession(userId int64, userAgent, ip string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSession", userId)
+	ret := m.ctrl.Call(m, "CreateSession", userId, userAgent, ip)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateSession indicates an expected call of CreateSession
-func (mr *MockUseCaseMockRecorder) CreateSession(userId interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) CreateSession(userId, userAgent, ip interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockUseCase)(nil).CreateSession), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockUseCase)(nil).CreateSession), userId, userAgent, ip)
 }
 
 // RemoveSession mocks base method
@@ -75,3 +76,46 @@ func (mr *MockUseCaseMockRecorder) RemoveSession(token interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSession", reflect.TypeOf((*MockUseCase)(nil).RemoveSession), token)
 }
+
+// ListSessions mocks base method
+func (m *MockUseCase) ListSessions(token string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", token)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions
+func (mr *MockUseCaseMockRecorder) ListSessions(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockUseCase)(nil).ListSessions), token)
+}
+
+// RevokeSession mocks base method
+func (m *MockUseCase) RevokeSession(userId, sessionId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession
+func (mr *MockUseCaseMockRecorder) RevokeSession(userId, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockUseCase)(nil).RevokeSession), userId, sessionId)
+}
+
+// RevokeAll mocks base method
+func (m *MockUseCase) RevokeAll(userId int64, exceptToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAll", userId, exceptToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAll indicates an expected call of RevokeAll
+func (mr *MockUseCaseMockRecorder) RevokeAll(userId, exceptToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAll", reflect.TypeOf((*MockUseCase)(nil).RevokeAll), userId, exceptToken)
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"crypto/rand"
+	"github.com/gomodule/redigo/redis"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -9,18 +11,40 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	dialogDeliveryPkg "konami_backend/internal/pkg/dialog/delivery/http"
+	dialogRepoPkg "konami_backend/internal/pkg/dialog/repository"
+	dialogUseCasePkg "konami_backend/internal/pkg/dialog/usecase"
+	mailPkg "konami_backend/internal/pkg/mail"
+	mailRepoPkg "konami_backend/internal/pkg/mail/repository"
+	mailSenderPkg "konami_backend/internal/pkg/mail/sender"
+	mailUseCasePkg "konami_backend/internal/pkg/mail/usecase"
 	meetingDeliveryPkg "konami_backend/internal/pkg/meeting/delivery/http"
 	meetingRepoPkg "konami_backend/internal/pkg/meeting/repository"
 	meetingUseCasePkg "konami_backend/internal/pkg/meeting/usecase"
+	messagePkg "konami_backend/internal/pkg/message"
+	brokerPkg "konami_backend/internal/pkg/message/broker"
 	messageDeliveryPkg "konami_backend/internal/pkg/message/delivery/http"
 	messageRepoPkg "konami_backend/internal/pkg/message/repository"
 	messageUseCasePkg "konami_backend/internal/pkg/message/usecase"
 	"konami_backend/internal/pkg/middleware"
+	notificationPkg "konami_backend/internal/pkg/notification"
+	notificationDeliveryPkg "konami_backend/internal/pkg/notification/delivery/http"
+	notificationRepoPkg "konami_backend/internal/pkg/notification/repository"
+	notificationUseCasePkg "konami_backend/internal/pkg/notification/usecase"
 	profileDeliveryPkg "konami_backend/internal/pkg/profile/delivery/http"
+	profileNotifierPkg "konami_backend/internal/pkg/profile/notifier"
 	profileRepoPkg "konami_backend/internal/pkg/profile/repository"
 	profileUseCasePkg "konami_backend/internal/pkg/profile/usecase"
+	reminderPkg "konami_backend/internal/pkg/reminder"
+	reminderRepoPkg "konami_backend/internal/pkg/reminder/repository"
+	schedulerPkg "konami_backend/internal/pkg/reminder/scheduler"
+	senderPkg "konami_backend/internal/pkg/reminder/sender"
 	tagRepoPkg "konami_backend/internal/pkg/tag/repository"
+	authCachePkg "konami_backend/internal/pkg/utils/auth_cache"
 	corsInit "konami_backend/internal/pkg/utils/cors_init"
+	cursorPkg "konami_backend/internal/pkg/utils/cursor"
+	geocoderPkg "konami_backend/internal/pkg/utils/geocoder"
+	httpUtilsPkg "konami_backend/internal/pkg/utils/http_utils"
 	"konami_backend/internal/pkg/utils/token_handler"
 	uploadsHandlerPkg "konami_backend/internal/pkg/utils/uploads_handler"
 	loggerPkg "konami_backend/logger"
@@ -30,15 +54,20 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func InitDelivery(db *gorm.DB, log *loggerPkg.Logger, maxReqSize int64,
 	authClient authProto.AuthCheckerClient,
 	csrfClient csrfProto.CsrfDispatcherClient,
-	uploadsDir, meetPicsDir, userPicsDir, defMeetPic, defUserPic string) (
+	uploadsDir, meetPicsDir, userPicsDir, defMeetPic, defUserPic string,
+	cursorKey []byte, broker messagePkg.Broker, mailer mailPkg.UseCase) (
 	meetingDeliveryPkg.MeetingHandler,
 	profileDeliveryPkg.ProfileHandler,
 	messageDeliveryPkg.MessageHandler,
+	dialogDeliveryPkg.DialogHandler,
+	notificationDeliveryPkg.NotificationHandler,
 	token_handler.TokenHandler,
 	middleware.AuthMiddleware,
 	middleware.CSRFMiddleware,
@@ -49,41 +78,123 @@ func InitDelivery(db *gorm.DB, log *loggerPkg.Logger, maxReqSize int64,
 	meetingRepo := meetingRepoPkg.NewMeetingGormRepo(db, profileRepo)
 	tagRepo := tagRepoPkg.NewTagGormRepo(db)
 	msgRepo := messageRepoPkg.NewMeetingGormRepo(db)
+	dialogRepo := dialogRepoPkg.NewDialogGormRepo(db, profileRepo)
+	notificationRepo := notificationRepoPkg.NewNotificationGormRepo(db)
 	uploadsHandler := uploadsHandlerPkg.NewUploadsHandler(uploadsDir)
+	notificationUC := notificationUseCasePkg.NewNotificationUseCase(notificationRepo,
+		&notificationDeliveryPkg.BrokerPusher{Broker: broker, Log: log}, log)
 	meetingUC := meetingUseCasePkg.NewMeetingUseCase(
-		meetingRepo, uploadsHandler, tagRepo, meetPicsDir, defMeetPic)
-	profileUC := profileUseCasePkg.NewProfileUseCase(
-		profileRepo, uploadsHandler, tagRepo, userPicsDir, defUserPic)
-	msgUC := messageUseCasePkg.NewMessageUseCase(msgRepo)
+		meetingRepo, uploadsHandler, tagRepo,
+		notificationUseCasePkg.NewMeetingNotifier(notificationUC),
+		geocoderPkg.NewCityGeocoder(geocoderPkg.DefaultCities), log, meetPicsDir, defMeetPic)
+	resetLink := os.Getenv("PASSWORD_RESET_URL")
+	if resetLink == "" {
+		resetLink = "https://onmeet.ru/reset?token="
+	}
+	resetNotifier := &profileNotifierPkg.MailResetNotifier{
+		Mail:     mailer,
+		Lang:     os.Getenv("MAIL_LANG"),
+		LinkBase: resetLink,
+	}
+	verifyLink := os.Getenv("EMAIL_VERIFY_URL")
+	if verifyLink == "" {
+		verifyLink = "https://onmeet.ru/verify?token="
+	}
+	verifyNotifier := &profileNotifierPkg.MailVerificationNotifier{
+		Mail:     mailer,
+		Lang:     os.Getenv("MAIL_LANG"),
+		LinkBase: verifyLink,
+	}
+	profileUC := profileUseCasePkg.NewProfileUseCase(profileRepo, uploadsHandler, tagRepo,
+		notificationUC, resetNotifier, verifyNotifier, userPicsDir, defUserPic)
+	msgUC := messageUseCasePkg.NewMessageUseCase(msgRepo, meetingRepo, notificationUC)
+	dialogUC := dialogUseCasePkg.NewDialogUseCase(dialogRepo, profileRepo)
+	cursors := cursorPkg.NewSigner(cursorKey)
 	meetingDelivery := meetingDeliveryPkg.MeetingHandler{
 		MeetingUC:  meetingUC,
 		MaxReqSize: maxReqSize,
+		Cursors:    cursors,
+		Broker:     broker,
+		Log:        log,
 	}
 	profileDelivery := profileDeliveryPkg.ProfileHandler{
 		ProfileUC:  profileUC,
 		AuthClient: authClient,
 		MaxReqSize: maxReqSize,
+		Cursors:    cursors,
 	}
 	tokenHandler := token_handler.TokenHandler{CsrfClient: csrfClient, Log: log}
-	msgDelivery := messageDeliveryPkg.NewMessageHandler(msgUC, log, maxReqSize)
+	msgDelivery := messageDeliveryPkg.NewMessageHandler(msgUC, log, maxReqSize, cursors, corsInit.AllowedOrigins, broker)
+	dialogDelivery := dialogDeliveryPkg.DialogHandler{
+		DialogUC:    dialogUC,
+		Log:         log,
+		MaxReqSize:  maxReqSize,
+		Broker:      broker,
+		SendLimiter: msgDelivery.SendLimiter,
+	}
+	notificationDelivery := notificationDeliveryPkg.NotificationHandler{
+		NotificationUC: notificationUC,
+		Log:            log,
+		MaxReqSize:     maxReqSize,
+	}
 	authM := middleware.NewAuthMiddleware(profileUC, authClient)
 	csrfM := middleware.NewCsrfMiddleware(csrfClient, log)
 	logM := middleware.NewAccessLogMiddleware(log)
-	return meetingDelivery, profileDelivery, msgDelivery, tokenHandler, authM, csrfM, logM, nil
+	return meetingDelivery, profileDelivery, msgDelivery, dialogDelivery, notificationDelivery,
+		tokenHandler, authM, csrfM, logM, nil
+}
+
+// InitMail returns a queue that is only delivered when SMTP_ADDR is set
+func InitMail(db *gorm.DB, log *loggerPkg.Logger) *mailUseCasePkg.MailUseCase {
+	var sender mailPkg.Sender
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		sender = mailSenderPkg.NewSMTPSender(smtpAddr, os.Getenv("SMTP_FROM"),
+			os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"))
+	}
+	return mailUseCasePkg.NewMailUseCase(mailRepoPkg.NewMailGormRepo(db), sender, log)
+}
+
+func InitScheduler(db *gorm.DB, log *loggerPkg.Logger, emitter notificationPkg.Emitter,
+	mailer mailPkg.UseCase) (*schedulerPkg.Scheduler, error) {
+
+	offsetsConf := os.Getenv("REMINDER_OFFSETS")
+	if offsetsConf == "" {
+		offsetsConf = "24h,1h"
+	}
+	offsets, err := reminderPkg.ParseOffsets(offsetsConf)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := time.ParseDuration(os.Getenv("REMINDER_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = time.Minute
+	}
+	senders := []reminderPkg.Sender{&senderPkg.InAppSender{Emitter: emitter}}
+	if os.Getenv("SMTP_ADDR") != "" {
+		senders = append(senders, &senderPkg.EmailSender{Mail: mailer, Lang: os.Getenv("MAIL_LANG")})
+	}
+	if webhookURL := os.Getenv("REMINDER_WEBHOOK_URL"); webhookURL != "" {
+		senders = append(senders, senderPkg.NewWebhookSender(webhookURL))
+	}
+	repo := reminderRepoPkg.NewReminderGormRepo(db)
+	return schedulerPkg.NewScheduler(repo, senders, offsets, interval, log), nil
 }
 
 func InitRouter(
 	meeting meetingDeliveryPkg.MeetingHandler,
 	profile profileDeliveryPkg.ProfileHandler,
 	message messageDeliveryPkg.MessageHandler,
+	dialog dialogDeliveryPkg.DialogHandler,
+	notification notificationDeliveryPkg.NotificationHandler,
 	token token_handler.TokenHandler,
 	authM middleware.AuthMiddleware,
 	csrfM middleware.CSRFMiddleware,
 	logM middleware.AccessLogMiddleware,
-	panicM middleware.PanicMiddleware) http.Handler {
+	panicM middleware.PanicMiddleware,
+	rateM middleware.RateLimitMiddleware) http.Handler {
 
 	r := mux.NewRouter()
-	r.HandleFunc("/api/ws", message.Upgrade)
+	r.Handle("/api/ws", authM.Auth(http.HandlerFunc(message.Upgrade)))
 	rApi := mux.NewRouter()
 	r.PathPrefix("/api/").Handler(http.StripPrefix("/api", rApi))
 	rApi.HandleFunc("/people", profile.GetPeople).Methods("GET")
@@ -92,8 +203,9 @@ func InitRouter(
 	rApi.HandleFunc("/unsubscribe", profile.RemoveUserSubscription).Methods("DELETE")
 
 	rApi.HandleFunc("/user", profile.GetUser).Methods("GET")
-	rApi.HandleFunc("/signup", profile.SignUp).Methods("POST")
-	rApi.HandleFunc("/login", profile.LogIn).Methods("POST")
+	rApi.HandleFunc("/signup", rateM.LimitIP(rateM.SignUpByIP, profile.SignUp)).Methods("POST")
+	rApi.HandleFunc("/login", rateM.LimitIP(rateM.LogInByIP,
+		rateM.LimitLogin(rateM.LogInByLogin, profile.LogIn))).Methods("POST")
 	rApi.HandleFunc("/csrf", token.GetCSRF).Methods("GET")
 
 	rApi.HandleFunc("/meeting", meeting.GetMeeting).Methods("GET")
@@ -105,22 +217,67 @@ func InitRouter(
 	rApi.HandleFunc("/meetings/tagged", meeting.GetTaggedMeetings).Methods("GET")
 	rApi.HandleFunc("/meetings/akin", meeting.GetAkinMeetings).Methods("GET")
 	rApi.HandleFunc("/meetings/search", meeting.SearchMeetings).Methods("GET")
+	rApi.HandleFunc("/meetings/nearby", meeting.GetNearbyMeetings).Methods("GET")
+	rApi.HandleFunc("/meetings/query", meeting.QueryMeetings).Methods("GET")
 	rApi.HandleFunc("/meetings/subs/registered", meeting.GetSubsMeetingsList).Methods("GET")
 	rApi.HandleFunc("/meetings/subs/favorite", meeting.GetSubsFavMeetingsList).Methods("GET")
+	rApi.HandleFunc("/meeting.ics", meeting.GetMeetingCalendar).Methods("GET")
+	rApi.HandleFunc("/calendar.ics", meeting.GetFeedCalendar).Methods("GET")
 
 	rApi.HandleFunc("/me", profile.GetUserId).Methods("GET")
 	rApi.HandleFunc("/logout", profile.LogOut).Methods("DELETE")
-	rApi.HandleFunc("/meeting", meeting.CreateMeeting).Methods("POST")
+	rApi.HandleFunc("/sessions", profile.GetSessions).Methods("GET")
+	rApi.HandleFunc("/sessions", profile.RevokeOtherSessions).Methods("DELETE")
+	rApi.HandleFunc("/session", profile.RevokeSession).Methods("DELETE")
+	rApi.HandleFunc("/password", profile.ChangePassword).Methods("POST")
+	rApi.HandleFunc("/password/reset", rateM.LimitIP(rateM.ResetByIP,
+		rateM.LimitReset(rateM.ResetByLogin, profile.RequestPasswordReset))).Methods("POST")
+	rApi.HandleFunc("/password/reset/confirm",
+		rateM.LimitIP(rateM.TokenByIP, profile.ConfirmPasswordReset)).Methods("POST")
+	rApi.HandleFunc("/email", profile.GetEmail).Methods("GET")
+	rApi.HandleFunc("/email", profile.SetEmail).Methods("PUT")
+	rApi.HandleFunc("/email/resend", profile.ResendVerification).Methods("POST")
+	rApi.HandleFunc("/email/verify", rateM.LimitIP(rateM.TokenByIP, profile.VerifyEmail)).Methods("POST")
+	rApi.HandleFunc("/meeting", authM.RequireVerified(meeting.CreateMeeting)).Methods("POST")
 	rApi.HandleFunc("/meeting", meeting.UpdateMeeting).Methods("PATCH")
+	rApi.HandleFunc("/meeting", meeting.DeleteMeeting).Methods("DELETE")
+	rApi.HandleFunc("/meeting/cancel", meeting.CancelMeeting).Methods("POST")
+	rApi.HandleFunc("/meeting/organizers", meeting.AddOrganizer).Methods("POST")
+	rApi.HandleFunc("/meeting/organizers", meeting.RemoveOrganizer).Methods("DELETE")
+	rApi.HandleFunc("/meeting/waitlist", meeting.GetWaitlist).Methods("GET")
+	rApi.HandleFunc("/meeting/waitlist", meeting.MoveInWaitlist).Methods("PATCH")
+	rApi.HandleFunc("/meeting/requests", meeting.GetRegRequests).Methods("GET")
+	rApi.HandleFunc("/meeting/requests/approve", meeting.ApproveReg).Methods("POST")
+	rApi.HandleFunc("/meeting/requests/reject", meeting.RejectReg).Methods("POST")
+	rApi.HandleFunc("/meetings/feed", meeting.GetFeedToken).Methods("GET")
+	rApi.HandleFunc("/meetings/feed", meeting.RevokeFeedToken).Methods("DELETE")
 	rApi.HandleFunc("/user", profile.EditUser).Methods("PATCH")
 	rApi.HandleFunc("/images", profile.UploadUserPic).Methods("POST")
 
 	r.Handle("/metrics", promhttp.Handler())
 
 	rApi.HandleFunc("/messages", message.GetMessages).Methods("GET")
+	rApi.HandleFunc("/messages/unread", message.GetUnreadCounts).Methods("GET")
+	rApi.HandleFunc("/messages/read", message.MarkRead).Methods("POST")
 	rApi.HandleFunc("/message", message.SendMessage).Methods("POST")
+	rApi.HandleFunc("/message", message.EditMessage).Methods("PATCH")
+	rApi.HandleFunc("/message", message.DeleteMessage).Methods("DELETE")
+	rApi.HandleFunc("/message/reactions", message.AddReaction).Methods("POST")
+	rApi.HandleFunc("/message/reactions", message.RemoveReaction).Methods("DELETE")
 	go message.ServeWS()
 
+	rApi.HandleFunc("/dialogs", dialog.GetConversations).Methods("GET")
+	rApi.HandleFunc("/dialog", dialog.GetMessages).Methods("GET")
+	rApi.HandleFunc("/dialog/message", dialog.SendMessage).Methods("POST")
+	rApi.HandleFunc("/blocked", dialog.GetBlocked).Methods("GET")
+	rApi.HandleFunc("/block", dialog.Block).Methods("POST")
+	rApi.HandleFunc("/unblock", dialog.Unblock).Methods("DELETE")
+
+	rApi.HandleFunc("/notifications", notification.GetNotifications).Methods("GET")
+	rApi.HandleFunc("/notifications/read", notification.MarkRead).Methods("POST")
+	rApi.HandleFunc("/notifications/settings", notification.GetSettings).Methods("GET")
+	rApi.HandleFunc("/notifications/settings", notification.SetSettings).Methods("PUT")
+
 	r.Use(panicM.PanicRecovery)
 	r.Use(middleware.HeadersMiddleware)
 	rApi.Use(logM.Log)
@@ -160,6 +317,10 @@ func Start() {
 	}
 	defer authConn.Close()
 	authClient := authProto.NewAuthCheckerClient(authConn)
+	// AUTH_CACHE_TTL trades how fast revocations from other instances apply for fewer Check calls
+	if cacheTTL, err := time.ParseDuration(os.Getenv("AUTH_CACHE_TTL")); err == nil && cacheTTL > 0 {
+		authClient = authCachePkg.NewCachedAuthClient(authClient, cacheTTL)
+	}
 
 	csrfAddr := os.Getenv("CSRF_ADDR")
 	if csrfAddr == "" {
@@ -181,17 +342,76 @@ func Start() {
 		maxReqSize = 10 * 1024 * 1024
 	}
 
-	meeting, profile, msg, token, authM, csrfM, logM, err := InitDelivery(
+	// TRUSTED_PROXIES lists the balancers, as comma separated addresses or CIDRs, whose
+	// forwarding headers carry the client IP for rate limits and sessions
+	if err := httpUtilsPkg.SetTrustedProxies(strings.Split(os.Getenv("TRUSTED_PROXIES"), ",")); err != nil {
+		logger.Fatalf("failed to parse TRUSTED_PROXIES: %v", err)
+		return
+	}
+
+	// Every replica has to sign cursors with the same key, a random one is only fine for a single dev instance
+	cursorKey := []byte(os.Getenv("CURSOR_SECRET"))
+	if len(cursorKey) == 0 {
+		if os.Getenv("APP_ENV") != "dev" {
+			logger.Fatalf("CURSOR_SECRET is not set")
+			return
+		}
+		logger.LogWarning("server", "Start", "CURSOR_SECRET is not set, page cursors will not survive a restart")
+		cursorKey = make([]byte, 32)
+		if _, err = rand.Read(cursorKey); err != nil {
+			logger.Fatalf("failed to generate cursor key: %v", err)
+		}
+	}
+
+	var broker messagePkg.Broker
+	var redisPool *redis.Pool
+	if redisAddr := os.Getenv("REDIS_CONN"); redisAddr != "" {
+		redisPool = &redis.Pool{
+			MaxIdle:   10,
+			MaxActive: 100,
+			Wait:      true,
+			Dial: func() (redis.Conn, error) {
+				return redis.DialURL(redisAddr)
+			},
+		}
+		defer redisPool.Close()
+		broker = brokerPkg.NewRedisBroker(redisPool, brokerPkg.ChatChannel, logger)
+	} else {
+		logger.LogWarning("server", "Start", "REDIS_CONN is not set, chat and rate limits are limited to a single instance")
+		broker = brokerPkg.NewMemoryBroker()
+	}
+	defer broker.Close()
+
+	mailer := InitMail(db, logger)
+	stopMail := make(chan struct{})
+	defer close(stopMail)
+	if mailer.Sender != nil {
+		go mailer.Run(time.Minute, stopMail)
+	} else {
+		logger.LogWarning("server", "Start", "SMTP_ADDR is not set, emails stay in the queue")
+	}
+
+	meeting, profile, msg, dialog, notification, token, authM, csrfM, logM, err := InitDelivery(
 		db, logger, maxReqSize, authClient, csrfClient,
 		"uploads", "meetingpics", "userpics",
-		"assets/paris.jpg", "assets/empty-avatar.jpeg")
+		"assets/paris.jpg", "assets/empty-avatar.jpeg", cursorKey, broker, mailer)
 	if err != nil {
 		logger.Fatalf("failed to init delivery: %v", err)
 		return
 	}
 
+	scheduler, err := InitScheduler(db, logger, notification.NotificationUC, mailer)
+	if err != nil {
+		logger.Fatalf("failed to init reminders: %v", err)
+		return
+	}
+	stopScheduler := make(chan struct{})
+	defer close(stopScheduler)
+	go scheduler.Run(stopScheduler)
+
 	panicM := middleware.NewPanicMiddleware(logger)
-	r := InitRouter(meeting, profile, msg, token, authM, csrfM, logM, panicM)
+	rateM := middleware.NewRateLimitMiddleware(redisPool, profile.ProfileUC, maxReqSize)
+	r := InitRouter(meeting, profile, msg, dialog, notification, token, authM, csrfM, logM, panicM, rateM)
 	c := corsInit.InitCors()
 	h := c.Handler(r)
 
@@ -245,10 +465,27 @@ func Migrate() {
 		&profileRepoPkg.InterestTag{},
 		&profileRepoPkg.Profile{},
 		&profileRepoPkg.Subscription{},
+		&profileRepoPkg.PasswordReset{},
+		&profileRepoPkg.EmailVerification{},
 		&meetingRepoPkg.Registration{},
 		&meetingRepoPkg.Like{},
+		&meetingRepoPkg.Organizer{},
+		&meetingRepoPkg.WaitlistEntry{},
+		&meetingRepoPkg.RegRequest{},
+		&meetingRepoPkg.Series{},
+		&meetingRepoPkg.FeedToken{},
 		&meetingRepoPkg.Meeting{},
 		&messageRepoPkg.Message{},
+		&messageRepoPkg.Reaction{},
+		&messageRepoPkg.ReadMarker{},
+		&dialogRepoPkg.Conversation{},
+		&dialogRepoPkg.DirectMessage{},
+		&dialogRepoPkg.Block{},
+		&notificationRepoPkg.Notification{},
+		&notificationRepoPkg.Mute{},
+		&reminderRepoPkg.Delivery{},
+		&reminderRepoPkg.Lease{},
+		&mailRepoPkg.QueuedMail{},
 	)
 	if err != nil {
 		log.Fatalf("failed to migrate db: %v", err)
@@ -297,10 +534,27 @@ func Truncate() {
 	db.Exec("DELETE FROM meeting_tags")
 	db.Exec("DELETE FROM registrations")
 	db.Exec("DELETE FROM likes")
+	db.Exec("DELETE FROM organizers")
+	db.Exec("DELETE FROM waitlist")
+	db.Exec("DELETE FROM reg_requests")
+	db.Exec("DELETE FROM meeting_series")
+	db.Exec("DELETE FROM feed_tokens")
 	db.Exec("DELETE FROM meetings")
+	db.Exec("DELETE FROM password_resets")
+	db.Exec("DELETE FROM email_verifications")
 	db.Exec("DELETE FROM profiles")
 	db.Exec("DELETE FROM tags")
+	db.Exec("DELETE FROM message_reactions")
+	db.Exec("DELETE FROM message_reads")
 	db.Exec("DELETE FROM messages")
+	db.Exec("DELETE FROM direct_messages")
+	db.Exec("DELETE FROM conversations")
+	db.Exec("DELETE FROM blocks")
+	db.Exec("DELETE FROM notifications")
+	db.Exec("DELETE FROM notification_mutes")
+	db.Exec("DELETE FROM reminder_deliveries")
+	db.Exec("DELETE FROM scheduler_leases")
+	db.Exec("DELETE FROM mail_queue")
 	db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&profileRepoPkg.InterestTag{})
 	db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&profileRepoPkg.SkillTag{})
 	db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&profileRepoPkg.Subscription{})
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"konami_backend/internal/pkg/dialog"
+	"konami_backend/internal/pkg/message"
+	"konami_backend/internal/pkg/middleware"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/profile"
+	hu "konami_backend/internal/pkg/utils/http_utils"
+	"konami_backend/internal/pkg/utils/ratelimit"
+	"konami_backend/logger"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+type DialogHandler struct {
+	DialogUC   dialog.UseCase
+	Log        *logger.Logger
+	MaxReqSize int64
+	Broker     message.Broker
+	// SendLimiter is shared with the meeting chat so both count towards one budget
+	SendLimiter *ratelimit.Limiter
+}
+
+const (
+	DefMessageLimit = 50
+	MaxMessageLimit = 100
+)
+
+func (h *DialogHandler) GetConversations(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	convs, err := h.DialogUC.GetConversations(userId)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	hu.WriteJson(w, convs)
+}
+
+func (h *DialogHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	peerId, err := strconv.Atoi(r.URL.Query().Get("userId"))
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	params := dialog.FilterParams{UserId: userId, PeerId: peerId}
+	params.Before, _ = strconv.Atoi(r.URL.Query().Get("before"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = DefMessageLimit
+	}
+	if limit > MaxMessageLimit {
+		limit = MaxMessageLimit
+	}
+	params.CountLimit = limit + 1
+	messages, err := h.DialogUC.GetMessages(params)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	page := models.DirectMessagePage{Messages: messages}
+	// Older messages come first, so the extra one is at the head
+	if len(messages) > limit {
+		page.Messages, page.HasMore = messages[1:], true
+	}
+	if page.Messages == nil {
+		page.Messages = []models.DirectMessage{}
+	}
+	hu.WriteJson(w, page)
+}
+
+func (h *DialogHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	if ok, wait := h.SendLimiter.Allow(strconv.Itoa(userId)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusTooManyRequests, ErrMsg: "too many messages"})
+		return
+	}
+	msg := &models.DirectMessage{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = msg.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	msg.AuthorId = userId
+	sent, err := h.DialogUC.SendMessage(*msg)
+	if err != nil {
+		h.writeDialogError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	hu.WriteJson(w, sent)
+	// The author's other sessions get the message too
+	go h.Publish(sent.RecipientId, "directMessage", sent)
+	go h.Publish(sent.AuthorId, "directMessage", sent)
+}
+
+func (h *DialogHandler) Block(w http.ResponseWriter, r *http.Request) {
+	h.changeBlock(w, r, h.DialogUC.Block)
+}
+
+func (h *DialogHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	h.changeBlock(w, r, h.DialogUC.Unblock)
+}
+
+func (h *DialogHandler) changeBlock(w http.ResponseWriter, r *http.Request,
+	change func(userId int, targetId int) error) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	req := &models.UserBlock{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = req.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = change(userId, req.TargetId)
+	if err != nil {
+		h.writeDialogError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *DialogHandler) GetBlocked(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	blocked, err := h.DialogUC.GetBlocked(userId)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	hu.WriteJson(w, blocked)
+}
+
+func (h *DialogHandler) writeDialogError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, profile.ErrUserNonExistent):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+	case errors.Is(err, dialog.ErrBlocked):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusForbidden, ErrMsg: err.Error()})
+	case errors.Is(err, dialog.ErrSelfDialog), errors.Is(err, message.ErrEmptyMessage),
+		errors.Is(err, message.ErrMessageTooLong):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+	default:
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+	}
+}
+
+// Publish sends an event to every connection of the user on every server instance
+func (h *DialogHandler) Publish(userId int, msgType string, payload interface{}) {
+	data, err := message.EncodeFrame(msgType, payload)
+	if err == nil {
+		err = h.Broker.Publish(message.Event{UserId: userId, Data: data})
+	}
+	if err != nil {
+		h.Log.LogError("dialog/delivery/http", "Publish", err)
+	}
+}
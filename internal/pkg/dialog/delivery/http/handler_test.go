@@ -0,0 +1,237 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"konami_backend/internal/pkg/dialog"
+	"konami_backend/internal/pkg/message"
+	"konami_backend/internal/pkg/message/broker"
+	"konami_backend/internal/pkg/middleware"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/profile"
+	"konami_backend/internal/pkg/utils/ratelimit"
+	"konami_backend/logger"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDialogs(t *testing.T) {
+	log := logger.NewLogger(ioutil.Discard)
+	var authArgs []middleware.RouteArgs
+	authArgs = append(authArgs, middleware.RouteArgs{Key: middleware.UserID, Value: 5})
+	authArgs = append(authArgs, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+	t.Run("SendMessage", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := dialog.NewMockUseCase(ctrl)
+		shared := broker.NewMemoryBroker()
+		h := DialogHandler{DialogUC: m, Log: log, MaxReqSize: 10000, Broker: shared,
+			SendLimiter: ratelimit.NewLimiter(10, time.Minute)}
+		events := make(chan message.Event, 2)
+		require.NoError(t, shared.Subscribe(func(e message.Event) { events <- e }))
+
+		sent := models.DirectMessage{Id: 7, AuthorId: 5, RecipientId: 2, Text: "hi",
+			Timestamp: "2020-12-05T10:00:00.000Z"}
+		m.EXPECT().SendMessage(models.DirectMessage{AuthorId: 5, RecipientId: 2, Text: "hi"}).Return(sent, nil)
+		sentJSON, _ := json.Marshal(sent)
+
+		apitest.New("SendDirectMessage").
+			Handler(middleware.SetMuxVars(h.SendMessage, authArgs)).
+			Method("Post").
+			URL("/dialog/message").
+			Body(`{"recipientId": 2, "text": "hi"}`).
+			Expect(t).
+			Status(http.StatusCreated).
+			Body(string(sentJSON)).
+			End()
+
+		recipients := map[int]bool{}
+		for i := 0; i < 2; i++ {
+			select {
+			case e := <-events:
+				var event struct {
+					Payload models.DirectMessage `json:"payload"`
+					MsgType string               `json:"type"`
+				}
+				require.NoError(t, json.Unmarshal(e.Data, &event))
+				require.Equal(t, "directMessage", event.MsgType)
+				require.Equal(t, 7, event.Payload.Id)
+				recipients[e.UserId] = true
+			case <-time.After(time.Second):
+				t.Fatal("direct message was not published")
+			}
+		}
+		require.Equal(t, map[int]bool{2: true, 5: true}, recipients)
+
+		m.EXPECT().SendMessage(gomock.Any()).Return(models.DirectMessage{}, dialog.ErrBlocked)
+		apitest.New("SendBlocked").
+			Handler(middleware.SetMuxVars(h.SendMessage, authArgs)).
+			Method("Post").
+			URL("/dialog/message").
+			Body(`{"recipientId": 3, "text": "hi"}`).
+			Expect(t).
+			Status(http.StatusForbidden).
+			End()
+
+		m.EXPECT().SendMessage(gomock.Any()).Return(models.DirectMessage{}, profile.ErrUserNonExistent)
+		apitest.New("SendToNobody").
+			Handler(middleware.SetMuxVars(h.SendMessage, authArgs)).
+			Method("Post").
+			URL("/dialog/message").
+			Body(`{"recipientId": 404, "text": "hi"}`).
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+
+		apitest.New("SendNoCSRF").
+			Handler(middleware.SetMuxVars(h.SendMessage, authArgs[:1])).
+			Method("Post").
+			URL("/dialog/message").
+			Body(`{"recipientId": 2, "text": "hi"}`).
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("SendMessageLimits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := dialog.NewMockUseCase(ctrl)
+		h := DialogHandler{DialogUC: m, Log: log, MaxReqSize: 10000, Broker: broker.NewMemoryBroker(),
+			SendLimiter: ratelimit.NewLimiter(1, time.Minute)}
+
+		m.EXPECT().SendMessage(gomock.Any()).Return(models.DirectMessage{}, message.ErrMessageTooLong)
+		apitest.New("SendTooLong").
+			Handler(middleware.SetMuxVars(h.SendMessage, authArgs)).
+			Method("Post").
+			URL("/dialog/message").
+			Body(`{"recipientId": 2, "text": "hi"}`).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		apitest.New("SendTooFast").
+			Handler(middleware.SetMuxVars(h.SendMessage, authArgs)).
+			Method("Post").
+			URL("/dialog/message").
+			Body(`{"recipientId": 2, "text": "hi"}`).
+			Expect(t).
+			Status(http.StatusTooManyRequests).
+			Header("Retry-After", "60").
+			End()
+	})
+
+	t.Run("GetMessages", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := dialog.NewMockUseCase(ctrl)
+		h := DialogHandler{DialogUC: m, Log: log}
+
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "userId", Value: "2"})
+		args = append(args, middleware.QueryArgs{Key: "before", Value: "9"})
+		args = append(args, middleware.QueryArgs{Key: "limit", Value: "2"})
+
+		msgs := []models.DirectMessage{{Id: 4}, {Id: 6}, {Id: 8}}
+		m.EXPECT().GetMessages(dialog.FilterParams{UserId: 5, PeerId: 2, Before: 9, CountLimit: 3}).Return(msgs, nil)
+		pageJSON, _ := json.Marshal(models.DirectMessagePage{Messages: msgs[1:], HasMore: true})
+
+		apitest.New("GetDirectMessages").
+			Handler(middleware.SetVarsAndMux(h.GetMessages, args, authArgs)).
+			Method("Get").
+			URL("/dialog").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(pageJSON)).
+			End()
+
+		apitest.New("GetDirectMessagesBadPeer").
+			Handler(middleware.SetVarsAndMux(h.GetMessages, nil, authArgs)).
+			Method("Get").
+			URL("/dialog").
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("Conversations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := dialog.NewMockUseCase(ctrl)
+		h := DialogHandler{DialogUC: m, Log: log}
+
+		convs := []models.Conversation{{Peer: models.ProfileLabel{Id: 2}, Unread: 3,
+			LastMessage: &models.DirectMessage{Id: 8, AuthorId: 2, RecipientId: 5}}}
+		m.EXPECT().GetConversations(5).Return(convs, nil)
+		convsJSON, _ := json.Marshal(convs)
+
+		apitest.New("GetConversations").
+			Handler(middleware.SetMuxVars(h.GetConversations, authArgs)).
+			Method("Get").
+			URL("/dialogs").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(convsJSON)).
+			End()
+
+		apitest.New("GetConversationsUnauthorized").
+			Handler(middleware.SetMuxVars(h.GetConversations, nil)).
+			Method("Get").
+			URL("/dialogs").
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("Blocks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := dialog.NewMockUseCase(ctrl)
+		h := DialogHandler{DialogUC: m, Log: log, MaxReqSize: 10000}
+
+		m.EXPECT().Block(5, 2).Return(nil)
+		apitest.New("Block").
+			Handler(middleware.SetMuxVars(h.Block, authArgs)).
+			Method("Post").
+			URL("/block").
+			Body(`{"targetId": 2}`).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		m.EXPECT().Block(5, 5).Return(dialog.ErrSelfDialog)
+		apitest.New("BlockSelf").
+			Handler(middleware.SetMuxVars(h.Block, authArgs)).
+			Method("Post").
+			URL("/block").
+			Body(`{"targetId": 5}`).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		m.EXPECT().Unblock(5, 2).Return(nil)
+		apitest.New("Unblock").
+			Handler(middleware.SetMuxVars(h.Unblock, authArgs)).
+			Method("Delete").
+			URL("/unblock").
+			Body(`{"targetId": 2}`).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		m.EXPECT().GetBlocked(5).Return([]models.ProfileLabel{{Id: 2, Name: "Ann"}}, nil)
+		apitest.New("GetBlocked").
+			Handler(middleware.SetMuxVars(h.GetBlocked, authArgs)).
+			Method("Get").
+			URL("/blocked").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(`[{"id": 2, "name": "Ann", "imgSrc": ""}]`).
+			End()
+	})
+}
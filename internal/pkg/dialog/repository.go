@@ -0,0 +1,28 @@
+//go:generate mockgen -source=repository.go -destination=./repositoty_mock.go -package=dialog
+package dialog
+
+import (
+	"errors"
+	"konami_backend/internal/pkg/models"
+)
+
+var ErrBlocked = errors.New("recipient has blocked you")
+var ErrSelfDialog = errors.New("cannot message yourself")
+
+type FilterParams struct {
+	UserId     int
+	PeerId     int
+	Before     int
+	CountLimit int
+}
+
+type Repository interface {
+	SaveMessage(msg models.DirectMessage) (int, error)
+	GetMessages(params FilterParams) ([]models.DirectMessage, error)
+	GetConversations(userId int) ([]models.Conversation, error)
+	MarkRead(userId int, peerId int, msgId int) error
+	Block(userId int, targetId int) error
+	Unblock(userId int, targetId int) error
+	IsBlocked(userId int, targetId int) (bool, error)
+	GetBlocked(userId int) ([]models.ProfileLabel, error)
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"konami_backend/internal/pkg/dialog"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/profile"
+	"sort"
+	"time"
+)
+
+type DialogGormRepo struct {
+	db       *gorm.DB
+	profRepo profile.Repository
+}
+
+func NewDialogGormRepo(db *gorm.DB, profileRepo profile.Repository) dialog.Repository {
+	return &DialogGormRepo{db: db, profRepo: profileRepo}
+}
+
+// Conversation is stored once per pair of users, FirstId < SecondId
+type Conversation struct {
+	Id           int `gorm:"primaryKey;autoIncrement;"`
+	FirstId      int `gorm:"uniqueIndex:conversation_pair;"`
+	SecondId     int `gorm:"uniqueIndex:conversation_pair;"`
+	FirstReadId  int
+	SecondReadId int
+}
+
+func (c *Conversation) TableName() string {
+	return "conversations"
+}
+
+type DirectMessage struct {
+	Id             int `gorm:"primaryKey;autoIncrement;"`
+	ConversationId int `gorm:"index"`
+	AuthorId       int
+	RecipientId    int
+	Text           string
+	Timestamp      time.Time
+}
+
+func (m *DirectMessage) TableName() string {
+	return "direct_messages"
+}
+
+type Block struct {
+	Id       int `gorm:"primaryKey;autoIncrement;"`
+	UserId   int `gorm:"uniqueIndex:block_user_target;"`
+	TargetId int `gorm:"uniqueIndex:block_user_target;"`
+}
+
+func (b *Block) TableName() string {
+	return "blocks"
+}
+
+func pair(userId, peerId int) (int, int) {
+	if userId < peerId {
+		return userId, peerId
+	}
+	return peerId, userId
+}
+
+func ToModel(obj DirectMessage) models.DirectMessage {
+	return models.DirectMessage{
+		Id:          obj.Id,
+		AuthorId:    obj.AuthorId,
+		RecipientId: obj.RecipientId,
+		Text:        obj.Text,
+		Timestamp:   obj.Timestamp.Format("2006-01-02T15:04:05.000Z0700"),
+	}
+}
+
+func ToDbObject(m models.DirectMessage) (DirectMessage, error) {
+	res := DirectMessage{
+		AuthorId:    m.AuthorId,
+		RecipientId: m.RecipientId,
+		Text:        m.Text,
+	}
+	var err error
+	res.Timestamp, err = time.Parse("2006-01-02T15:04:05.000Z0700", m.Timestamp)
+	return res, err
+}
+
+func (h *DialogGormRepo) SaveMessage(msg models.DirectMessage) (int, error) {
+	m, err := ToDbObject(msg)
+	if err != nil {
+		return 0, err
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		first, second := pair(msg.AuthorId, msg.RecipientId)
+		conv := Conversation{FirstId: first, SecondId: second}
+		db := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&conv)
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			db = tx.Where("first_id = ?", first).Where("second_id = ?", second).First(&conv)
+			if db.Error != nil {
+				return db.Error
+			}
+		}
+		m.ConversationId = conv.Id
+		return tx.Create(&m).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return m.Id, nil
+}
+
+func (h *DialogGormRepo) conversationQuery(userId, peerId int) *gorm.DB {
+	first, second := pair(userId, peerId)
+	return h.db.Model(&Conversation{}).Select("id").
+		Where("first_id = ?", first).
+		Where("second_id = ?", second)
+}
+
+func (h *DialogGormRepo) GetMessages(params dialog.FilterParams) ([]models.DirectMessage, error) {
+	var messages []DirectMessage
+	bd := h.db.Where("conversation_id = (?)", h.conversationQuery(params.UserId, params.PeerId))
+	if params.Before > 0 {
+		bd = bd.Where("id < ?", params.Before)
+	}
+	if params.CountLimit > 0 {
+		bd = bd.Limit(params.CountLimit)
+	}
+	err := bd.Order("id DESC").Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	// Newest messages are selected first and returned in chronological order
+	res := make([]models.DirectMessage, len(messages))
+	for i, msg := range messages {
+		res[len(messages)-1-i] = ToModel(msg)
+	}
+	return res, nil
+}
+
+type unreadCount struct {
+	ConversationId int
+	Count          int
+}
+
+func (h *DialogGormRepo) GetConversations(userId int) ([]models.Conversation, error) {
+	var convs []Conversation
+	err := h.db.Where("first_id = ? OR second_id = ?", userId, userId).Find(&convs).Error
+	if err != nil {
+		return nil, err
+	}
+	res := []models.Conversation{}
+	if len(convs) == 0 {
+		return res, nil
+	}
+	ids := make([]int, len(convs))
+	for i, c := range convs {
+		ids[i] = c.Id
+	}
+	var last []DirectMessage
+	err = h.db.Where("id IN (?)", h.db.Model(&DirectMessage{}).
+		Select("MAX(id)").
+		Where("conversation_id IN ?", ids).
+		Group("conversation_id")).
+		Find(&last).Error
+	if err != nil {
+		return nil, err
+	}
+	lastByConv := make(map[int]DirectMessage, len(last))
+	for _, m := range last {
+		lastByConv[m.ConversationId] = m
+	}
+	var counts []unreadCount
+	err = h.db.Model(&DirectMessage{}).
+		Select("direct_messages.conversation_id, COUNT(*) AS count").
+		Joins("JOIN conversations ON conversations.id = direct_messages.conversation_id").
+		Where("direct_messages.recipient_id = ?", userId).
+		Where("direct_messages.id > CASE WHEN conversations.first_id = ? "+
+			"THEN conversations.first_read_id ELSE conversations.second_read_id END", userId).
+		Group("direct_messages.conversation_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	unread := make(map[int]int, len(counts))
+	for _, c := range counts {
+		unread[c.ConversationId] = c.Count
+	}
+	for _, c := range convs {
+		peerId := c.FirstId
+		if peerId == userId {
+			peerId = c.SecondId
+		}
+		conv := models.Conversation{Unread: unread[c.Id]}
+		conv.Peer, err = h.profRepo.GetLabel(peerId)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := lastByConv[c.Id]; ok {
+			lastMsg := ToModel(m)
+			conv.LastMessage = &lastMsg
+		}
+		res = append(res, conv)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[j].LastMessage == nil {
+			return res[i].LastMessage != nil
+		}
+		return res[i].LastMessage != nil && res[i].LastMessage.Id > res[j].LastMessage.Id
+	})
+	return res, nil
+}
+
+// MarkRead moves the user's read marker forward only
+func (h *DialogGormRepo) MarkRead(userId int, peerId int, msgId int) error {
+	first, second := pair(userId, peerId)
+	column := "second_read_id"
+	if userId == first {
+		column = "first_read_id"
+	}
+	return h.db.Model(&Conversation{}).
+		Where("first_id = ?", first).
+		Where("second_id = ?", second).
+		Update(column, gorm.Expr("GREATEST("+column+", ?)", msgId)).Error
+}
+
+func (h *DialogGormRepo) Block(userId int, targetId int) error {
+	b := Block{UserId: userId, TargetId: targetId}
+	return h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&b).Error
+}
+
+func (h *DialogGormRepo) Unblock(userId int, targetId int) error {
+	return h.db.
+		Where("user_id = ?", userId).
+		Where("target_id = ?", targetId).
+		Delete(&Block{}).Error
+}
+
+func (h *DialogGormRepo) IsBlocked(userId int, targetId int) (bool, error) {
+	var b Block
+	err := h.db.
+		Where("user_id = ?", userId).
+		Where("target_id = ?", targetId).
+		First(&b).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *DialogGormRepo) GetBlocked(userId int) ([]models.ProfileLabel, error) {
+	var blocks []Block
+	err := h.db.Where("user_id = ?", userId).Order("id ASC").Find(&blocks).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.ProfileLabel, len(blocks))
+	for i, b := range blocks {
+		res[i], err = h.profRepo.GetLabel(b.TargetId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
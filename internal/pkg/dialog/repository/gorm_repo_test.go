@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"konami_backend/internal/pkg/dialog"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/profile"
+	"testing"
+	"time"
+)
+
+type Suite struct {
+	suite.Suite
+	DB   *gorm.DB
+	mock sqlmock.Sqlmock
+
+	bdError error
+}
+
+func (s *Suite) SetupSuite() {
+	var db *sql.DB
+	var err error
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(postgres.New(postgres.Config{
+		DriverName:           "postgres",
+		DSN:                  "sqlmock_db_0",
+		PreferSimpleProtocol: true,
+		Conn:                 db,
+	}), &gorm.Config{})
+	require.NoError(s.T(), err)
+
+	s.bdError = errors.New("some bd error")
+}
+
+func (s *Suite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestDialogs(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestSaveMessage() {
+	repo := NewDialogGormRepo(s.DB, nil)
+	msg := models.DirectMessage{AuthorId: 5, RecipientId: 2, Text: "hi", Timestamp: "2020-12-05T10:00:00.000Z"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"conversations\" (.+) ON CONFLICT DO NOTHING").
+		WithArgs(2, 5, 0, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectQuery("SELECT \\* FROM \"conversations\" WHERE first_id = (.+) AND second_id = (.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_id", "second_id"}).AddRow(3, 2, 5))
+	s.mock.ExpectQuery("INSERT INTO \"direct_messages\"").
+		WithArgs(3, 5, 2, "hi", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	s.mock.ExpectCommit()
+
+	id, err := repo.SaveMessage(msg)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 10, id)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"conversations\"").
+		WillReturnError(s.bdError)
+	s.mock.ExpectRollback()
+	_, err = repo.SaveMessage(msg)
+	require.Equal(s.T(), s.bdError, err)
+
+	msg.Timestamp = "bad"
+	_, err = repo.SaveMessage(msg)
+	require.Error(s.T(), err)
+}
+
+func (s *Suite) TestGetMessages() {
+	repo := NewDialogGormRepo(s.DB, nil)
+	ts := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery("SELECT \\* FROM \"direct_messages\" WHERE conversation_id = " +
+		"\\(SELECT \"id\" FROM \"conversations\" WHERE first_id = (.+) AND second_id = (.+)\\) " +
+		"AND id < (.+) ORDER BY id DESC LIMIT 3").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "author_id", "timestamp"}).
+			AddRow(8, 2, ts).AddRow(7, 5, ts))
+
+	msgs, err := repo.GetMessages(dialog.FilterParams{UserId: 5, PeerId: 2, Before: 9, CountLimit: 3})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), msgs, 2)
+	require.Equal(s.T(), 7, msgs[0].Id)
+	require.Equal(s.T(), 8, msgs[1].Id)
+}
+
+func (s *Suite) TestGetConversations() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	profRepo := profile.NewMockRepository(ctrl)
+	repo := NewDialogGormRepo(s.DB, profRepo)
+	ts := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery("SELECT \\* FROM \"conversations\" WHERE first_id = (.+) OR second_id = (.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_id", "second_id"}).
+			AddRow(1, 2, 5).AddRow(3, 5, 9))
+	s.mock.ExpectQuery("SELECT \\* FROM \"direct_messages\" WHERE id IN \\(SELECT MAX\\(id\\) " +
+		"FROM \"direct_messages\" WHERE conversation_id IN \\((.+),(.+)\\) GROUP BY \"conversation_id\"\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "conversation_id", "author_id", "recipient_id", "timestamp"}).
+			AddRow(4, 1, 2, 5, ts).AddRow(6, 3, 5, 9, ts))
+	s.mock.ExpectQuery("SELECT direct_messages.conversation_id, COUNT\\(\\*\\) AS count FROM \"direct_messages\" " +
+		"JOIN conversations (.+) WHERE direct_messages.recipient_id = (.+) GROUP BY \"direct_messages\".\"conversation_id\"").
+		WillReturnRows(sqlmock.NewRows([]string{"conversation_id", "count"}).AddRow(1, 2))
+	profRepo.EXPECT().GetLabel(2).Return(models.ProfileLabel{Id: 2, Name: "Ann"}, nil)
+	profRepo.EXPECT().GetLabel(9).Return(models.ProfileLabel{Id: 9, Name: "Bob"}, nil)
+
+	convs, err := repo.GetConversations(5)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), convs, 2)
+	require.Equal(s.T(), 9, convs[0].Peer.Id)
+	require.Equal(s.T(), 0, convs[0].Unread)
+	require.Equal(s.T(), 6, convs[0].LastMessage.Id)
+	require.Equal(s.T(), "Ann", convs[1].Peer.Name)
+	require.Equal(s.T(), 2, convs[1].Unread)
+
+	s.mock.ExpectQuery("SELECT \\* FROM \"conversations\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	convs, err = repo.GetConversations(7)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), convs)
+}
+
+func (s *Suite) TestMarkRead() {
+	repo := NewDialogGormRepo(s.DB, nil)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"conversations\" SET \"second_read_id\"=GREATEST\\(second_read_id, (.+)\\) "+
+		"WHERE first_id = (.+) AND second_id = (.+)").
+		WithArgs(12, 2, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	require.NoError(s.T(), repo.MarkRead(5, 2, 12))
+}
+
+func (s *Suite) TestBlocks() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	profRepo := profile.NewMockRepository(ctrl)
+	repo := NewDialogGormRepo(s.DB, profRepo)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"blocks\" (.+) ON CONFLICT DO NOTHING").
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.Block(5, 2))
+
+	s.mock.ExpectQuery("SELECT \\* FROM \"blocks\" WHERE user_id = (.+) AND target_id = (.+)").
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	blocked, err := repo.IsBlocked(5, 2)
+	require.NoError(s.T(), err)
+	require.True(s.T(), blocked)
+
+	s.mock.ExpectQuery("SELECT \\* FROM \"blocks\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	blocked, err = repo.IsBlocked(2, 5)
+	require.NoError(s.T(), err)
+	require.False(s.T(), blocked)
+
+	s.mock.ExpectQuery("SELECT \\* FROM \"blocks\" WHERE user_id = (.+) ORDER BY id ASC").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "target_id"}).AddRow(1, 5, 2))
+	profRepo.EXPECT().GetLabel(2).Return(models.ProfileLabel{Id: 2}, nil)
+	labels, err := repo.GetBlocked(5)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []models.ProfileLabel{{Id: 2}}, labels)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"blocks\" WHERE user_id = (.+) AND target_id = (.+)").
+		WithArgs(5, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.Unblock(5, 2))
+}
@@ -0,0 +1,151 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+
+// Package dialog is a generated GoMock package.
+package dialog
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	models "konami_backend/internal/pkg/models"
+	reflect "reflect"
+)
+
+// MockRepository is a mock of Repository interface
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// SaveMessage mocks base method
+func (m *MockRepository) SaveMessage(msg models.DirectMessage) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMessage", msg)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveMessage indicates an expected call of SaveMessage
+func (mr *MockRepositoryMockRecorder) SaveMessage(msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMessage", reflect.TypeOf((*MockRepository)(nil).SaveMessage), msg)
+}
+
+// GetMessages mocks base method
+func (m *MockRepository) GetMessages(params FilterParams) ([]models.DirectMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessages", params)
+	ret0, _ := ret[0].([]models.DirectMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessages indicates an expected call of GetMessages
+func (mr *MockRepositoryMockRecorder) GetMessages(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessages", reflect.TypeOf((*MockRepository)(nil).GetMessages), params)
+}
+
+// GetConversations mocks base method
+func (m *MockRepository) GetConversations(userId int) ([]models.Conversation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConversations", userId)
+	ret0, _ := ret[0].([]models.Conversation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConversations indicates an expected call of GetConversations
+func (mr *MockRepositoryMockRecorder) GetConversations(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConversations", reflect.TypeOf((*MockRepository)(nil).GetConversations), userId)
+}
+
+// MarkRead mocks base method
+func (m *MockRepository) MarkRead(userId, peerId, msgId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", userId, peerId, msgId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead
+func (mr *MockRepositoryMockRecorder) MarkRead(userId, peerId, msgId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockRepository)(nil).MarkRead), userId, peerId, msgId)
+}
+
+// Block mocks base method
+func (m *MockRepository) Block(userId, targetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Block", userId, targetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Block indicates an expected call of Block
+func (mr *MockRepositoryMockRecorder) Block(userId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Block", reflect.TypeOf((*MockRepository)(nil).Block), userId, targetId)
+}
+
+// Unblock mocks base method
+func (m *MockRepository) Unblock(userId, targetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unblock", userId, targetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unblock indicates an expected call of Unblock
+func (mr *MockRepositoryMockRecorder) Unblock(userId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unblock", reflect.TypeOf((*MockRepository)(nil).Unblock), userId, targetId)
+}
+
+// IsBlocked mocks base method
+func (m *MockRepository) IsBlocked(userId, targetId int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBlocked", userId, targetId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsBlocked indicates an expected call of IsBlocked
+func (mr *MockRepositoryMockRecorder) IsBlocked(userId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockRepository)(nil).IsBlocked), userId, targetId)
+}
+
+// GetBlocked mocks base method
+func (m *MockRepository) GetBlocked(userId int) ([]models.ProfileLabel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocked", userId)
+	ret0, _ := ret[0].([]models.ProfileLabel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocked indicates an expected call of GetBlocked
+func (mr *MockRepositoryMockRecorder) GetBlocked(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocked", reflect.TypeOf((*MockRepository)(nil).GetBlocked), userId)
+}
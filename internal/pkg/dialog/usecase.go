@@ -0,0 +1,13 @@
+//go:generate mockgen -source=usecase.go -destination=./usecase_mock.go -package=dialog
+package dialog
+
+import "konami_backend/internal/pkg/models"
+
+type UseCase interface {
+	SendMessage(msg models.DirectMessage) (models.DirectMessage, error)
+	GetMessages(params FilterParams) ([]models.DirectMessage, error)
+	GetConversations(userId int) ([]models.Conversation, error)
+	Block(userId int, targetId int) error
+	Unblock(userId int, targetId int) error
+	GetBlocked(userId int) ([]models.ProfileLabel, error)
+}
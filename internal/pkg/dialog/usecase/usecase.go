@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"konami_backend/internal/pkg/dialog"
+	"konami_backend/internal/pkg/message"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/profile"
+	"time"
+)
+
+type DialogUseCase struct {
+	repo     dialog.Repository
+	profRepo profile.Repository
+	now      func() time.Time
+}
+
+func NewDialogUseCase(repo dialog.Repository, profRepo profile.Repository) dialog.UseCase {
+	return DialogUseCase{repo: repo, profRepo: profRepo, now: time.Now}
+}
+
+func (u DialogUseCase) SendMessage(msg models.DirectMessage) (models.DirectMessage, error) {
+	if msg.AuthorId == msg.RecipientId {
+		return models.DirectMessage{}, dialog.ErrSelfDialog
+	}
+	err := message.ValidateText(msg.Text)
+	if err != nil {
+		return models.DirectMessage{}, err
+	}
+	_, err = u.profRepo.GetLabel(msg.RecipientId)
+	if err != nil {
+		return models.DirectMessage{}, err
+	}
+	blocked, err := u.repo.IsBlocked(msg.RecipientId, msg.AuthorId)
+	if err != nil {
+		return models.DirectMessage{}, err
+	}
+	if blocked {
+		return models.DirectMessage{}, dialog.ErrBlocked
+	}
+	msg.Timestamp = u.now().UTC().Format("2006-01-02T15:04:05.000Z0700")
+	msg.Id, err = u.repo.SaveMessage(msg)
+	if err != nil {
+		return models.DirectMessage{}, err
+	}
+	return msg, nil
+}
+
+// GetMessages marks the returned history as read by the requesting user
+func (u DialogUseCase) GetMessages(params dialog.FilterParams) ([]models.DirectMessage, error) {
+	messages, err := u.repo.GetMessages(params)
+	if err != nil || len(messages) == 0 {
+		return messages, err
+	}
+	err = u.repo.MarkRead(params.UserId, params.PeerId, messages[len(messages)-1].Id)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (u DialogUseCase) GetConversations(userId int) ([]models.Conversation, error) {
+	return u.repo.GetConversations(userId)
+}
+
+func (u DialogUseCase) Block(userId int, targetId int) error {
+	if userId == targetId {
+		return dialog.ErrSelfDialog
+	}
+	_, err := u.profRepo.GetLabel(targetId)
+	if err != nil {
+		return err
+	}
+	return u.repo.Block(userId, targetId)
+}
+
+func (u DialogUseCase) Unblock(userId int, targetId int) error {
+	return u.repo.Unblock(userId, targetId)
+}
+
+func (u DialogUseCase) GetBlocked(userId int) ([]models.ProfileLabel, error) {
+	return u.repo.GetBlocked(userId)
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"konami_backend/internal/pkg/dialog"
+	"konami_backend/internal/pkg/message"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/profile"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDialog(t *testing.T) {
+	t.Run("SendMessage", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := dialog.NewMockRepository(ctrl)
+		profRepo := profile.NewMockRepository(ctrl)
+		uc := DialogUseCase{repo: repo, profRepo: profRepo, now: func() time.Time {
+			return time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+		}}
+
+		msg := models.DirectMessage{AuthorId: 5, RecipientId: 2, Text: "hi"}
+		saved := msg
+		saved.Timestamp = "2020-12-05T10:00:00.000Z"
+		profRepo.EXPECT().GetLabel(2).Return(models.ProfileLabel{Id: 2}, nil)
+		repo.EXPECT().IsBlocked(2, 5).Return(false, nil)
+		repo.EXPECT().SaveMessage(saved).Return(7, nil)
+		res, err := uc.SendMessage(msg)
+		assert.NoError(t, err)
+		saved.Id = 7
+		assert.Equal(t, saved, res)
+
+		profRepo.EXPECT().GetLabel(2).Return(models.ProfileLabel{Id: 2}, nil)
+		repo.EXPECT().IsBlocked(2, 5).Return(true, nil)
+		_, err = uc.SendMessage(msg)
+		assert.Equal(t, dialog.ErrBlocked, err)
+
+		profRepo.EXPECT().GetLabel(3).Return(models.ProfileLabel{}, profile.ErrUserNonExistent)
+		_, err = uc.SendMessage(models.DirectMessage{AuthorId: 5, RecipientId: 3, Text: "hi"})
+		assert.Equal(t, profile.ErrUserNonExistent, err)
+
+		_, err = uc.SendMessage(models.DirectMessage{AuthorId: 5, RecipientId: 5, Text: "hi"})
+		assert.Equal(t, dialog.ErrSelfDialog, err)
+		_, err = uc.SendMessage(models.DirectMessage{AuthorId: 5, RecipientId: 2, Text: "  "})
+		assert.Equal(t, message.ErrEmptyMessage, err)
+		_, err = uc.SendMessage(models.DirectMessage{AuthorId: 5, RecipientId: 2,
+			Text: strings.Repeat("ы", message.MaxMessageLen+1)})
+		assert.Equal(t, message.ErrMessageTooLong, err)
+	})
+
+	t.Run("GetMessagesMarksRead", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := dialog.NewMockRepository(ctrl)
+		uc := NewDialogUseCase(repo, nil)
+
+		params := dialog.FilterParams{UserId: 5, PeerId: 2, CountLimit: 3}
+		repo.EXPECT().GetMessages(params).Return([]models.DirectMessage{{Id: 3}, {Id: 8}}, nil)
+		repo.EXPECT().MarkRead(5, 2, 8).Return(nil)
+		msgs, err := uc.GetMessages(params)
+		assert.NoError(t, err)
+		assert.Len(t, msgs, 2)
+
+		repo.EXPECT().GetMessages(params).Return([]models.DirectMessage{}, nil)
+		msgs, err = uc.GetMessages(params)
+		assert.NoError(t, err)
+		assert.Empty(t, msgs)
+	})
+
+	t.Run("Blocks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := dialog.NewMockRepository(ctrl)
+		profRepo := profile.NewMockRepository(ctrl)
+		uc := NewDialogUseCase(repo, profRepo)
+
+		profRepo.EXPECT().GetLabel(2).Return(models.ProfileLabel{Id: 2}, nil)
+		repo.EXPECT().Block(5, 2).Return(nil)
+		assert.NoError(t, uc.Block(5, 2))
+		assert.Equal(t, dialog.ErrSelfDialog, uc.Block(5, 5))
+
+		repo.EXPECT().Unblock(5, 2).Return(nil)
+		assert.NoError(t, uc.Unblock(5, 2))
+
+		repo.EXPECT().GetBlocked(5).Return([]models.ProfileLabel{{Id: 2}}, nil)
+		blocked, err := uc.GetBlocked(5)
+		assert.NoError(t, err)
+		assert.Len(t, blocked, 1)
+
+		repo.EXPECT().GetConversations(5).Return([]models.Conversation{}, nil)
+		_, err = uc.GetConversations(5)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase.go
+
+// Package dialog is a generated GoMock package.
+package dialog
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	models "konami_backend/internal/pkg/models"
+	reflect "reflect"
+)
+
+// MockUseCase is a mock of UseCase interface
+type MockUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockUseCaseMockRecorder
+}
+
+// MockUseCaseMockRecorder is the mock recorder for MockUseCase
+type MockUseCaseMockRecorder struct {
+	mock *MockUseCase
+}
+
+// NewMockUseCase creates a new mock instance
+func NewMockUseCase(ctrl *gomock.Controller) *MockUseCase {
+	mock := &MockUseCase{ctrl: ctrl}
+	mock.recorder = &MockUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
+	return m.recorder
+}
+
+// SendMessage mocks base method
+func (m *MockUseCase) SendMessage(msg models.DirectMessage) (models.DirectMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMessage", msg)
+	ret0, _ := ret[0].(models.DirectMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendMessage indicates an expected call of SendMessage
+func (mr *MockUseCaseMockRecorder) SendMessage(msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockUseCase)(nil).SendMessage), msg)
+}
+
+// GetMessages mocks base method
+func (m *MockUseCase) GetMessages(params FilterParams) ([]models.DirectMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessages", params)
+	ret0, _ := ret[0].([]models.DirectMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessages indicates an expected call of GetMessages
+func (mr *MockUseCaseMockRecorder) GetMessages(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessages", reflect.TypeOf((*MockUseCase)(nil).GetMessages), params)
+}
+
+// GetConversations mocks base method
+func (m *MockUseCase) GetConversations(userId int) ([]models.Conversation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConversations", userId)
+	ret0, _ := ret[0].([]models.Conversation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConversations indicates an expected call of GetConversations
+func (mr *MockUseCaseMockRecorder) GetConversations(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConversations", reflect.TypeOf((*MockUseCase)(nil).GetConversations), userId)
+}
+
+// Block mocks base method
+func (m *MockUseCase) Block(userId, targetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Block", userId, targetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Block indicates an expected call of Block
+func (mr *MockUseCaseMockRecorder) Block(userId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Block", reflect.TypeOf((*MockUseCase)(nil).Block), userId, targetId)
+}
+
+// Unblock mocks base method
+func (m *MockUseCase) Unblock(userId, targetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unblock", userId, targetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unblock indicates an expected call of Unblock
+func (mr *MockUseCaseMockRecorder) Unblock(userId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unblock", reflect.TypeOf((*MockUseCase)(nil).Unblock), userId, targetId)
+}
+
+// GetBlocked mocks base method
+func (m *MockUseCase) GetBlocked(userId int) ([]models.ProfileLabel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocked", userId)
+	ret0, _ := ret[0].([]models.ProfileLabel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocked indicates an expected call of GetBlocked
+func (mr *MockUseCaseMockRecorder) GetBlocked(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocked", reflect.TypeOf((*MockUseCase)(nil).GetBlocked), userId)
+}
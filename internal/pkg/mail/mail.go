@@ -0,0 +1,46 @@
+//go:generate mockgen -source=mail.go -destination=./mail_mock.go -package=mail
+package mail
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnknownTemplate = errors.New("unknown mail template")
+
+const (
+	LangRu = "ru"
+	LangEn = "en"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers a rendered message right away
+type Sender interface {
+	Send(msg Message) error
+}
+
+type QueuedMessage struct {
+	Message
+	Id       int
+	Attempts int
+}
+
+type Repository interface {
+	Enqueue(msg Message, now time.Time) (int, error)
+	// ClaimBatch locks due messages until leaseUntil so other workers skip them
+	ClaimBatch(now time.Time, leaseUntil time.Time, limit int) ([]QueuedMessage, error)
+	MarkSent(id int, now time.Time) error
+	// MarkFailed schedules another attempt, a zero nextAttempt gives up on the message
+	MarkFailed(id int, attempts int, nextAttempt time.Time, lastError string) error
+}
+
+type UseCase interface {
+	// Send renders the template and puts the message into the delivery queue
+	Send(to string, lang string, template string, data interface{}) error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"konami_backend/internal/pkg/mail"
+	"time"
+)
+
+type MailGormRepo struct {
+	db *gorm.DB
+}
+
+func NewMailGormRepo(db *gorm.DB) mail.Repository {
+	return &MailGormRepo{db: db}
+}
+
+// QueuedMail is pending while NextAttemptAt is set and SentAt is not
+type QueuedMail struct {
+	Id            int `gorm:"primaryKey;autoIncrement;"`
+	Recipient     string
+	Subject       string
+	Text          string
+	HTML          string
+	Attempts      int
+	NextAttemptAt *time.Time `gorm:"index"`
+	LastError     string
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
+
+func (m *QueuedMail) TableName() string {
+	return "mail_queue"
+}
+
+func (h *MailGormRepo) Enqueue(msg mail.Message, now time.Time) (int, error) {
+	m := QueuedMail{
+		Recipient:     msg.To,
+		Subject:       msg.Subject,
+		Text:          msg.Text,
+		HTML:          msg.HTML,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}
+	err := h.db.Create(&m).Error
+	if err != nil {
+		return 0, err
+	}
+	return m.Id, nil
+}
+
+func (h *MailGormRepo) ClaimBatch(now time.Time, leaseUntil time.Time, limit int) ([]mail.QueuedMessage, error) {
+	var batch []QueuedMail
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL").
+			Where("next_attempt_at <= ?", now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&batch).Error
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+		ids := make([]int, len(batch))
+		for i, m := range batch {
+			ids[i] = m.Id
+		}
+		return tx.Model(&QueuedMail{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]mail.QueuedMessage, len(batch))
+	for i, m := range batch {
+		res[i] = mail.QueuedMessage{
+			Message:  mail.Message{To: m.Recipient, Subject: m.Subject, Text: m.Text, HTML: m.HTML},
+			Id:       m.Id,
+			Attempts: m.Attempts,
+		}
+	}
+	return res, nil
+}
+
+func (h *MailGormRepo) MarkSent(id int, now time.Time) error {
+	return h.db.Model(&QueuedMail{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"sent_at": now, "next_attempt_at": nil}).Error
+}
+
+func (h *MailGormRepo) MarkFailed(id int, attempts int, nextAttempt time.Time, lastError string) error {
+	var next interface{}
+	if !nextAttempt.IsZero() {
+		next = nextAttempt
+	}
+	return h.db.Model(&QueuedMail{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": next,
+			"last_error":      lastError,
+		}).Error
+}
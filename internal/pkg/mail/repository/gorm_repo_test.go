@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"konami_backend/internal/pkg/mail"
+	"testing"
+	"time"
+)
+
+type Suite struct {
+	suite.Suite
+	DB   *gorm.DB
+	mock sqlmock.Sqlmock
+
+	bdError error
+}
+
+func (s *Suite) SetupSuite() {
+	var db *sql.DB
+	var err error
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(postgres.New(postgres.Config{
+		DriverName:           "postgres",
+		DSN:                  "sqlmock_db_0",
+		PreferSimpleProtocol: true,
+		Conn:                 db,
+	}), &gorm.Config{})
+	require.NoError(s.T(), err)
+
+	s.bdError = errors.New("some bd error")
+}
+
+func (s *Suite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestMailQueue(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestEnqueue() {
+	repo := NewMailGormRepo(s.DB)
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"mail_queue\"").
+		WithArgs("ann@example.com", "Hi", "text", "<p>html</p>", 0, now, "", now, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectCommit()
+	id, err := repo.Enqueue(mail.Message{To: "ann@example.com", Subject: "Hi", Text: "text", HTML: "<p>html</p>"}, now)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 3, id)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"mail_queue\"").
+		WillReturnError(s.bdError)
+	s.mock.ExpectRollback()
+	_, err = repo.Enqueue(mail.Message{To: "ann@example.com"}, now)
+	require.Equal(s.T(), s.bdError, err)
+}
+
+func (s *Suite) TestClaimBatch() {
+	repo := NewMailGormRepo(s.DB)
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	until := now.Add(5 * time.Minute)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT \\* FROM \"mail_queue\" WHERE sent_at IS NULL AND next_attempt_at <= (.+) " +
+		"ORDER BY id ASC LIMIT 2 FOR UPDATE SKIP LOCKED").
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "subject", "attempts"}).
+			AddRow(1, "ann@example.com", "Hi", 0).AddRow(4, "bob@example.com", "Hi", 2))
+	s.mock.ExpectExec("UPDATE \"mail_queue\" SET \"next_attempt_at\"=(.+) WHERE id IN \\((.+),(.+)\\)").
+		WithArgs(until, 1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+	batch, err := repo.ClaimBatch(now, until, 2)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []mail.QueuedMessage{
+		{Id: 1, Message: mail.Message{To: "ann@example.com", Subject: "Hi"}},
+		{Id: 4, Message: mail.Message{To: "bob@example.com", Subject: "Hi"}, Attempts: 2},
+	}, batch)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT \\* FROM \"mail_queue\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+	batch, err = repo.ClaimBatch(now, until, 2)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), batch)
+}
+
+func (s *Suite) TestMarkResult() {
+	repo := NewMailGormRepo(s.DB)
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"mail_queue\" SET \"next_attempt_at\"=(.+),\"sent_at\"=(.+) WHERE id = (.+)").
+		WithArgs(nil, now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.MarkSent(1, now))
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"mail_queue\" SET \"attempts\"=(.+),\"last_error\"=(.+),\"next_attempt_at\"=(.+) "+
+		"WHERE id = (.+)").
+		WithArgs(2, "refused", now.Add(time.Minute), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.MarkFailed(1, 2, now.Add(time.Minute), "refused"))
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"mail_queue\"").
+		WithArgs(6, "refused", nil, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.MarkFailed(1, 6, time.Time{}, "refused"))
+}
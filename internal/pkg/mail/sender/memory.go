@@ -0,0 +1,33 @@
+package sender
+
+import (
+	"konami_backend/internal/pkg/mail"
+	"sync"
+)
+
+// MemorySender keeps sent messages for tests, Err makes every send fail
+type MemorySender struct {
+	mu   sync.Mutex
+	sent []mail.Message
+	Err  error
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(msg mail.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Err != nil {
+		return s.Err
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *MemorySender) Sent() []mail.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mail.Message{}, s.sent...)
+}
@@ -0,0 +1,68 @@
+package sender
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"konami_backend/internal/pkg/mail"
+	"mime"
+	"mime/multipart"
+	netMail "net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+func TestSenders(t *testing.T) {
+	msg := mail.Message{To: "ann@example.com", Subject: "Напоминание: Go", Text: "Привет, Ann", HTML: "<p>Привет, Ann</p>"}
+
+	t.Run("SMTP", func(t *testing.T) {
+		var raw []byte
+		s := NewSMTPSender("localhost:1025", "noreply@onmeet.ru", "", "")
+		s.SendMail = func(addr string, a smtp.Auth, from string, to []string, body []byte) error {
+			assert.Equal(t, "localhost:1025", addr)
+			assert.Nil(t, a)
+			assert.Equal(t, "noreply@onmeet.ru", from)
+			assert.Equal(t, []string{"ann@example.com"}, to)
+			raw = body
+			return nil
+		}
+		assert.NoError(t, s.Send(msg))
+
+		parsed, err := netMail.ReadMessage(strings.NewReader(string(raw)))
+		assert.NoError(t, err)
+		subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		assert.NoError(t, err)
+		assert.Equal(t, msg.Subject, subject)
+		mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/alternative", mediaType)
+
+		r := multipart.NewReader(parsed.Body, params["boundary"])
+		var bodies []string
+		for {
+			part, err := r.NextPart()
+			if err != nil {
+				break
+			}
+			b, _ := ioutil.ReadAll(part)
+			bodies = append(bodies, string(b))
+		}
+		assert.Equal(t, []string{msg.Text, msg.HTML}, bodies)
+
+		s.SendMail = func(string, smtp.Auth, string, []string, []byte) error {
+			return errors.New("connection refused")
+		}
+		assert.Error(t, s.Send(msg))
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		s := NewMemorySender()
+		assert.NoError(t, s.Send(msg))
+		assert.Equal(t, []mail.Message{msg}, s.Sent())
+
+		s.Err = errors.New("down")
+		assert.Error(t, s.Send(msg))
+		assert.Len(t, s.Sent(), 1)
+	})
+}
@@ -0,0 +1,81 @@
+package sender
+
+import (
+	"bytes"
+	"fmt"
+	"konami_backend/internal/pkg/mail"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+)
+
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPSender talks to a relay, for local runs point it to MailHog at localhost:1025
+type SMTPSender struct {
+	Addr     string
+	From     string
+	Auth     smtp.Auth
+	SendMail SendMailFunc
+}
+
+func NewSMTPSender(addr, from, username, password string) *SMTPSender {
+	s := &SMTPSender{Addr: addr, From: from, SendMail: smtp.SendMail}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		s.Auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTPSender) Send(msg mail.Message) error {
+	body, err := Compose(s.From, msg)
+	if err != nil {
+		return err
+	}
+	return s.SendMail(s.Addr, s.Auth, s.From, []string{msg.To}, body)
+}
+
+// Compose builds a multipart/alternative message with text and HTML parts
+func Compose(from string, msg mail.Message) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+	fmt.Fprintf(buf, "From: %s\r\n", from)
+	fmt.Fprintf(buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+	for _, p := range parts {
+		if p.body == "" {
+			continue
+		}
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write([]byte(p.body)); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,186 @@
+package mail
+
+import (
+	"bytes"
+	htmlTemplate "html/template"
+	textTemplate "text/template"
+)
+
+const (
+	TemplateReminder      = "reminder"
+	TemplateVerification  = "verification"
+	TemplatePasswordReset = "passwordReset"
+	TemplateDigest        = "digest"
+)
+
+type ReminderData struct {
+	Name    string
+	Title   string
+	Address string
+	Start   string
+	Link    string
+}
+
+type LinkData struct {
+	Name string
+	Link string
+}
+
+type DigestData struct {
+	Name     string
+	Meetings []DigestMeeting
+}
+
+type DigestMeeting struct {
+	Title string
+	Start string
+	Link  string
+}
+
+type template struct {
+	subject *textTemplate.Template
+	text    *textTemplate.Template
+	html    *htmlTemplate.Template
+}
+
+var templates = map[string]map[string]template{}
+
+func register(name, lang, subject, text, html string) {
+	if templates[name] == nil {
+		templates[name] = map[string]template{}
+	}
+	templates[name][lang] = template{
+		subject: textTemplate.Must(textTemplate.New(name + "_" + lang + "_subject").Parse(subject)),
+		text:    textTemplate.Must(textTemplate.New(name + "_" + lang + "_text").Parse(text)),
+		html:    htmlTemplate.Must(htmlTemplate.New(name + "_" + lang + "_html").Parse(html)),
+	}
+}
+
+// Render builds a message from the named template, unknown languages fall back to Russian
+func Render(name string, lang string, to string, data interface{}) (Message, error) {
+	langs, ok := templates[name]
+	if !ok {
+		return Message{}, ErrUnknownTemplate
+	}
+	t, ok := langs[lang]
+	if !ok {
+		t = langs[LangRu]
+	}
+	msg := Message{To: to}
+	buf := new(bytes.Buffer)
+	if err := t.subject.Execute(buf, data); err != nil {
+		return Message{}, err
+	}
+	msg.Subject = buf.String()
+	buf.Reset()
+	if err := t.text.Execute(buf, data); err != nil {
+		return Message{}, err
+	}
+	msg.Text = buf.String()
+	buf.Reset()
+	if err := t.html.Execute(buf, data); err != nil {
+		return Message{}, err
+	}
+	msg.HTML = buf.String()
+	return msg, nil
+}
+
+func init() {
+	register(TemplateReminder, LangRu,
+		`Напоминание: {{.Title}}`,
+		`Здравствуйте, {{.Name}}!
+
+Встреча «{{.Title}}» начнётся {{.Start}}.
+Адрес: {{.Address}}
+{{if .Link}}
+Подробнее: {{.Link}}
+{{end}}`,
+		`<p>Здравствуйте, {{.Name}}!</p>
+<p>Встреча «<b>{{.Title}}</b>» начнётся {{.Start}}.<br>Адрес: {{.Address}}</p>
+{{if .Link}}<p><a href="{{.Link}}">Подробнее о встрече</a></p>{{end}}`)
+	register(TemplateReminder, LangEn,
+		`Reminder: {{.Title}}`,
+		`Hello, {{.Name}}!
+
+The meeting "{{.Title}}" starts at {{.Start}}.
+Address: {{.Address}}
+{{if .Link}}
+Details: {{.Link}}
+{{end}}`,
+		`<p>Hello, {{.Name}}!</p>
+<p>The meeting "<b>{{.Title}}</b>" starts at {{.Start}}.<br>Address: {{.Address}}</p>
+{{if .Link}}<p><a href="{{.Link}}">Meeting details</a></p>{{end}}`)
+
+	register(TemplateVerification, LangRu,
+		`Подтвердите адрес почты`,
+		`Здравствуйте, {{.Name}}!
+
+Чтобы подтвердить адрес почты, перейдите по ссылке:
+{{.Link}}
+
+Если вы не регистрировались, просто проигнорируйте это письмо.
+`,
+		`<p>Здравствуйте, {{.Name}}!</p>
+<p>Чтобы подтвердить адрес почты, перейдите по <a href="{{.Link}}">ссылке</a>.</p>
+<p>Если вы не регистрировались, просто проигнорируйте это письмо.</p>`)
+	register(TemplateVerification, LangEn,
+		`Confirm your email address`,
+		`Hello, {{.Name}}!
+
+Follow the link to confirm your email address:
+{{.Link}}
+
+If you did not sign up, just ignore this email.
+`,
+		`<p>Hello, {{.Name}}!</p>
+<p>Follow the <a href="{{.Link}}">link</a> to confirm your email address.</p>
+<p>If you did not sign up, just ignore this email.</p>`)
+
+	register(TemplatePasswordReset, LangRu,
+		`Восстановление пароля`,
+		`Здравствуйте, {{.Name}}!
+
+Чтобы задать новый пароль, перейдите по ссылке:
+{{.Link}}
+
+Если вы не запрашивали восстановление, просто проигнорируйте это письмо.
+`,
+		`<p>Здравствуйте, {{.Name}}!</p>
+<p>Чтобы задать новый пароль, перейдите по <a href="{{.Link}}">ссылке</a>.</p>
+<p>Если вы не запрашивали восстановление, просто проигнорируйте это письмо.</p>`)
+	register(TemplatePasswordReset, LangEn,
+		`Password reset`,
+		`Hello, {{.Name}}!
+
+Follow the link to set a new password:
+{{.Link}}
+
+If you did not request a reset, just ignore this email.
+`,
+		`<p>Hello, {{.Name}}!</p>
+<p>Follow the <a href="{{.Link}}">link</a> to set a new password.</p>
+<p>If you did not request a reset, just ignore this email.</p>`)
+
+	register(TemplateDigest, LangRu,
+		`Встречи, которые могут вам понравиться`,
+		`Здравствуйте, {{.Name}}!
+
+Подборка встреч для вас:
+{{range .Meetings}}
+- {{.Title}}, {{.Start}}: {{.Link}}{{end}}
+`,
+		`<p>Здравствуйте, {{.Name}}!</p>
+<p>Подборка встреч для вас:</p>
+<ul>{{range .Meetings}}<li><a href="{{.Link}}">{{.Title}}</a>, {{.Start}}</li>{{end}}</ul>`)
+	register(TemplateDigest, LangEn,
+		`Meetings you may like`,
+		`Hello, {{.Name}}!
+
+Meetings picked for you:
+{{range .Meetings}}
+- {{.Title}}, {{.Start}}: {{.Link}}{{end}}
+`,
+		`<p>Hello, {{.Name}}!</p>
+<p>Meetings picked for you:</p>
+<ul>{{range .Meetings}}<li><a href="{{.Link}}">{{.Title}}</a>, {{.Start}}</li>{{end}}</ul>`)
+}
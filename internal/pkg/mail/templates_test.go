@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Run("AllTemplates", func(t *testing.T) {
+		data := map[string]interface{}{
+			TemplateReminder:      ReminderData{Name: "Ann", Title: "Go", Address: "Moscow", Start: "05.12.2020 10:00"},
+			TemplateVerification:  LinkData{Name: "Ann", Link: "https://onmeet.ru/verify?token=1"},
+			TemplatePasswordReset: LinkData{Name: "Ann", Link: "https://onmeet.ru/reset?token=1"},
+			TemplateDigest: DigestData{Name: "Ann", Meetings: []DigestMeeting{
+				{Title: "Go", Start: "05.12.2020", Link: "https://onmeet.ru/meeting?meetId=1"}}},
+		}
+		for name, d := range data {
+			for _, lang := range []string{LangRu, LangEn} {
+				msg, err := Render(name, lang, "ann@example.com", d)
+				assert.NoError(t, err, name+"/"+lang)
+				assert.Equal(t, "ann@example.com", msg.To)
+				assert.NotEmpty(t, msg.Subject)
+				assert.True(t, strings.Contains(msg.Text, "Ann"), name+"/"+lang)
+				assert.True(t, strings.Contains(msg.HTML, "Ann"), name+"/"+lang)
+			}
+		}
+	})
+
+	t.Run("Languages", func(t *testing.T) {
+		data := LinkData{Name: "Ann", Link: "https://onmeet.ru/reset"}
+		en, err := Render(TemplatePasswordReset, LangEn, "a@b.c", data)
+		assert.NoError(t, err)
+		assert.Equal(t, "Password reset", en.Subject)
+
+		ru, err := Render(TemplatePasswordReset, "de", "a@b.c", data)
+		assert.NoError(t, err)
+		assert.Equal(t, "Восстановление пароля", ru.Subject)
+	})
+
+	t.Run("EscapesHTML", func(t *testing.T) {
+		msg, err := Render(TemplateReminder, LangEn, "a@b.c", ReminderData{Name: "<script>", Title: "Go"})
+		assert.NoError(t, err)
+		assert.True(t, strings.Contains(msg.Text, "<script>"))
+		assert.False(t, strings.Contains(msg.HTML, "<script>"))
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := Render("spam", LangRu, "a@b.c", nil)
+		assert.Equal(t, ErrUnknownTemplate, err)
+	})
+}
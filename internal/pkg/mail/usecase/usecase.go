@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"konami_backend/internal/pkg/mail"
+	"konami_backend/logger"
+	"time"
+)
+
+const (
+	DefBatchSize   = 20
+	DefMaxAttempts = 6
+	// sendTimeout keeps a claimed batch away from other workers while it is being sent
+	sendTimeout = 5 * time.Minute
+)
+
+// Backoff is the delay before each retry, the last value repeats
+var Backoff = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+
+type MailUseCase struct {
+	Repo        mail.Repository
+	Sender      mail.Sender
+	Log         *logger.Logger
+	BatchSize   int
+	MaxAttempts int
+	Now         func() time.Time
+}
+
+func NewMailUseCase(repo mail.Repository, sender mail.Sender, log *logger.Logger) *MailUseCase {
+	return &MailUseCase{
+		Repo:        repo,
+		Sender:      sender,
+		Log:         log,
+		BatchSize:   DefBatchSize,
+		MaxAttempts: DefMaxAttempts,
+		Now:         time.Now,
+	}
+}
+
+func (u *MailUseCase) Send(to string, lang string, template string, data interface{}) error {
+	msg, err := mail.Render(template, lang, to, data)
+	if err != nil {
+		return err
+	}
+	_, err = u.Repo.Enqueue(msg, u.Now())
+	return err
+}
+
+// Run delivers the queue until stop is closed
+func (u *MailUseCase) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := u.Deliver(); err != nil {
+			u.Log.LogError("mail/usecase", "Run", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deliver sends one batch of due messages and returns how many went out
+func (u *MailUseCase) Deliver() (int, error) {
+	now := u.Now()
+	batch, err := u.Repo.ClaimBatch(now, now.Add(sendTimeout), u.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, msg := range batch {
+		err = u.Sender.Send(msg.Message)
+		if err == nil {
+			sent++
+			err = u.Repo.MarkSent(msg.Id, u.Now())
+		} else {
+			err = u.retry(msg, err)
+		}
+		if err != nil {
+			u.Log.LogError("mail/usecase", "Deliver", err)
+		}
+	}
+	return sent, nil
+}
+
+func (u *MailUseCase) retry(msg mail.QueuedMessage, sendErr error) error {
+	attempts := msg.Attempts + 1
+	var next time.Time
+	if attempts < u.MaxAttempts {
+		delay := Backoff[len(Backoff)-1]
+		if attempts <= len(Backoff) {
+			delay = Backoff[attempts-1]
+		}
+		next = u.Now().Add(delay)
+	} else {
+		u.Log.LogWarning("mail/usecase", "Deliver", "giving up on mail to "+msg.To+": "+sendErr.Error())
+	}
+	return u.Repo.MarkFailed(msg.Id, attempts, next, sendErr.Error())
+}
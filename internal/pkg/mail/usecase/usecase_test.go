@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"konami_backend/internal/pkg/mail"
+	"konami_backend/internal/pkg/mail/sender"
+	"konami_backend/logger"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestMail(t *testing.T) {
+	log := logger.NewLogger(ioutil.Discard)
+	start := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Send", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := mail.NewMockRepository(ctrl)
+		clock := &fakeClock{now: start}
+		uc := NewMailUseCase(repo, sender.NewMemorySender(), log)
+		uc.Now = clock.Now
+
+		repo.EXPECT().Enqueue(gomock.Any(), start).DoAndReturn(func(msg mail.Message, _ time.Time) (int, error) {
+			assert.Equal(t, "ann@example.com", msg.To)
+			assert.Equal(t, "Confirm your email address", msg.Subject)
+			return 1, nil
+		})
+		err := uc.Send("ann@example.com", mail.LangEn, mail.TemplateVerification,
+			mail.LinkData{Name: "Ann", Link: "https://onmeet.ru/verify"})
+		assert.NoError(t, err)
+
+		err = uc.Send("ann@example.com", mail.LangEn, "spam", nil)
+		assert.Equal(t, mail.ErrUnknownTemplate, err)
+	})
+
+	t.Run("Deliver", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := mail.NewMockRepository(ctrl)
+		memory := sender.NewMemorySender()
+		clock := &fakeClock{now: start}
+		uc := NewMailUseCase(repo, memory, log)
+		uc.Now = clock.Now
+
+		batch := []mail.QueuedMessage{
+			{Id: 1, Message: mail.Message{To: "ann@example.com", Subject: "Hi"}},
+			{Id: 2, Message: mail.Message{To: "bob@example.com", Subject: "Hi"}, Attempts: 2},
+		}
+		repo.EXPECT().ClaimBatch(start, start.Add(sendTimeout), DefBatchSize).Return(batch, nil)
+		repo.EXPECT().MarkSent(1, start).Return(nil)
+		repo.EXPECT().MarkSent(2, start).Return(nil)
+		sent, err := uc.Deliver()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, sent)
+		assert.Len(t, memory.Sent(), 2)
+
+		dbErr := errors.New("db error")
+		repo.EXPECT().ClaimBatch(start, gomock.Any(), DefBatchSize).Return(nil, dbErr)
+		_, err = uc.Deliver()
+		assert.Equal(t, dbErr, err)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := mail.NewMockRepository(ctrl)
+		memory := sender.NewMemorySender()
+		memory.Err = errors.New("connection refused")
+		clock := &fakeClock{now: start}
+		uc := NewMailUseCase(repo, memory, log)
+		uc.Now = clock.Now
+
+		repo.EXPECT().ClaimBatch(start, gomock.Any(), DefBatchSize).Return([]mail.QueuedMessage{
+			{Id: 1, Attempts: 0},
+			{Id: 2, Attempts: 4},
+			{Id: 3, Attempts: DefMaxAttempts - 1},
+		}, nil)
+		repo.EXPECT().MarkFailed(1, 1, start.Add(time.Minute), "connection refused").Return(nil)
+		repo.EXPECT().MarkFailed(2, 5, start.Add(2*time.Hour), "connection refused").Return(nil)
+		repo.EXPECT().MarkFailed(3, DefMaxAttempts, time.Time{}, "connection refused").Return(nil)
+		sent, err := uc.Deliver()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, sent)
+	})
+}
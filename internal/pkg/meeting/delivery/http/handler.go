@@ -4,10 +4,16 @@ import (
 	"bytes"
 	"errors"
 	"konami_backend/internal/pkg/meeting"
+	"konami_backend/internal/pkg/message"
 	"konami_backend/internal/pkg/middleware"
 	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/utils/cursor"
 	hu "konami_backend/internal/pkg/utils/http_utils"
+	"konami_backend/internal/pkg/utils/ical"
+	"konami_backend/internal/pkg/utils/recurrence"
+	"konami_backend/logger"
 	"konami_backend/proto/auth"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -18,11 +24,21 @@ type MeetingHandler struct {
 	MeetingUC  meeting.UseCase
 	AuthClient auth.AuthCheckerClient
 	MaxReqSize int64
+	Cursors    *cursor.Signer
+	Broker     message.Broker
+	Log        *logger.Logger
 }
 
 const DefCountLimit = 10
+const DefRadiusKm = 10.0
+const MaxRadiusKm = 500.0
 const MaxLikes = int(^uint(0) >> 1)
 
+const (
+	CursorById       = "id"
+	CursorByDistance = "distance"
+)
+
 func GetQueryParams(r *http.Request) meeting.FilterParams {
 	var res meeting.FilterParams
 	var err error
@@ -52,6 +68,11 @@ func GetQueryParams(r *http.Request) meeting.FilterParams {
 	if err != nil {
 		res.PrevStart = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
 	}
+	res.PrevDistance, err = strconv.ParseFloat(r.URL.Query().Get("prevDistance"), 64)
+	if err != nil {
+		res.PrevDistance = 0
+	}
+	res.WithCancelled = r.URL.Query().Get("cancelled") == "true"
 	var ok bool
 	res.UserId, ok = r.Context().Value(middleware.UserID).(int)
 	if !ok {
@@ -60,140 +81,209 @@ func GetQueryParams(r *http.Request) meeting.FilterParams {
 	return res
 }
 
-func (h *MeetingHandler) GetMeetingsList(w http.ResponseWriter, r *http.Request) {
+// GetPageParams applies the cursor on top of the legacy prev* parameters.
+// One extra item is requested to find out whether there is a next page.
+func (h *MeetingHandler) GetPageParams(r *http.Request, kind string) (meeting.FilterParams, error) {
 	params := GetQueryParams(r)
-	var meets []models.Meeting
-	var err error
-	meets, err = h.MeetingUC.GetNextMeetings(params)
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		c, err := h.Cursors.Decode(token, kind)
+		if err != nil {
+			return params, err
+		}
+		params.PrevId = c.Id
+		params.PrevLikes = c.Likes
+		params.PrevStart = c.Start
+		params.PrevDistance = c.Distance
+	}
+	params.CountLimit++
+	return params, nil
+}
+
+func (h *MeetingHandler) Page(meets []models.Meeting, params meeting.FilterParams, kind string) models.MeetingPage {
+	page := models.MeetingPage{Meetings: meets}
+	if page.Meetings == nil {
+		page.Meetings = []models.Meeting{}
+	}
+	if len(meets) < params.CountLimit {
+		return page
+	}
+	page.Meetings = meets[:params.CountLimit-1]
+	page.HasMore = true
+	last := page.Meetings[len(page.Meetings)-1]
+	c := cursor.Cursor{Kind: kind, Id: last.Card.Label.Id, Likes: last.Card.LikesCount}
+	c.Start, _ = time.Parse("2006-01-02T15:04:05.000Z0700", last.Card.StartDate)
+	if last.Distance != nil {
+		c.Distance = *last.Distance
+	}
+	page.NextCursor = h.Cursors.Encode(c)
+	return page
+}
+
+// WritePage keeps answering legacy clients with a bare array
+func (h *MeetingHandler) WritePage(w http.ResponseWriter, r *http.Request,
+	meets []models.Meeting, params meeting.FilterParams, kind string) {
+	page := h.Page(meets, params, kind)
+	if hu.PageRequested(r) {
+		hu.WriteJson(w, page)
+		return
+	}
+	hu.WriteJson(w, page.Meetings)
+}
+
+func (h *MeetingHandler) GetMeetingsList(w http.ResponseWriter, r *http.Request) {
+	params, err := h.GetPageParams(r, meeting.SortByDate)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
+	meets, err := h.MeetingUC.GetNextMeetings(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, meeting.SortByDate)
 }
 
 func (h *MeetingHandler) GetUserMeetingsList(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r, CursorById)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	if params.UserId == -1 {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
 		return
 	}
-	var meets []models.Meeting
-	var err error
-	meets, err = h.MeetingUC.FilterRegistered(params)
+	meets, err := h.MeetingUC.FilterRegistered(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, CursorById)
 }
 
 func (h *MeetingHandler) GetSubsMeetingsList(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r, CursorById)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	if params.UserId == -1 {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
 		return
 	}
-	var meets []models.Meeting
-	var err error
-	meets, err = h.MeetingUC.FilterSubsRegistered(params)
+	meets, err := h.MeetingUC.FilterSubsRegistered(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, CursorById)
 }
 
 func (h *MeetingHandler) GetFavMeetingsList(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r, CursorById)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	if params.UserId == -1 {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
 		return
 	}
-	var meets []models.Meeting
-	var err error
-	meets, err = h.MeetingUC.FilterLiked(params)
+	meets, err := h.MeetingUC.FilterLiked(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, CursorById)
 }
 
 func (h *MeetingHandler) GetSubsFavMeetingsList(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r, CursorById)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	if params.UserId == -1 {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
 		return
 	}
-	var meets []models.Meeting
-	var err error
-	meets, err = h.MeetingUC.FilterSubsLiked(params)
+	meets, err := h.MeetingUC.FilterSubsLiked(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, CursorById)
 }
 
 func (h *MeetingHandler) GetTopMeetingsList(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
-	var meets []models.Meeting
-	var err error
-	meets, err = h.MeetingUC.GetTopMeetings(params)
+	params, err := h.GetPageParams(r, meeting.SortByLikes)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
+	meets, err := h.MeetingUC.GetTopMeetings(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, meeting.SortByLikes)
 }
 
 func (h *MeetingHandler) GetRecommendedList(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r, CursorById)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	if params.UserId == -1 {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
 		return
 	}
-	var meets []models.Meeting
-	var err error
-	meets, err = h.MeetingUC.FilterRecommended(params)
+	meets, err := h.MeetingUC.FilterRecommended(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, CursorById)
 }
 
 func (h *MeetingHandler) GetTaggedMeetings(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r, CursorById)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	tags, exist := r.URL.Query()["tag"]
 	if !exist || len(tags) == 0 {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
 		return
 	}
-	var meets []models.Meeting
 	meets, err := h.MeetingUC.FilterTagged(params, tags)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, CursorById)
 }
 
 func (h *MeetingHandler) GetAkinMeetings(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r, CursorById)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	meetId, err := strconv.Atoi(r.URL.Query().Get("meetId"))
 	if err != nil || meetId < 0 {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
 		return
 	}
-	var meets []models.Meeting
-	meets, err = h.MeetingUC.FilterSimilar(params, meetId)
+	meets, err := h.MeetingUC.FilterSimilar(params, meetId)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	h.WritePage(w, r, meets, params, CursorById)
 }
 
 func (h *MeetingHandler) CreateMeeting(w http.ResponseWriter, r *http.Request) {
@@ -218,6 +308,10 @@ func (h *MeetingHandler) CreateMeeting(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	_, err = h.MeetingUC.CreateMeeting(userId, *mData)
+	if errors.Is(err, recurrence.ErrInvalidRule) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
@@ -268,8 +362,23 @@ func (h *MeetingHandler) UpdateMeeting(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	err = h.MeetingUC.UpdateMeeting(userId, *update)
+	// the registration may be gone even if a later part of the update failed
+	if update.Fields != nil && update.Fields.Reg != nil && !*update.Fields.Reg {
+		h.membershipChanged(update.MeetId, userId)
+	}
 	if errors.Is(err, meeting.ErrMeetingNotFound) {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+		return
+	}
+	if errors.Is(err, meeting.ErrAccessDenied) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusForbidden, ErrMsg: "only organizers can edit the meeting"})
+		return
+	}
+	if errors.Is(err, meeting.ErrNoSeatsLeft) || errors.Is(err, meeting.ErrAlreadyRegistered) ||
+		errors.Is(err, meeting.ErrSeatsOccupied) || errors.Is(err, meeting.ErrMeetingCancelled) ||
+		errors.Is(err, meeting.ErrSeatsAvailable) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusConflict, ErrMsg: err.Error()})
+		return
 	}
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
@@ -278,22 +387,416 @@ func (h *MeetingHandler) UpdateMeeting(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *MeetingHandler) CancelMeeting(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	cancel := &models.MeetingCancel{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = cancel.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = h.MeetingUC.CancelMeeting(userId, cancel.MeetId, cancel.Reason)
+	h.writeManageResult(w, err)
+}
+
+func (h *MeetingHandler) DeleteMeeting(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	meetId, err := strconv.Atoi(r.URL.Query().Get("meetId"))
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = h.MeetingUC.DeleteMeeting(userId, meetId)
+	if err == nil {
+		h.membershipChanged(meetId, 0)
+	}
+	h.writeManageResult(w, err)
+}
+
+func (h *MeetingHandler) AddOrganizer(w http.ResponseWriter, r *http.Request) {
+	h.changeOrganizers(w, r, h.MeetingUC.AddOrganizer)
+}
+
+func (h *MeetingHandler) RemoveOrganizer(w http.ResponseWriter, r *http.Request) {
+	h.changeOrganizers(w, r, func(userId int, meetId int, targetId int) error {
+		err := h.MeetingUC.RemoveOrganizer(userId, meetId, targetId)
+		if err == nil {
+			h.membershipChanged(meetId, targetId)
+		}
+		return err
+	})
+}
+
+// membershipChanged makes every server instance re-check the user's access to the meeting chat,
+// a zero userId re-checks the whole room
+func (h *MeetingHandler) membershipChanged(meetId int, userId int) {
+	if h.Broker == nil {
+		return
+	}
+	err := h.Broker.Publish(message.Event{MeetId: meetId, UserId: userId, Recheck: true})
+	if err != nil {
+		h.Log.LogError("meeting/delivery/http", "membershipChanged", err)
+	}
+}
+
+func (h *MeetingHandler) changeOrganizers(w http.ResponseWriter, r *http.Request,
+	change func(userId int, meetId int, targetId int) error) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	org := &models.MeetingOrganizer{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = org.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = change(userId, org.MeetId, org.UserId)
+	h.writeManageResult(w, err)
+}
+
+func (h *MeetingHandler) GetWaitlist(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	meetId, err := strconv.Atoi(r.URL.Query().Get("meetId"))
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	list, err := h.MeetingUC.GetWaitlist(userId, meetId)
+	if err != nil {
+		h.writeManageResult(w, err)
+		return
+	}
+	hu.WriteJson(w, list)
+}
+
+func (h *MeetingHandler) MoveInWaitlist(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	move := &models.WaitlistMove{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = move.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil || move.Position < 1 {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = h.MeetingUC.MoveInWaitlist(userId, *move)
+	h.writeManageResult(w, err)
+}
+
+func (h *MeetingHandler) GetRegRequests(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	meetId, err := strconv.Atoi(r.URL.Query().Get("meetId"))
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	requests, err := h.MeetingUC.GetRegRequests(userId, meetId)
+	if err != nil {
+		h.writeManageResult(w, err)
+		return
+	}
+	hu.WriteJson(w, requests)
+}
+
+func (h *MeetingHandler) ApproveReg(w http.ResponseWriter, r *http.Request) {
+	h.decideRegRequest(w, r, h.MeetingUC.ApproveReg)
+}
+
+func (h *MeetingHandler) RejectReg(w http.ResponseWriter, r *http.Request) {
+	h.decideRegRequest(w, r, h.MeetingUC.RejectReg)
+}
+
+func (h *MeetingHandler) decideRegRequest(w http.ResponseWriter, r *http.Request,
+	decide func(userId int, meetId int, targetId int) error) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	req := &models.RegRequest{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = req.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = decide(userId, req.MeetId, req.UserId)
+	h.writeManageResult(w, err)
+}
+
+func (h *MeetingHandler) writeManageResult(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, meeting.ErrMeetingNotFound), errors.Is(err, meeting.ErrNoRegRequest):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+	case errors.Is(err, meeting.ErrAccessDenied):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusForbidden, ErrMsg: err.Error()})
+	case errors.Is(err, meeting.ErrNotParticipant), errors.Is(err, meeting.ErrNotWaiting):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+	case errors.Is(err, meeting.ErrNoSeatsLeft), errors.Is(err, meeting.ErrMeetingCancelled):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusConflict, ErrMsg: err.Error()})
+	default:
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+	}
+}
+
+func (h *MeetingHandler) GetNearbyMeetings(w http.ResponseWriter, r *http.Request) {
+	params, err := h.GetPageParams(r, CursorByDistance)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
+	var errLat, errLon error
+	params.Lat, errLat = strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	params.Lon, errLon = strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+	if errLat != nil || errLon != nil || params.Lat < -90 || params.Lat > 90 ||
+		params.Lon < -180 || params.Lon > 180 {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "invalid coordinates"})
+		return
+	}
+	params.RadiusKm, err = strconv.ParseFloat(r.URL.Query().Get("radiusKm"), 64)
+	if err != nil || params.RadiusKm <= 0 {
+		params.RadiusKm = DefRadiusKm
+	}
+	params.RadiusKm = math.Min(params.RadiusKm, MaxRadiusKm)
+	meets, err := h.MeetingUC.FilterNearby(params)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	h.WritePage(w, r, meets, params, CursorByDistance)
+}
+
 func (h *MeetingHandler) SearchMeetings(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r, CursorById)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	searchQuery := strings.TrimSpace(r.URL.Query().Get("query"))
 	if searchQuery == "" {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
 		return
 	}
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil || limit <= 0 {
-		limit = -1
+	meets, err := h.MeetingUC.SearchMeetings(params, searchQuery, params.CountLimit)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	h.WritePage(w, r, meets, params, CursorById)
+}
+
+func (h *MeetingHandler) QueryMeetings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var params meeting.QueryParams
+	switch query.Get("sort") {
+	case "", meeting.SortByDate:
+		params.SortBy = meeting.SortByDate
+	case meeting.SortByLikes:
+		params.SortBy = meeting.SortByLikes
+	default:
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "invalid sort order"})
+		return
+	}
+	var err error
+	params.FilterParams, err = h.GetPageParams(r, params.SortBy)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
+	for _, t := range query["tag"] {
+		if t = strings.TrimSpace(t); t != "" {
+			params.Tags = append(params.Tags, t)
+		}
+	}
+	switch query.Get("tagMode") {
+	case "", "any":
+	case "all":
+		params.AllTags = true
+	default:
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "invalid tag mode"})
+		return
+	}
+	if author := query.Get("authorId"); author != "" {
+		params.AuthorId, err = strconv.Atoi(author)
+		if err != nil || params.AuthorId <= 0 {
+			hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "invalid author id"})
+			return
+		}
+	}
+	params.City = strings.TrimSpace(query.Get("city"))
+	params.FreeSeats = query.Get("freeSeats") == "true"
+	params.Text = strings.TrimSpace(query.Get("query"))
+	res, err := h.MeetingUC.QueryMeetings(params)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	page := h.Page(res.Meetings, params.FilterParams, params.SortBy)
+	res.Meetings, res.NextCursor, res.HasMore = page.Meetings, page.NextCursor, page.HasMore
+	hu.WriteJson(w, res)
+}
+
+func ToEvent(card models.MeetingCard) (ical.Event, error) {
+	layout := "2006-01-02T15:04:05.000Z0700"
+	start, errSt := time.Parse(layout, card.StartDate)
+	end, errEnd := time.Parse(layout, card.EndDate)
+	if errSt != nil || errEnd != nil {
+		return ical.Event{}, errors.New("invalid datetime format")
+	}
+	location := card.City
+	if card.Address != "" && card.City != "" {
+		location = card.Address + ", " + card.City
+	} else if card.Address != "" {
+		location = card.Address
+	}
+	return ical.Event{
+		UID:         "meeting-" + strconv.Itoa(card.Label.Id) + "@konami",
+		Summary:     card.Label.Title,
+		Description: card.Text,
+		Location:    location,
+		Start:       start,
+		End:         end,
+		Cancelled:   card.Cancelled,
+	}, nil
+}
+
+func writeCalendar(w http.ResponseWriter, events []ical.Event, filename string) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename=\""+filename+"\"")
+	_, _ = w.Write(ical.Calendar("Konami", events, time.Now()))
+}
+
+func (h *MeetingHandler) GetMeetingCalendar(w http.ResponseWriter, r *http.Request) {
+	meetId, err := strconv.Atoi(r.URL.Query().Get("meetId"))
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+		return
+	}
+	meet, err := h.MeetingUC.GetMeeting(meetId, -1, false)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+		return
+	}
+	event, err := ToEvent(*meet.Card)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	writeCalendar(w, []ical.Event{event}, "meeting-"+strconv.Itoa(meetId)+".ics")
+}
+
+func (h *MeetingHandler) GetFeedCalendar(w http.ResponseWriter, r *http.Request) {
+	meets, err := h.MeetingUC.GetFeedMeetings(r.URL.Query().Get("token"))
+	if errors.Is(err, meeting.ErrInvalidFeedToken) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+		return
 	}
-	var meets []models.Meeting
-	meets, err = h.MeetingUC.SearchMeetings(params, searchQuery, limit)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, meets)
+	events := make([]ical.Event, 0, len(meets))
+	for _, meet := range meets {
+		event, err := ToEvent(*meet.Card)
+		if err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	writeCalendar(w, events, "konami.ics")
+}
+
+func (h *MeetingHandler) GetFeedToken(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	token, err := h.MeetingUC.GetFeedToken(userId)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	hu.WriteJson(w, models.FeedToken{Token: token})
+}
+
+func (h *MeetingHandler) RevokeFeedToken(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	err := h.MeetingUC.RevokeFeedToken(userId)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
 }
@@ -5,9 +5,14 @@ import (
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"konami_backend/internal/pkg/meeting"
+	"konami_backend/internal/pkg/message"
 	"konami_backend/internal/pkg/middleware"
 	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/utils/cursor"
+	"konami_backend/internal/pkg/utils/recurrence"
 	"net/http"
 	"testing"
 	"time"
@@ -41,7 +46,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -81,7 +86,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -121,7 +126,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -161,7 +166,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -227,7 +232,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -267,7 +272,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -333,7 +338,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -373,7 +378,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -413,7 +418,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -453,7 +458,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -520,7 +525,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -561,7 +566,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -628,7 +633,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -669,7 +674,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -1182,11 +1187,11 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     -1,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
-		}, "test", -1).Return([]models.Meeting{}, nil)
+		}, "test", 11).Return([]models.Meeting{}, nil)
 
 		apitest.New("GetMeetingsList").
 			Handler(handler).
@@ -1223,11 +1228,11 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     -1,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
-		}, "test", -1).Return([]models.Meeting{}, errors.New("Err"))
+		}, "test", 11).Return([]models.Meeting{}, errors.New("Err"))
 
 		apitest.New("GetMeetingsList").
 			Handler(handler).
@@ -1311,7 +1316,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -1351,7 +1356,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -1406,7 +1411,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -1446,7 +1451,7 @@ func TestSessions(t *testing.T) {
 			StartDate:  time1,
 			EndDate:    time2,
 			PrevId:     3,
-			CountLimit: 10,
+			CountLimit: 11,
 			UserId:     4,
 			PrevLikes:  MaxLikes,
 			PrevStart:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -1476,4 +1481,778 @@ func TestSessions(t *testing.T) {
 			End()
 	})
 
+	t.Run("AddOrganizer", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.AddOrganizer, args)
+
+		testOrg := &models.MeetingOrganizer{MeetId: 1, UserId: 5}
+		testOrgJSON, _ := json.Marshal(testOrg)
+		testHandler.MaxReqSize = 10000
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().AddOrganizer(4, 1, 5).Return(nil)
+
+		apitest.New("AddOrganizer").
+			Handler(handler).
+			Method("Post").
+			URL("/meeting/organizers").
+			Body(string(testOrgJSON)).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("AddOrganizerForbidden", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.AddOrganizer, args)
+
+		testOrg := &models.MeetingOrganizer{MeetId: 1, UserId: 5}
+		testOrgJSON, _ := json.Marshal(testOrg)
+		testHandler.MaxReqSize = 10000
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().AddOrganizer(4, 1, 5).Return(meeting.ErrAccessDenied)
+
+		apitest.New("AddOrganizer").
+			Handler(handler).
+			Method("Post").
+			URL("/meeting/organizers").
+			Body(string(testOrgJSON)).
+			Expect(t).
+			Status(http.StatusForbidden).
+			End()
+	})
+
+	t.Run("RemoveOrganizerUnauthorized", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		handler := middleware.SetMuxVars(testHandler.RemoveOrganizer, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		apitest.New("RemoveOrganizer").
+			Handler(handler).
+			Method("Delete").
+			URL("/meeting/organizers").
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("RemoveOrganizerRecheck", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.RemoveOrganizer, args)
+
+		testOrgJSON, _ := json.Marshal(&models.MeetingOrganizer{MeetId: 1, UserId: 5})
+		testHandler.MaxReqSize = 10000
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		b := message.NewMockBroker(ctrl)
+		testHandler.MeetingUC = m
+		testHandler.Broker = b
+		defer func() { testHandler.Broker = nil }()
+
+		// the removed organizer loses the chat unless they are also registered
+		m.EXPECT().RemoveOrganizer(4, 1, 5).Return(nil)
+		b.EXPECT().Publish(message.Event{MeetId: 1, UserId: 5, Recheck: true}).Return(nil)
+
+		apitest.New("RemoveOrganizer").
+			Handler(handler).
+			Method("Delete").
+			URL("/meeting/organizers").
+			Body(string(testOrgJSON)).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		m.EXPECT().RemoveOrganizer(4, 1, 5).Return(meeting.ErrAccessDenied)
+
+		apitest.New("RemoveOrganizerForbidden").
+			Handler(handler).
+			Method("Delete").
+			URL("/meeting/organizers").
+			Body(string(testOrgJSON)).
+			Expect(t).
+			Status(http.StatusForbidden).
+			End()
+
+		// unregistering also changes the chat membership
+		handler = middleware.SetMuxVars(testHandler.UpdateMeeting, args)
+		reg := false
+		update := models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Reg: &reg}}
+		updateJSON, _ := json.Marshal(update)
+		m.EXPECT().UpdateMeeting(4, update).Return(nil)
+		b.EXPECT().Publish(message.Event{MeetId: 1, UserId: 4, Recheck: true}).Return(nil)
+
+		apitest.New("Unregister").
+			Handler(handler).
+			Method("Post").
+			URL("/meeting").
+			Body(string(updateJSON)).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("CancelMeeting", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.CancelMeeting, args)
+
+		testCancel := &models.MeetingCancel{MeetId: 1, Reason: "rain"}
+		testCancelJSON, _ := json.Marshal(testCancel)
+		testHandler.MaxReqSize = 10000
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().CancelMeeting(4, 1, "rain").Return(nil)
+
+		apitest.New("CancelMeeting").
+			Handler(handler).
+			Method("Post").
+			URL("/meeting/cancel").
+			Body(string(testCancelJSON)).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("DeleteMeeting", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "1"})
+
+		var args2 []middleware.RouteArgs
+		args2 = append(args2, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args2 = append(args2, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetVarsAndMux(testHandler.DeleteMeeting, args, args2)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		b := message.NewMockBroker(ctrl)
+		testHandler.MeetingUC = m
+		testHandler.Broker = b
+		defer func() { testHandler.Broker = nil }()
+
+		// subscribers of the chat are dropped on every instance
+		m.EXPECT().DeleteMeeting(4, 1).Return(nil)
+		b.EXPECT().Publish(message.Event{MeetId: 1, Recheck: true}).Return(nil)
+
+		apitest.New("DeleteMeeting").
+			Handler(handler).
+			Method("Delete").
+			URL("/meeting").
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("DeleteMeetingNotFound", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "1"})
+
+		var args2 []middleware.RouteArgs
+		args2 = append(args2, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args2 = append(args2, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetVarsAndMux(testHandler.DeleteMeeting, args, args2)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().DeleteMeeting(4, 1).Return(meeting.ErrMeetingNotFound)
+
+		apitest.New("DeleteMeeting").
+			Handler(handler).
+			Method("Delete").
+			URL("/meeting").
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+	})
+
+	t.Run("UpdateMeetingNoSeats", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.UpdateMeeting, args)
+
+		reg := true
+		testUpd := &models.MeetingUpdate{
+			MeetId: 1,
+			Fields: &models.MeetUpdateFields{Reg: &reg},
+		}
+		testUpdJSON, _ := json.Marshal(testUpd)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().UpdateMeeting(4, *testUpd).Return(meeting.ErrNoSeatsLeft)
+
+		apitest.New("UpdateMeeting").
+			Handler(handler).
+			Method("Patch").
+			URL("/meeting").
+			Body(string(testUpdJSON)).
+			Expect(t).
+			Status(http.StatusConflict).
+			End()
+	})
+
+	t.Run("GetWaitlist", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "1"})
+
+		var args2 []middleware.RouteArgs
+		args2 = append(args2, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		handler := middleware.SetVarsAndMux(testHandler.GetWaitlist, args, args2)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		testList := []models.WaitlistEntry{{Position: 1, User: &models.ProfileLabel{Id: 5}}}
+		testListJSON, _ := json.Marshal(testList)
+		m.EXPECT().GetWaitlist(4, 1).Return(testList, nil)
+
+		apitest.New("GetWaitlist").
+			Handler(handler).
+			Method("Get").
+			URL("/meeting/waitlist").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testListJSON)).
+			End()
+	})
+
+	t.Run("GetWaitlistForbidden", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "1"})
+
+		var args2 []middleware.RouteArgs
+		args2 = append(args2, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		handler := middleware.SetVarsAndMux(testHandler.GetWaitlist, args, args2)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().GetWaitlist(4, 1).Return(nil, meeting.ErrAccessDenied)
+
+		apitest.New("GetWaitlistForbidden").
+			Handler(handler).
+			Method("Get").
+			URL("/meeting/waitlist").
+			Expect(t).
+			Status(http.StatusForbidden).
+			End()
+	})
+
+	t.Run("MoveInWaitlist", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.MoveInWaitlist, args)
+
+		testMove := models.WaitlistMove{MeetId: 1, UserId: 5, Position: 1}
+		testMoveJSON, _ := json.Marshal(testMove)
+		testHandler.MaxReqSize = 10000
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().MoveInWaitlist(4, testMove).Return(meeting.ErrNotWaiting)
+
+		apitest.New("MoveInWaitlist").
+			Handler(handler).
+			Method("Patch").
+			URL("/meeting/waitlist").
+			Body(string(testMoveJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("ApproveReg", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.ApproveReg, args)
+
+		testReq := &models.RegRequest{MeetId: 1, UserId: 5}
+		testReqJSON, _ := json.Marshal(testReq)
+		testHandler.MaxReqSize = 10000
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().ApproveReg(4, 1, 5).Return(nil)
+
+		apitest.New("ApproveReg").
+			Handler(handler).
+			Method("Post").
+			URL("/meeting/requests/approve").
+			Body(string(testReqJSON)).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("ApproveRegNoSeats", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.ApproveReg, args)
+
+		testReq := &models.RegRequest{MeetId: 1, UserId: 5}
+		testReqJSON, _ := json.Marshal(testReq)
+		testHandler.MaxReqSize = 10000
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().ApproveReg(4, 1, 5).Return(meeting.ErrNoSeatsLeft)
+
+		apitest.New("ApproveRegNoSeats").
+			Handler(handler).
+			Method("Post").
+			URL("/meeting/requests/approve").
+			Body(string(testReqJSON)).
+			Expect(t).
+			Status(http.StatusConflict).
+			End()
+	})
+
+	t.Run("RejectRegNotFound", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.RejectReg, args)
+
+		testReq := &models.RegRequest{MeetId: 1, UserId: 5}
+		testReqJSON, _ := json.Marshal(testReq)
+		testHandler.MaxReqSize = 10000
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().RejectReg(4, 1, 5).Return(meeting.ErrNoRegRequest)
+
+		apitest.New("RejectRegNotFound").
+			Handler(handler).
+			Method("Post").
+			URL("/meeting/requests/reject").
+			Body(string(testReqJSON)).
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+	})
+
+	t.Run("GetRegRequests", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "1"})
+
+		var args2 []middleware.RouteArgs
+		args2 = append(args2, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		handler := middleware.SetVarsAndMux(testHandler.GetRegRequests, args, args2)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		testList := []*models.ProfileLabel{{Id: 5, Name: "test"}}
+		testListJSON, _ := json.Marshal(testList)
+		m.EXPECT().GetRegRequests(4, 1).Return(testList, nil)
+
+		apitest.New("GetRegRequests").
+			Handler(handler).
+			Method("Get").
+			URL("/meeting/requests").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testListJSON)).
+			End()
+	})
+
+	t.Run("CreateMeetingInvalidRule", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.CreateMeeting, args)
+
+		rule := "FREQ=YEARLY"
+		testHandler.MaxReqSize = 10000
+		testData := &models.MeetingData{Recurrence: &rule}
+		testDataJSON, _ := json.Marshal(testData)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().CreateMeeting(4, *testData).
+			Return(0, recurrence.ErrInvalidRule)
+
+		apitest.New("CreateMeetingInvalidRule").
+			Handler(handler).
+			Method("Post").
+			URL("/meeting").
+			Body(string(testDataJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("GetMeetingCalendar", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "1"})
+		handler := middleware.SetVarsAndMux(testHandler.GetMeetingCalendar, args, nil)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		testMeet := models.MeetingDetails{Card: &models.MeetingCard{
+			Label:     &models.MeetingLabel{Id: 1, Title: "Go meetup"},
+			Address:   "Baumanskaya 5",
+			City:      "Moscow",
+			StartDate: "2020-12-01T19:00:00.000Z",
+			EndDate:   "2020-12-01T21:00:00.000Z",
+		}}
+		m.EXPECT().GetMeeting(1, -1, false).Return(testMeet, nil)
+
+		res := apitest.New("GetMeetingCalendar").
+			Handler(handler).
+			Method("Get").
+			URL("/meeting.ics").
+			Expect(t).
+			Status(http.StatusOK).
+			Header("Content-Type", "text/calendar; charset=utf-8").
+			End()
+		body, _ := ioutil.ReadAll(res.Response.Body)
+		assert.Contains(t, string(body), "UID:meeting-1@konami\r\n")
+		assert.Contains(t, string(body), "LOCATION:Baumanskaya 5\\, Moscow\r\n")
+		assert.Contains(t, string(body), "DTSTART:20201201T190000Z\r\n")
+	})
+
+	t.Run("GetFeedCalendarInvalidToken", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "token", Value: "bad"})
+		handler := middleware.SetVarsAndMux(testHandler.GetFeedCalendar, args, nil)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().GetFeedMeetings("bad").Return(nil, meeting.ErrInvalidFeedToken)
+
+		apitest.New("GetFeedCalendarInvalidToken").
+			Handler(handler).
+			Method("Get").
+			URL("/calendar.ics").
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+	})
+
+	t.Run("GetFeedToken", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		handler := middleware.SetMuxVars(testHandler.GetFeedToken, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().GetFeedToken(4).Return("secret", nil)
+
+		apitest.New("GetFeedToken").
+			Handler(handler).
+			Method("Get").
+			URL("/meetings/feed").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(`{"token":"secret"}`).
+			End()
+	})
+
+	t.Run("RevokeFeedToken", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(testHandler.RevokeFeedToken, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		m.EXPECT().RevokeFeedToken(4).Return(nil)
+
+		apitest.New("RevokeFeedToken").
+			Handler(handler).
+			Method("Delete").
+			URL("/meetings/feed").
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("GetNearbyMeetings", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "lat", Value: "55.75"})
+		args = append(args, middleware.QueryArgs{Key: "lon", Value: "37.61"})
+		args = append(args, middleware.QueryArgs{Key: "radiusKm", Value: "1000"})
+		args = append(args, middleware.QueryArgs{Key: "prevDistance", Value: "1.5"})
+		args = append(args, middleware.QueryArgs{Key: "prevId", Value: "4"})
+		handler := middleware.SetVarsAndMux(testHandler.GetNearbyMeetings, args, nil)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		distance := 2.5
+		testMeets := []models.Meeting{{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 5}}, Distance: &distance}}
+		testMeetsJSON, _ := json.Marshal(testMeets)
+		m.EXPECT().FilterNearby(gomock.Any()).
+			DoAndReturn(func(params meeting.FilterParams) ([]models.Meeting, error) {
+				assert.Equal(t, 55.75, params.Lat)
+				assert.Equal(t, 37.61, params.Lon)
+				assert.Equal(t, MaxRadiusKm, params.RadiusKm)
+				assert.Equal(t, 1.5, params.PrevDistance)
+				assert.Equal(t, 4, params.PrevId)
+				return testMeets, nil
+			})
+
+		apitest.New("GetNearbyMeetings").
+			Handler(handler).
+			Method("Get").
+			URL("/meetings/nearby").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testMeetsJSON)).
+			End()
+	})
+
+	t.Run("GetNearbyMeetingsBadCoords", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "lat", Value: "100"})
+		args = append(args, middleware.QueryArgs{Key: "lon", Value: "37.61"})
+		handler := middleware.SetVarsAndMux(testHandler.GetNearbyMeetings, args, nil)
+
+		apitest.New("GetNearbyMeetingsBadCoords").
+			Handler(handler).
+			Method("Get").
+			URL("/meetings/nearby").
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("QueryMeetings", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "tag", Value: "golang"})
+		args = append(args, middleware.QueryArgs{Key: "tag", Value: "backend"})
+		args = append(args, middleware.QueryArgs{Key: "tagMode", Value: "all"})
+		args = append(args, middleware.QueryArgs{Key: "city", Value: " Moscow "})
+		args = append(args, middleware.QueryArgs{Key: "freeSeats", Value: "true"})
+		args = append(args, middleware.QueryArgs{Key: "authorId", Value: "3"})
+		args = append(args, middleware.QueryArgs{Key: "query", Value: "go"})
+		args = append(args, middleware.QueryArgs{Key: "sort", Value: "likes"})
+		handler := middleware.SetVarsAndMux(testHandler.QueryMeetings, args, nil)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		testRes := models.MeetingQueryResult{
+			Meetings: []models.Meeting{{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 5}}}},
+			Facets: models.MeetingFacets{
+				Tags:   []models.FacetCount{{Value: "golang", Count: 1}},
+				Cities: []models.FacetCount{{Value: "Moscow", Count: 1}},
+			},
+		}
+		testResJSON, _ := json.Marshal(testRes)
+		m.EXPECT().QueryMeetings(gomock.Any()).
+			DoAndReturn(func(params meeting.QueryParams) (models.MeetingQueryResult, error) {
+				assert.Equal(t, []string{"golang", "backend"}, params.Tags)
+				assert.True(t, params.AllTags)
+				assert.Equal(t, "Moscow", params.City)
+				assert.True(t, params.FreeSeats)
+				assert.Equal(t, 3, params.AuthorId)
+				assert.Equal(t, "go", params.Text)
+				assert.Equal(t, meeting.SortByLikes, params.SortBy)
+				return testRes, nil
+			})
+
+		apitest.New("QueryMeetings").
+			Handler(handler).
+			Method("Get").
+			URL("/meetings/query").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testResJSON)).
+			End()
+	})
+
+	t.Run("QueryMeetingsBadParams", func(t *testing.T) {
+		for _, arg := range []middleware.QueryArgs{
+			{Key: "sort", Value: "relevance"},
+			{Key: "tagMode", Value: "none"},
+			{Key: "authorId", Value: "abc"},
+		} {
+			handler := middleware.SetVarsAndMux(testHandler.QueryMeetings, []middleware.QueryArgs{arg}, nil)
+
+			apitest.New("QueryMeetingsBadParams").
+				Handler(handler).
+				Method("Get").
+				URL("/meetings/query").
+				Expect(t).
+				Status(http.StatusBadRequest).
+				End()
+		}
+	})
+
+	t.Run("GetTopMeetingsCursor", func(t *testing.T) {
+		testHandler.Cursors = cursor.NewSigner([]byte("test"))
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "limit", Value: "2"})
+		args = append(args, middleware.QueryArgs{Key: "cursor", Value: ""})
+		handler := middleware.SetVarsAndMux(testHandler.GetTopMeetingsList, args, nil)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := meeting.NewMockUseCase(ctrl)
+		testHandler.MeetingUC = m
+
+		testMeets := []models.Meeting{
+			{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 4}, LikesCount: 9}},
+			{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 7}, LikesCount: 5}},
+			{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 2}, LikesCount: 5}},
+		}
+		m.EXPECT().GetTopMeetings(gomock.Any()).
+			DoAndReturn(func(params meeting.FilterParams) ([]models.Meeting, error) {
+				assert.Equal(t, 3, params.CountLimit)
+				return testMeets, nil
+			})
+
+		var page models.MeetingPage
+		apitest.New("GetTopMeetingsCursor").
+			Handler(handler).
+			Method("Get").
+			URL("/meetings/top").
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(&page)
+		assert.True(t, page.HasMore)
+		assert.Len(t, page.Meetings, 2)
+
+		args[1].Value = page.NextCursor
+		handler = middleware.SetVarsAndMux(testHandler.GetTopMeetingsList, args, nil)
+		m.EXPECT().GetTopMeetings(gomock.Any()).
+			DoAndReturn(func(params meeting.FilterParams) ([]models.Meeting, error) {
+				assert.Equal(t, 7, params.PrevId)
+				assert.Equal(t, 5, params.PrevLikes)
+				return testMeets[2:], nil
+			})
+		testPageJSON, _ := json.Marshal(models.MeetingPage{Meetings: testMeets[2:]})
+
+		apitest.New("GetTopMeetingsCursor").
+			Handler(handler).
+			Method("Get").
+			URL("/meetings/top").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testPageJSON)).
+			End()
+
+		// a cursor issued for another ordering is rejected
+		handler = middleware.SetVarsAndMux(testHandler.GetMeetingsList, args, nil)
+		apitest.New("GetTopMeetingsCursor").
+			Handler(handler).
+			Method("Get").
+			URL("/meetings").
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		// legacy clients keep getting a bare array
+		handler = middleware.SetVarsAndMux(testHandler.GetTopMeetingsList, args[:1], nil)
+		m.EXPECT().GetTopMeetings(gomock.Any()).Return(testMeets, nil)
+		testJSON, _ := json.Marshal(testMeets[:2])
+
+		apitest.New("GetTopMeetingsLegacy").
+			Handler(handler).
+			Method("Get").
+			URL("/meetings/top").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testJSON)).
+			End()
+	})
 }
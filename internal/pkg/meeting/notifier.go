@@ -0,0 +1,14 @@
+//go:generate mockgen -source=notifier.go -destination=./notifier_mock.go -package=meeting
+package meeting
+
+const (
+	EventLiked        = "liked"
+	EventRegistered   = "registered"
+	EventRegRequested = "regRequested"
+)
+
+type Notifier interface {
+	WaitlistPromoted(meetId int, userIds []int)
+	// MeetingEvent tells the meeting author that userId liked or joined the meeting
+	MeetingEvent(eventType string, meetId int, authorId int, userId int)
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: notifier.go
+
+// Package meeting is a generated GoMock package.
+package meeting
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockNotifier is a mock of Notifier interface
+type MockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierMockRecorder
+}
+
+// MockNotifierMockRecorder is the mock recorder for MockNotifier
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
+}
+
+// NewMockNotifier creates a new mock instance
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
+	return m.recorder
+}
+
+// WaitlistPromoted mocks base method
+func (m *MockNotifier) WaitlistPromoted(meetId int, userIds []int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WaitlistPromoted", meetId, userIds)
+}
+
+// WaitlistPromoted indicates an expected call of WaitlistPromoted
+func (mr *MockNotifierMockRecorder) WaitlistPromoted(meetId, userIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitlistPromoted", reflect.TypeOf((*MockNotifier)(nil).WaitlistPromoted), meetId, userIds)
+}
+
+// MeetingEvent mocks base method
+func (m *MockNotifier) MeetingEvent(eventType string, meetId, authorId, userId int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MeetingEvent", eventType, meetId, authorId, userId)
+}
+
+// MeetingEvent indicates an expected call of MeetingEvent
+func (mr *MockNotifierMockRecorder) MeetingEvent(eventType, meetId, authorId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MeetingEvent", reflect.TypeOf((*MockNotifier)(nil).MeetingEvent), eventType, meetId, authorId, userId)
+}
@@ -9,25 +9,89 @@ import (
 
 var ErrMeetingNotFound = errors.New("meeting not found")
 var ErrNoSeatsLeft = errors.New("no meeting seats left")
+var ErrAlreadyRegistered = errors.New("user already registered")
+var ErrSeatsOccupied = errors.New("seats count is less than registrations count")
+var ErrAccessDenied = errors.New("meeting access denied")
+var ErrNotParticipant = errors.New("user is not a meeting participant")
+var ErrMeetingCancelled = errors.New("meeting cancelled")
+var ErrSeatsAvailable = errors.New("meeting has free seats")
+var ErrNotWaiting = errors.New("user is not in the waitlist")
+var ErrNoRegRequest = errors.New("registration request not found")
+var ErrInvalidFeedToken = errors.New("invalid calendar feed token")
+var ErrInvalidLocation = errors.New("invalid meeting coordinates")
+
+const (
+	RoleAuthor      = "author"
+	RoleOrganizer   = "organizer"
+	RoleParticipant = "participant"
+	RoleGuest       = ""
+)
+
+const (
+	ScopeSingle    = "single"
+	ScopeFollowing = "following"
+)
+
+const (
+	SortByDate  = "date"
+	SortByLikes = "likes"
+)
+
+func IsOrganizer(role string) bool {
+	return role == RoleAuthor || role == RoleOrganizer
+}
 
 type FilterParams struct {
-	StartDate  time.Time
-	EndDate    time.Time
-	PrevId     int
-	PrevLikes  int
-	PrevStart  time.Time
-	CountLimit int
-	UserId     int
+	StartDate     time.Time
+	EndDate       time.Time
+	PrevId        int
+	PrevLikes     int
+	PrevStart     time.Time
+	CountLimit    int
+	UserId        int
+	WithCancelled bool
+	Lat           float64
+	Lon           float64
+	RadiusKm      float64
+	PrevDistance  float64
+}
+
+type QueryParams struct {
+	FilterParams
+	Tags      []string
+	AllTags   bool
+	City      string
+	FreeSeats bool
+	AuthorId  int
+	Text      string
+	SortBy    string
 }
 
 type Repository interface {
 	CreateMeeting(meeting models.Meeting) (meetingId int, err error)
+	CreateSeries(rule string, meetings []models.Meeting) (meetingIds []int, err error)
+	GetSeriesFollowing(meetId int) ([]models.MeetingCard, error)
+	CountCoverRefs(cover string) (int, error)
 	GetMeeting(meetingId, userId int, authorized bool) (models.MeetingDetails, error)
 	SetLike(meetId int, userId int) error
 	RemoveLike(meetId int, userId int) error
 	SetReg(meetId int, userId int) error
-	RemoveReg(meetId int, userId int) error
-	UpdateMeeting(update models.MeetingCard) error
+	RemoveReg(meetId int, userId int) (promoted []int, err error)
+	RequestReg(meetId int, userId int) error
+	GetRegRequests(meetId int) ([]*models.ProfileLabel, error)
+	ApproveReg(meetId int, userId int) error
+	RejectReg(meetId int, userId int) error
+	JoinWaitlist(meetId int, userId int) error
+	LeaveWaitlist(meetId int, userId int) error
+	GetWaitlist(meetId int) ([]models.WaitlistEntry, error)
+	MoveInWaitlist(meetId int, userId int, position int) error
+	GetRole(meetId int, userId int) (string, error)
+	GetMemberMeetingIds(userId int) ([]int, error)
+	AddOrganizer(meetId int, userId int) error
+	RemoveOrganizer(meetId int, userId int) error
+	UpdateMeeting(update models.MeetingCard) (promoted []int, err error)
+	CancelMeeting(meetId int, reason string) error
+	DeleteMeeting(meetId int) error
 	GetNextMeetings(params FilterParams) ([]models.Meeting, error)
 	GetTopMeetings(params FilterParams) ([]models.Meeting, error)
 	FilterLiked(params FilterParams) ([]models.Meeting, error)
@@ -38,4 +102,12 @@ type Repository interface {
 	FilterTagged(params FilterParams, tags []string) ([]models.Meeting, error)
 	FilterSimilar(params FilterParams, meetingId int) ([]models.Meeting, error)
 	SearchMeetings(params FilterParams, meetingName string, limit int) ([]models.Meeting, error)
+	FilterNearby(params FilterParams) ([]models.Meeting, error)
+	QueryMeetings(params QueryParams) ([]models.Meeting, error)
+	QueryFacets(params QueryParams) (models.MeetingFacets, error)
+	GetFeedToken(userId int) (string, error)
+	SetFeedToken(userId int, token string) error
+	RemoveFeedToken(userId int) error
+	GetFeedOwner(token string) (userId int, err error)
+	GetFeedMeetings(userId int, since time.Time, limit int) ([]models.Meeting, error)
 }
@@ -9,6 +9,7 @@ import (
 	"konami_backend/internal/pkg/models"
 	"konami_backend/internal/pkg/profile"
 	tagRepo "konami_backend/internal/pkg/tag/repository"
+	"math"
 	"regexp"
 	"time"
 )
@@ -27,33 +28,70 @@ func NewMeetingGormRepoLite(db *gorm.DB) meeting.Repository {
 }
 
 type Meeting struct {
-	Id         int `gorm:"primaryKey;autoIncrement;"`
-	AuthorId   int
-	Title      string
-	Text       string
-	ImgSrc     string
-	Tags       []tagRepo.Tag `gorm:"many2many:meeting_tags;"`
-	City       string
-	Address    string
-	StartDate  time.Time
-	EndDate    time.Time
-	Seats      int
-	SeatsLeft  int
-	LikesCount int
-	Regs       []Registration `gorm:"foreignKey:MeetingId"`
-	Likes      []Like         `gorm:"foreignKey:MeetingId"`
+	Id           int `gorm:"primaryKey;autoIncrement;"`
+	AuthorId     int
+	Title        string
+	Text         string
+	ImgSrc       string
+	Tags         []tagRepo.Tag `gorm:"many2many:meeting_tags;"`
+	City         string
+	Address      string
+	StartDate    time.Time
+	EndDate      time.Time
+	Seats        int
+	SeatsLeft    int
+	LikesCount   int
+	Cancelled    bool
+	CancelReason string
+	Approval     bool
+	SeriesId     int            `gorm:"index"`
+	Lat          *float64       `gorm:"index:meeting_geo"`
+	Lon          *float64       `gorm:"index:meeting_geo"`
+	Regs         []Registration `gorm:"foreignKey:MeetingId"`
+	Likes        []Like         `gorm:"foreignKey:MeetingId"`
+	Organizers   []Organizer    `gorm:"foreignKey:MeetingId"`
 }
 
 type Registration struct {
 	Id        int `gorm:"primaryKey;autoIncrement;"`
-	MeetingId int
-	UserId    int
+	MeetingId int `gorm:"uniqueIndex:reg_meeting_user;"`
+	UserId    int `gorm:"uniqueIndex:reg_meeting_user;"`
 }
 
 type Like struct {
 	Id        int `gorm:"primaryKey;autoIncrement;"`
-	MeetingId int
-	UserId    int
+	MeetingId int `gorm:"uniqueIndex:like_meeting_user;"`
+	UserId    int `gorm:"uniqueIndex:like_meeting_user;"`
+}
+
+type Organizer struct {
+	Id        int `gorm:"primaryKey;autoIncrement;"`
+	MeetingId int `gorm:"uniqueIndex:org_meeting_user;"`
+	UserId    int `gorm:"uniqueIndex:org_meeting_user;"`
+}
+
+type WaitlistEntry struct {
+	Id        int `gorm:"primaryKey;autoIncrement;"`
+	MeetingId int `gorm:"uniqueIndex:wait_meeting_user;"`
+	UserId    int `gorm:"uniqueIndex:wait_meeting_user;"`
+	Position  int
+}
+
+type Series struct {
+	Id       int `gorm:"primaryKey;autoIncrement;"`
+	AuthorId int
+	Rule     string
+}
+
+type FeedToken struct {
+	UserId int    `gorm:"primaryKey;autoIncrement:false;"`
+	Token  string `gorm:"uniqueIndex;"`
+}
+
+type RegRequest struct {
+	Id        int `gorm:"primaryKey;autoIncrement;"`
+	MeetingId int `gorm:"uniqueIndex:req_meeting_user;"`
+	UserId    int `gorm:"uniqueIndex:req_meeting_user;"`
 }
 
 func (m *Meeting) TableName() string {
@@ -68,17 +106,43 @@ func (l *Like) TableName() string {
 	return "likes"
 }
 
+func (o *Organizer) TableName() string {
+	return "organizers"
+}
+
+func (w *WaitlistEntry) TableName() string {
+	return "waitlist"
+}
+
+func (r *RegRequest) TableName() string {
+	return "reg_requests"
+}
+
+func (s *Series) TableName() string {
+	return "meeting_series"
+}
+
+func (f *FeedToken) TableName() string {
+	return "feed_tokens"
+}
+
 func ToDbObject(data models.MeetingCard) (Meeting, error) {
 	m := Meeting{
-		AuthorId:   data.AuthorId,
-		Title:      data.Label.Title,
-		Text:       data.Text,
-		ImgSrc:     data.Label.Cover,
-		City:       data.City,
-		Address:    data.Address,
-		Seats:      data.Seats,
-		SeatsLeft:  data.SeatsLeft,
-		LikesCount: data.LikesCount,
+		AuthorId:     data.AuthorId,
+		Title:        data.Label.Title,
+		Text:         data.Text,
+		ImgSrc:       data.Label.Cover,
+		City:         data.City,
+		Address:      data.Address,
+		Seats:        data.Seats,
+		SeatsLeft:    data.SeatsLeft,
+		LikesCount:   data.LikesCount,
+		Cancelled:    data.Cancelled,
+		CancelReason: data.CancelReason,
+		Approval:     data.Approval,
+		SeriesId:     data.SeriesId,
+		Lat:          data.Lat,
+		Lon:          data.Lon,
 	}
 	m.Tags = make([]tagRepo.Tag, len(data.Tags))
 	for i, val := range data.Tags {
@@ -107,16 +171,22 @@ func ToMeetingLabel(obj Meeting) models.MeetingLabel {
 func ToMeetingCard(obj Meeting) models.MeetingCard {
 	label := ToMeetingLabel(obj)
 	m := models.MeetingCard{
-		Label:      &label,
-		AuthorId:   obj.AuthorId,
-		Text:       obj.Text,
-		Address:    obj.Address,
-		City:       obj.City,
-		StartDate:  obj.StartDate.Format("2006-01-02T15:04:05.000Z0700"),
-		EndDate:    obj.EndDate.Format("2006-01-02T15:04:05.000Z0700"),
-		Seats:      obj.Seats,
-		SeatsLeft:  obj.SeatsLeft,
-		LikesCount: obj.LikesCount,
+		Label:        &label,
+		AuthorId:     obj.AuthorId,
+		Text:         obj.Text,
+		Address:      obj.Address,
+		City:         obj.City,
+		StartDate:    obj.StartDate.Format("2006-01-02T15:04:05.000Z0700"),
+		EndDate:      obj.EndDate.Format("2006-01-02T15:04:05.000Z0700"),
+		Seats:        obj.Seats,
+		SeatsLeft:    obj.SeatsLeft,
+		LikesCount:   obj.LikesCount,
+		Cancelled:    obj.Cancelled,
+		CancelReason: obj.CancelReason,
+		Approval:     obj.Approval,
+		SeriesId:     obj.SeriesId,
+		Lat:          obj.Lat,
+		Lon:          obj.Lon,
 	}
 	m.Tags = make([]*models.Tag, len(obj.Tags))
 	for i, val := range obj.Tags {
@@ -126,6 +196,21 @@ func ToMeetingCard(obj Meeting) models.MeetingCard {
 	return m
 }
 
+func ToRole(obj Meeting, userId int, registered bool) string {
+	if obj.AuthorId == userId {
+		return meeting.RoleAuthor
+	}
+	for _, org := range obj.Organizers {
+		if org.UserId == userId {
+			return meeting.RoleOrganizer
+		}
+	}
+	if registered {
+		return meeting.RoleParticipant
+	}
+	return meeting.RoleGuest
+}
+
 func (h *MeetingGormRepo) ToMeeting(obj Meeting, userId int) models.Meeting {
 	card := ToMeetingCard(obj)
 	m := models.Meeting{Card: &card}
@@ -156,6 +241,21 @@ func (h *MeetingGormRepo) ToMeetingDetails(obj Meeting, userId int) (models.Meet
 		}
 		m.Registrations[i] = &label
 	}
+	m.Organizers = make([]*models.ProfileLabel, len(obj.Organizers))
+	for i, org := range obj.Organizers {
+		label, err := h.profRepo.GetLabel(org.UserId)
+		if err != nil {
+			return models.MeetingDetails{}, err
+		}
+		m.Organizers[i] = &label
+	}
+	if userId != -1 {
+		m.Role = ToRole(obj, userId, m.Reg)
+	}
+	if userId != -1 && !m.Reg {
+		m.WaitlistPos = h.WaitlistPosition(obj.Id, userId)
+		m.Pending = h.RegRequestExists(obj.Id, userId)
+	}
 	return m, err
 }
 
@@ -177,52 +277,39 @@ func (h *MeetingGormRepo) LikeExists(meetId int, userId int) bool {
 }
 
 func (h *MeetingGormRepo) SetLike(meetId int, userId int) error {
-	var l Like
-	newLike := false
-	db := h.db.
-		Where("meeting_id = ?", meetId).
-		Where("user_id = ?", userId).
-		First(&l)
-	if db.Error != nil {
-		l = Like{
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		l := Like{
 			MeetingId: meetId,
 			UserId:    userId,
 		}
-		db = h.db.Create(&l)
-		if db.Error == nil {
-			newLike = true
+		db := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&l)
+		if db.Error != nil || db.RowsAffected == 0 {
+			return db.Error
+		}
+		db = tx.Model(&Meeting{}).
+			Where("id = ?", meetId).
+			Update("likes_count", gorm.Expr("likes_count + 1"))
+		if db.Error == nil && db.RowsAffected == 0 {
+			return meeting.ErrMeetingNotFound
 		}
-	}
-	if !newLike {
 		return db.Error
-	}
-	var m Meeting
-	db = h.db.
-		Where("id = ?", meetId).
-		First(&m)
-	if db.Error == nil {
-		m.LikesCount += 1
-		db = h.db.Save(m)
-	}
-	return db.Error
+	})
 }
 
 func (h *MeetingGormRepo) RemoveLike(meetId int, userId int) error {
-	db := h.db.
-		Where("meeting_id = ?", meetId).
-		Where("user_id = ?", userId).
-		Delete(Like{})
-	if db.Error == nil && db.RowsAffected > 0 {
-		var m Meeting
-		db = h.db.
-			Where("id = ?", meetId).
-			First(&m)
-		if db.Error == nil {
-			m.LikesCount -= 1
-			db = h.db.Save(m)
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.
+			Where("meeting_id = ?", meetId).
+			Where("user_id = ?", userId).
+			Delete(Like{})
+		if db.Error != nil || db.RowsAffected == 0 {
+			return db.Error
 		}
-	}
-	return db.Error
+		return tx.Model(&Meeting{}).
+			Where("id = ?", meetId).
+			Where("likes_count > 0").
+			Update("likes_count", gorm.Expr("likes_count - 1")).Error
+	})
 }
 
 func (h *MeetingGormRepo) RegExists(meetId int, userId int) bool {
@@ -235,71 +322,538 @@ func (h *MeetingGormRepo) RegExists(meetId int, userId int) bool {
 }
 
 func (h *MeetingGormRepo) SetReg(meetId int, userId int) error {
-	var l Registration
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		return addRegistration(tx, meetId, userId)
+	})
+}
+
+func addRegistration(tx *gorm.DB, meetId int, userId int) error {
 	var m Meeting
-	db := h.db.
+	// Row lock serializes concurrent sign-ups for the same meeting
+	db := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 		Where("id = ?", meetId).
 		First(&m)
+	if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return meeting.ErrMeetingNotFound
+	}
+	if db.Error != nil {
+		return db.Error
+	}
+	if m.Cancelled {
+		return meeting.ErrMeetingCancelled
+	}
+	// An existing registration wins over a full meeting, the seat check is the
+	// conditional decrement below and rolls the insert back
+	l := Registration{
+		MeetingId: meetId,
+		UserId:    userId,
+	}
+	db = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&l)
 	if db.Error != nil {
 		return db.Error
 	}
-	if m.SeatsLeft == 0 {
+	if db.RowsAffected == 0 {
+		return meeting.ErrAlreadyRegistered
+	}
+	db = tx.Model(&Meeting{}).
+		Where("id = ?", meetId).
+		Where("seats_left > 0").
+		Update("seats_left", gorm.Expr("seats_left - 1"))
+	if db.Error == nil && db.RowsAffected == 0 {
 		return meeting.ErrNoSeatsLeft
 	}
-	newReg := false
-	db = h.db.
+	return db.Error
+}
+
+func (h *MeetingGormRepo) RegRequestExists(meetId int, userId int) bool {
+	var count int64
+	db := h.db.Model(&RegRequest{}).
 		Where("meeting_id = ?", meetId).
 		Where("user_id = ?", userId).
-		First(&l)
-	if db.Error != nil {
-		l = Registration{
+		Count(&count)
+	return db.Error == nil && count > 0
+}
+
+func (h *MeetingGormRepo) RequestReg(meetId int, userId int) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		var m Meeting
+		db := tx.Where("id = ?", meetId).First(&m)
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return meeting.ErrMeetingNotFound
+		}
+		if db.Error != nil {
+			return db.Error
+		}
+		if m.Cancelled {
+			return meeting.ErrMeetingCancelled
+		}
+		var regs int64
+		db = tx.Model(&Registration{}).
+			Where("meeting_id = ?", meetId).
+			Where("user_id = ?", userId).
+			Count(&regs)
+		if db.Error != nil {
+			return db.Error
+		}
+		if regs > 0 {
+			return meeting.ErrAlreadyRegistered
+		}
+		r := RegRequest{
 			MeetingId: meetId,
 			UserId:    userId,
 		}
-		db = h.db.Create(&l)
-		newReg = db.Error == nil
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&r).Error
+	})
+}
+
+func (h *MeetingGormRepo) GetRegRequests(meetId int) ([]*models.ProfileLabel, error) {
+	var requests []RegRequest
+	db := h.db.
+		Where("meeting_id = ?", meetId).
+		Order("id ASC").
+		Find(&requests)
+	if db.Error != nil {
+		return nil, db.Error
 	}
-	if newReg {
-		m.SeatsLeft -= 1
-		db = h.db.Save(m)
+	result := make([]*models.ProfileLabel, len(requests))
+	for i, r := range requests {
+		label, err := h.profRepo.GetLabel(r.UserId)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = &label
+	}
+	return result, nil
+}
+
+func (h *MeetingGormRepo) ApproveReg(meetId int, userId int) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.
+			Where("meeting_id = ?", meetId).
+			Where("user_id = ?", userId).
+			Delete(RegRequest{})
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return meeting.ErrNoRegRequest
+		}
+		return addRegistration(tx, meetId, userId)
+	})
+}
+
+func (h *MeetingGormRepo) RejectReg(meetId int, userId int) error {
+	db := h.db.
+		Where("meeting_id = ?", meetId).
+		Where("user_id = ?", userId).
+		Delete(RegRequest{})
+	if db.Error == nil && db.RowsAffected == 0 {
+		return meeting.ErrNoRegRequest
 	}
 	return db.Error
 }
 
-func (h *MeetingGormRepo) RemoveReg(meetId int, userId int) error {
+func (h *MeetingGormRepo) RemoveReg(meetId int, userId int) ([]int, error) {
+	var promoted []int
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.
+			Where("meeting_id = ?", meetId).
+			Where("user_id = ?", userId).
+			Delete(RegRequest{})
+		if db.Error != nil {
+			return db.Error
+		}
+		db = tx.
+			Where("meeting_id = ?", meetId).
+			Where("user_id = ?", userId).
+			Delete(Registration{})
+		if db.Error != nil || db.RowsAffected == 0 {
+			return db.Error
+		}
+		db = tx.Model(&Meeting{}).
+			Where("id = ?", meetId).
+			Update("seats_left", gorm.Expr("seats_left + 1"))
+		if db.Error != nil {
+			return db.Error
+		}
+		db = tx.
+			Where("meeting_id = ?", meetId).
+			Where("user_id = ?", userId).
+			Delete(Organizer{})
+		if db.Error != nil {
+			return db.Error
+		}
+		var err error
+		promoted, err = PromoteWaitlist(tx, meetId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// PromoteWaitlist moves users from the head of the waitlist into free seats,
+// on meetings with approval they become pending requests instead.
+// Must be called inside a transaction.
+func PromoteWaitlist(tx *gorm.DB, meetId int) ([]int, error) {
+	var m Meeting
+	db := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", meetId).
+		First(&m)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	if m.Cancelled || m.SeatsLeft <= 0 {
+		return nil, nil
+	}
+	promoted := []int{}
+	offered := 0
+	// Entries of users who are already registered free no seat, so the next
+	// ones in line are fetched until the seats are filled or the queue is empty
+	for offered < m.SeatsLeft {
+		var queue []WaitlistEntry
+		db = tx.
+			Where("meeting_id = ?", meetId).
+			Order("position ASC").Order("id ASC").
+			Limit(m.SeatsLeft - offered).
+			Find(&queue)
+		if db.Error != nil {
+			return nil, db.Error
+		}
+		if len(queue) == 0 {
+			break
+		}
+		entryIds := make([]int, len(queue))
+		for i, entry := range queue {
+			entryIds[i] = entry.Id
+			if m.Approval {
+				requested, err := requestFromWaitlist(tx, meetId, entry.UserId)
+				if err != nil {
+					return nil, err
+				}
+				if requested {
+					offered++
+				}
+				continue
+			}
+			reg := Registration{
+				MeetingId: meetId,
+				UserId:    entry.UserId,
+			}
+			db = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reg)
+			if db.Error != nil {
+				return nil, db.Error
+			}
+			if db.RowsAffected > 0 {
+				promoted = append(promoted, entry.UserId)
+				offered++
+			}
+		}
+		db = tx.Where("id IN ?", entryIds).Delete(WaitlistEntry{})
+		if db.Error != nil {
+			return nil, db.Error
+		}
+	}
+	if len(promoted) == 0 {
+		return promoted, nil
+	}
+	db = tx.Model(&Meeting{}).
+		Where("id = ?", meetId).
+		Update("seats_left", gorm.Expr("seats_left - ?", len(promoted)))
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	return promoted, nil
+}
+
+// requestFromWaitlist leaves the seat to the organizer's decision, the seat is
+// taken only when the request is approved
+func requestFromWaitlist(tx *gorm.DB, meetId int, userId int) (bool, error) {
+	var regs int64
+	db := tx.Model(&Registration{}).
+		Where("meeting_id = ?", meetId).
+		Where("user_id = ?", userId).
+		Count(&regs)
+	if db.Error != nil || regs > 0 {
+		return false, db.Error
+	}
+	r := RegRequest{
+		MeetingId: meetId,
+		UserId:    userId,
+	}
+	return true, tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&r).Error
+}
+
+func (h *MeetingGormRepo) WaitlistPosition(meetId int, userId int) int {
+	var entry WaitlistEntry
 	db := h.db.
 		Where("meeting_id = ?", meetId).
 		Where("user_id = ?", userId).
-		Delete(Registration{})
-	if db.Error == nil && db.RowsAffected > 0 {
+		First(&entry)
+	if db.Error != nil {
+		return 0
+	}
+	var ahead int64
+	db = h.db.Model(&WaitlistEntry{}).
+		Where("meeting_id = ?", meetId).
+		Where("position < ? OR (position = ? AND id < ?)", entry.Position, entry.Position, entry.Id).
+		Count(&ahead)
+	if db.Error != nil {
+		return 0
+	}
+	return int(ahead) + 1
+}
+
+func (h *MeetingGormRepo) JoinWaitlist(meetId int, userId int) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
 		var m Meeting
-		db = h.db.
+		db := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 			Where("id = ?", meetId).
 			First(&m)
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return meeting.ErrMeetingNotFound
+		}
+		if db.Error != nil {
+			return db.Error
+		}
+		if m.Cancelled {
+			return meeting.ErrMeetingCancelled
+		}
+		if m.SeatsLeft > 0 {
+			return meeting.ErrSeatsAvailable
+		}
+		var regs int64
+		db = tx.Model(&Registration{}).
+			Where("meeting_id = ?", meetId).
+			Where("user_id = ?", userId).
+			Count(&regs)
+		if db.Error != nil {
+			return db.Error
+		}
+		if regs > 0 {
+			return meeting.ErrAlreadyRegistered
+		}
+		entry := WaitlistEntry{
+			MeetingId: meetId,
+			UserId:    userId,
+			Position:  1,
+		}
+		var last WaitlistEntry
+		db = tx.
+			Where("meeting_id = ?", meetId).
+			Order("position DESC").
+			First(&last)
 		if db.Error == nil {
-			m.SeatsLeft += 1
-			db = h.db.Save(m)
+			entry.Position = last.Position + 1
+		} else if !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return db.Error
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error
+	})
+}
+
+func (h *MeetingGormRepo) LeaveWaitlist(meetId int, userId int) error {
+	db := h.db.
+		Where("meeting_id = ?", meetId).
+		Where("user_id = ?", userId).
+		Delete(WaitlistEntry{})
+	return db.Error
+}
+
+func (h *MeetingGormRepo) GetWaitlist(meetId int) ([]models.WaitlistEntry, error) {
+	var queue []WaitlistEntry
+	db := h.db.
+		Where("meeting_id = ?", meetId).
+		Order("position ASC").Order("id ASC").
+		Find(&queue)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	result := make([]models.WaitlistEntry, len(queue))
+	for i, entry := range queue {
+		label, err := h.profRepo.GetLabel(entry.UserId)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = models.WaitlistEntry{
+			Position: i + 1,
+			User:     &label,
 		}
 	}
+	return result, nil
+}
+
+func (h *MeetingGormRepo) MoveInWaitlist(meetId int, userId int, position int) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", meetId).
+			First(&Meeting{})
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return meeting.ErrMeetingNotFound
+		}
+		if db.Error != nil {
+			return db.Error
+		}
+		var queue []WaitlistEntry
+		db = tx.
+			Where("meeting_id = ?", meetId).
+			Order("position ASC").Order("id ASC").
+			Find(&queue)
+		if db.Error != nil {
+			return db.Error
+		}
+		from := -1
+		for i, entry := range queue {
+			if entry.UserId == userId {
+				from = i
+			}
+		}
+		if from == -1 {
+			return meeting.ErrNotWaiting
+		}
+		to := position - 1
+		if to < 0 {
+			to = 0
+		}
+		if to > len(queue)-1 {
+			to = len(queue) - 1
+		}
+		moved := queue[from]
+		queue = append(queue[:from], queue[from+1:]...)
+		queue = append(queue[:to], append([]WaitlistEntry{moved}, queue[to:]...)...)
+		for i, entry := range queue {
+			if entry.Position == i+1 {
+				continue
+			}
+			db = tx.Model(&WaitlistEntry{}).
+				Where("id = ?", entry.Id).
+				Update("position", i+1)
+			if db.Error != nil {
+				return db.Error
+			}
+		}
+		return nil
+	})
+}
+
+func (h *MeetingGormRepo) GetRole(meetId int, userId int) (string, error) {
+	var m Meeting
+	db := h.db.
+		Where("id = ?", meetId).
+		Preload("Organizers").
+		First(&m)
+	if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return meeting.RoleGuest, meeting.ErrMeetingNotFound
+	}
+	if db.Error != nil {
+		return meeting.RoleGuest, db.Error
+	}
+	return ToRole(m, userId, h.RegExists(meetId, userId)), nil
+}
+
+// GetMemberMeetingIds lists the meetings the user authored, organizes or is registered for
+func (h *MeetingGormRepo) GetMemberMeetingIds(userId int) ([]int, error) {
+	var ids []int
+	db := h.db.Model(&Meeting{}).
+		Where("author_id = ? OR id IN (?) OR id IN (?)", userId,
+			h.db.Model(&Registration{}).Select("meeting_id").Where("user_id = ?", userId),
+			h.db.Model(&Organizer{}).Select("meeting_id").Where("user_id = ?", userId)).
+		Order("id ASC").
+		Pluck("id", &ids)
+	return ids, db.Error
+}
+
+func (h *MeetingGormRepo) AddOrganizer(meetId int, userId int) error {
+	o := Organizer{
+		MeetingId: meetId,
+		UserId:    userId,
+	}
+	return h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&o).Error
+}
+
+func (h *MeetingGormRepo) RemoveOrganizer(meetId int, userId int) error {
+	db := h.db.
+		Where("meeting_id = ?", meetId).
+		Where("user_id = ?", userId).
+		Delete(Organizer{})
 	return db.Error
 }
 
-func (h *MeetingGormRepo) FilterQuery(params meeting.FilterParams) *gorm.DB {
-	return h.db.
+func DiscoveryQuery(db *gorm.DB, params meeting.FilterParams) *gorm.DB {
+	if params.WithCancelled {
+		return db
+	}
+	return db.Where("cancelled = ?", false)
+}
+
+func DateQuery(db *gorm.DB, params meeting.FilterParams) *gorm.DB {
+	return db.
 		Where("Start_Date >= ?::date ", params.StartDate.Format("2006-01-02")).
-		Where("End_Date <= ?::date", params.EndDate.Format("2006-01-02")).
+		Where("End_Date <= ?::date", params.EndDate.Format("2006-01-02"))
+}
+
+func TextSearchQuery(db *gorm.DB, searchQuery string) *gorm.DB {
+	nonWord := regexp.MustCompile(`([!&$()*+.:<=>?[\\\]^{|}-])`)
+	searchQuery = nonWord.ReplaceAllString(searchQuery, "\\$1")
+	space := regexp.MustCompile(`\s+`)
+	searchQuery = space.ReplaceAllString(searchQuery, ":* & ") + ":*"
+	return db.Where(`
+(setweight(to_tsvector('russian', title), 'A') || setweight(to_tsvector('english', title), 'A') ||
+setweight(to_tsvector('russian', text), 'B') || setweight(to_tsvector('english', text), 'B') || 
+setweight(to_tsvector('russian', city), 'C') || setweight(to_tsvector('english', city), 'C') ||
+setweight(to_tsvector('russian', address), 'D') || setweight(to_tsvector('english', address), 'D')
+) @@ 
+(to_tsquery('russian', ?) || to_tsquery('english', ?))`, searchQuery, searchQuery)
+}
+
+// MeetingQuery narrows discovery conditions with any combination of facet filters
+func (h *MeetingGormRepo) MeetingQuery(db *gorm.DB, params meeting.QueryParams) *gorm.DB {
+	if len(params.Tags) > 0 {
+		tagged := h.db.Table("meeting_tags").
+			Select("meeting_tags.meeting_id").
+			Joins("JOIN tags ON tags.id = meeting_tags.tag_id").
+			Where("tags.name IN ?", params.Tags)
+		if params.AllTags {
+			tagged = tagged.
+				Group("meeting_tags.meeting_id").
+				Having("COUNT(DISTINCT tags.name) = ?", len(params.Tags))
+		}
+		db = db.Where("id IN (?)", tagged)
+	}
+	if params.City != "" {
+		db = db.Where("lower(city) = lower(?)", params.City)
+	}
+	if params.FreeSeats {
+		db = db.Where("seats_left > 0")
+	}
+	if params.AuthorId > 0 {
+		db = db.Where("author_id = ?", params.AuthorId)
+	}
+	if params.Text != "" {
+		db = TextSearchQuery(db, params.Text)
+	}
+	return db
+}
+
+func (h *MeetingGormRepo) FilterQuery(params meeting.FilterParams) *gorm.DB {
+	return DateQuery(h.db, params).
 		Preload("Tags").
 		Preload("Regs").
 		Limit(params.CountLimit)
 }
 
 func (h *MeetingGormRepo) CreateMeeting(data models.Meeting) (int, error) {
+	return createMeeting(h.db, data, 0)
+}
+
+func createMeeting(db *gorm.DB, data models.Meeting, seriesId int) (int, error) {
 	m, err := ToDbObject(*data.Card)
 	if err != nil {
 		return 0, err
 	}
-	db := h.db.Create(&m)
-	err = db.Error
+	m.SeriesId = seriesId
+	err = db.Create(&m).Error
 	if err != nil {
 		return 0, err
 	}
@@ -307,8 +861,73 @@ func (h *MeetingGormRepo) CreateMeeting(data models.Meeting) (int, error) {
 		MeetingId: m.Id,
 		UserId:    m.AuthorId,
 	}
-	db = h.db.Create(&l)
-	return m.Id, db.Error
+	err = db.Create(&l).Error
+	return m.Id, err
+}
+
+func (h *MeetingGormRepo) CreateSeries(rule string, meetings []models.Meeting) ([]int, error) {
+	if len(meetings) == 0 {
+		return nil, errors.New("empty meeting series")
+	}
+	ids := make([]int, len(meetings))
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		s := Series{
+			AuthorId: meetings[0].Card.AuthorId,
+			Rule:     rule,
+		}
+		if err := tx.Create(&s).Error; err != nil {
+			return err
+		}
+		for i, data := range meetings {
+			var err error
+			ids[i], err = createMeeting(tx, data, s.Id)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (h *MeetingGormRepo) GetSeriesFollowing(meetId int) ([]models.MeetingCard, error) {
+	var m Meeting
+	db := h.db.Where("id = ?", meetId).Preload("Tags").First(&m)
+	if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return nil, meeting.ErrMeetingNotFound
+	}
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	if m.SeriesId == 0 {
+		return []models.MeetingCard{ToMeetingCard(m)}, nil
+	}
+	var meetings []Meeting
+	db = h.db.
+		Where("series_id = ?", m.SeriesId).
+		Where("start_date >= ?", m.StartDate).
+		Preload("Tags").
+		Order("start_date ASC").Order("id ASC").
+		Find(&meetings)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	cards := make([]models.MeetingCard, len(meetings))
+	for i, obj := range meetings {
+		cards[i] = ToMeetingCard(obj)
+	}
+	return cards, nil
+}
+
+func (h *MeetingGormRepo) CountCoverRefs(cover string) (int, error) {
+	var count int64
+	db := h.db.Model(&Meeting{}).
+		Where("img_src = ?", cover).
+		Count(&count)
+	return int(count), db.Error
 }
 
 func (h *MeetingGormRepo) GetMeeting(meetingId, userId int, authorized bool) (models.MeetingDetails, error) {
@@ -320,6 +939,7 @@ func (h *MeetingGormRepo) GetMeeting(meetingId, userId int, authorized bool) (mo
 		Where("id = ?", meetingId).
 		Preload("Tags").
 		Preload("Regs").
+		Preload("Organizers").
 		First(&m)
 	err := db.Error
 	if err != nil {
@@ -328,24 +948,117 @@ func (h *MeetingGormRepo) GetMeeting(meetingId, userId int, authorized bool) (mo
 	return h.ToMeetingDetails(m, userId)
 }
 
-func (h *MeetingGormRepo) UpdateMeeting(update models.MeetingCard) error {
+func (h *MeetingGormRepo) UpdateMeeting(update models.MeetingCard) ([]int, error) {
 	obj, err := ToDbObject(update)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	obj.Id = update.Label.Id
-	db := h.db.Omit(clause.Associations).Save(&obj)
-	err = db.Error
-	if err == nil {
-		err = h.db.Model(&obj).Association("Tags").Replace(obj.Tags)
+	var promoted []int
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Counters are owned by SetReg and SetLike, only the capacity delta is applied here
+		db := tx.Model(&Meeting{}).
+			Where("id = ?", obj.Id).
+			Where("seats - seats_left <= ?", obj.Seats).
+			Update("seats_left", gorm.Expr("seats_left + ? - seats", obj.Seats))
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&Meeting{}).Where("id = ?", obj.Id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return meeting.ErrMeetingNotFound
+			}
+			return meeting.ErrSeatsOccupied
+		}
+		// Cancellation is owned by CancelMeeting, an edit of a stale card must not undo it
+		db = tx.Omit(clause.Associations, "SeatsLeft", "LikesCount", "Cancelled", "CancelReason").Save(&obj)
+		if db.Error != nil {
+			return db.Error
+		}
+		err := tx.Model(&obj).Association("Tags").Replace(obj.Tags)
+		if err != nil {
+			return err
+		}
+		promoted, err = PromoteWaitlist(tx, obj.Id)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return err
+	return promoted, nil
+}
+
+func (h *MeetingGormRepo) CancelMeeting(meetId int, reason string) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&Meeting{}).
+			Where("id = ?", meetId).
+			Updates(map[string]interface{}{"cancelled": true, "cancel_reason": reason})
+		if db.Error == nil && db.RowsAffected == 0 {
+			return meeting.ErrMeetingNotFound
+		}
+		if db.Error != nil {
+			return db.Error
+		}
+		if err := tx.Where("meeting_id = ?", meetId).Delete(WaitlistEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("meeting_id = ?", meetId).Delete(RegRequest{}).Error
+	})
+}
+
+func (h *MeetingGormRepo) DeleteMeeting(meetId int) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("meeting_id = ?", meetId).Delete(Registration{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("meeting_id = ?", meetId).Delete(Like{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("meeting_id = ?", meetId).Delete(Organizer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("meeting_id = ?", meetId).Delete(WaitlistEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("meeting_id = ?", meetId).Delete(RegRequest{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM meeting_tags WHERE meeting_id = ?", meetId).Error; err != nil {
+			return err
+		}
+		err := tx.Exec("DELETE FROM message_reactions WHERE message_id IN "+
+			"(SELECT id FROM messages WHERE meeting_id = ?)", meetId).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM message_reads WHERE meeting_id = ?", meetId).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM messages WHERE meeting_id = ?", meetId).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM notifications WHERE meet_id = ?", meetId).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM reminder_deliveries WHERE meeting_id = ?", meetId).Error; err != nil {
+			return err
+		}
+		db := tx.Where("id = ?", meetId).Delete(Meeting{})
+		if db.Error == nil && db.RowsAffected == 0 {
+			return meeting.ErrMeetingNotFound
+		}
+		return db.Error
+	})
 }
 
 func (h *MeetingGormRepo) GetNextMeetings(params meeting.FilterParams) ([]models.Meeting, error) {
 	var meetings []Meeting
 	dtStr := params.PrevStart.Format("2006-01-02T15:04:05.000Z0700")
-	db := h.FilterQuery(params).
+	db := DiscoveryQuery(h.FilterQuery(params), params).
 		Where("start_date > ?::timestamptz OR (start_date = ?::timestamptz AND Id > ?)",
 			dtStr, dtStr, params.PrevId).
 		Order("Start_Date ASC").Order("Id ASC").Find(&meetings)
@@ -358,7 +1071,7 @@ func (h *MeetingGormRepo) GetNextMeetings(params meeting.FilterParams) ([]models
 
 func (h *MeetingGormRepo) GetTopMeetings(params meeting.FilterParams) ([]models.Meeting, error) {
 	var meetings []Meeting
-	db := h.FilterQuery(params).
+	db := DiscoveryQuery(h.FilterQuery(params), params).
 		Where("Likes_Count < ? OR (Likes_Count = ? AND Id > ?)", params.PrevLikes, params.PrevLikes, params.PrevId).
 		Order("Likes_Count DESC").Order("Id ASC").Find(&meetings)
 	err := db.Error
@@ -369,27 +1082,30 @@ func (h *MeetingGormRepo) GetTopMeetings(params meeting.FilterParams) ([]models.
 }
 
 func (h *MeetingGormRepo) FilterSubsLiked(params meeting.FilterParams) ([]models.Meeting, error) {
+	return h.filterSubs(params, "likes")
+}
+
+// filterSubs lists meetings any of the user's subscriptions marked in table, ordered by id
+func (h *MeetingGormRepo) filterSubs(params meeting.FilterParams, table string) ([]models.Meeting, error) {
 	subs, err := h.profRepo.GetUserSubscriptionIds(profile.FilterParams{ReqAuthorId: params.UserId})
 	if err != nil {
 		return nil, err
 	}
-	meetMap := make(map[int]*models.Meeting)
-	for _, sub := range subs {
-		params.UserId = sub
-		subLiked, err := h.FilterLiked(params)
-		if err != nil {
-			return nil, err
-		}
-		params.CountLimit -= len(subLiked)
-		for _, meet := range subLiked {
-			meetMap[meet.Card.Label.Id] = &meet
-		}
+	if len(subs) == 0 {
+		return []models.Meeting{}, nil
 	}
-	result := []models.Meeting{}
-	for _, meetPtr := range meetMap {
-		result = append(result, *meetPtr)
+	marked := h.db.Table(table).
+		Select("meeting_id").
+		Where("user_id IN ?", subs)
+	var meetings []Meeting
+	db := DiscoveryQuery(h.FilterQuery(params), params).
+		Where("id IN (?)", marked).
+		Where("id > ?", params.PrevId).
+		Order("Id ASC").Find(&meetings)
+	if db.Error != nil {
+		return nil, db.Error
 	}
-	return result, nil
+	return h.ToMeetingList(meetings, params.UserId)
 }
 
 func (h *MeetingGormRepo) FilterLiked(params meeting.FilterParams) ([]models.Meeting, error) {
@@ -456,59 +1172,40 @@ func (h *MeetingGormRepo) FilterLikedTags(userId int) (map[int]bool, error) {
 }
 
 func (h *MeetingGormRepo) FilterSubsRegistered(params meeting.FilterParams) ([]models.Meeting, error) {
-	subs, err := h.profRepo.GetUserSubscriptionIds(profile.FilterParams{ReqAuthorId: params.UserId})
-	if err != nil {
-		return nil, err
-	}
-	meetMap := make(map[int]*models.Meeting)
-	for _, sub := range subs {
-		params.UserId = sub
-		subLiked, err := h.FilterRegistered(params)
-		if err != nil {
-			return nil, err
-		}
-		params.CountLimit -= len(subLiked)
-		for _, meet := range subLiked {
-			meetMap[meet.Card.Label.Id] = &meet
-		}
-	}
-	result := []models.Meeting{}
-	for _, meetPtr := range meetMap {
-		result = append(result, *meetPtr)
-	}
-	return result, nil
+	return h.filterSubs(params, "registrations")
 }
 
+// FilterRegistered joins the meetings so that the date filter applies before the limit
 func (h *MeetingGormRepo) FilterRegistered(params meeting.FilterParams) ([]models.Meeting, error) {
-	var regs []Registration
-	db := h.db.
-		Where("User_Id = ?", params.UserId).
-		Where("Meeting_Id > ?", params.PrevId).
-		Order("Meeting_Id ASC").
-		Limit(params.CountLimit).Find(&regs)
-
+	var meetings []Meeting
+	db := h.FilterQuery(params).
+		Joins("JOIN registrations ON registrations.meeting_id = meetings.id").
+		Where("registrations.user_id = ?", params.UserId).
+		Where("meetings.id > ?", params.PrevId).
+		Order("meetings.id ASC").
+		Find(&meetings)
 	if db.Error != nil {
 		return nil, db.Error
 	}
-	result := []models.Meeting{}
-	total := 0
-	for _, el := range regs {
-		if total >= params.CountLimit {
-			break
-		}
-		var m Meeting
-		db = h.FilterQuery(params).
-			Where("id = ?", el.MeetingId).
-			First(&m)
-		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
-			continue
-		}
-		if db.Error != nil {
-			return nil, db.Error
-		}
-		result = append(result, h.ToMeeting(m, params.UserId))
+	return h.ToMeetingList(meetings, params.UserId)
+}
+
+// GetFeedMeetings returns the registered meetings starting after since in calendar order,
+// the dates are filtered before the limit so that old registrations do not crowd out new ones
+func (h *MeetingGormRepo) GetFeedMeetings(userId int, since time.Time, limit int) ([]models.Meeting, error) {
+	var meetings []Meeting
+	db := h.db.
+		Joins("JOIN registrations ON registrations.meeting_id = meetings.id").
+		Where("registrations.user_id = ?", userId).
+		Where("meetings.start_date >= ?", since).
+		Preload("Tags").
+		Preload("Regs").
+		Order("meetings.start_date ASC").Order("meetings.id ASC").
+		Limit(limit).Find(&meetings)
+	if db.Error != nil {
+		return nil, db.Error
 	}
-	return result, nil
+	return h.ToMeetingList(meetings, userId)
 }
 
 func (h *MeetingGormRepo) ExtractMeetingsFromRows(params meeting.FilterParams, rows *sql.Rows) ([]Meeting, error) {
@@ -528,6 +1225,9 @@ func (h *MeetingGormRepo) ExtractMeetingsFromRows(params meeting.FilterParams, r
 		if db.Error == nil && (meetBuf.EndDate.Before(params.StartDate) || meetBuf.EndDate.After(params.EndDate)) {
 			continue
 		}
+		if db.Error == nil && meetBuf.Cancelled && !params.WithCancelled {
+			continue
+		}
 		meetings = append(meetings, meetBuf)
 		total += 1
 		err = db.Error
@@ -619,17 +1319,9 @@ func (h *MeetingGormRepo) FilterSimilar(params meeting.FilterParams, meetingId i
 func (h *MeetingGormRepo) SearchMeetings(params meeting.FilterParams,
 	searchQuery string, limit int) ([]models.Meeting, error) {
 	var res []Meeting
-	nonWord := regexp.MustCompile(`([!&$()*+.:<=>?[\\\]^{|}-])`)
-	searchQuery = nonWord.ReplaceAllString(searchQuery, "\\$1")
-	space := regexp.MustCompile(`\s+`)
-	searchQuery = space.ReplaceAllString(searchQuery, ":* & ") + ":*"
-	db := h.db.Table("meetings").Where(`
-(setweight(to_tsvector('russian', title), 'A') || setweight(to_tsvector('english', title), 'A') ||
-setweight(to_tsvector('russian', text), 'B') || setweight(to_tsvector('english', text), 'B') || 
-setweight(to_tsvector('russian', city), 'C') || setweight(to_tsvector('english', city), 'C') ||
-setweight(to_tsvector('russian', address), 'D') || setweight(to_tsvector('english', address), 'D')
-) @@ 
-(to_tsquery('russian', ?) || to_tsquery('english', ?))`, searchQuery, searchQuery)
+	db := TextSearchQuery(DiscoveryQuery(h.db.Table("meetings"), params), searchQuery).
+		Where("id > ?", params.PrevId).
+		Order("Id ASC")
 	if limit > 0 {
 		db = db.Limit(limit)
 	}
@@ -641,3 +1333,166 @@ setweight(to_tsvector('russian', address), 'D') || setweight(to_tsvector('englis
 
 	return h.ToMeetingList(res, params.UserId)
 }
+
+func (h *MeetingGormRepo) GetFeedToken(userId int) (string, error) {
+	var f FeedToken
+	db := h.db.Where("user_id = ?", userId).First(&f)
+	if db.Error != nil {
+		return "", db.Error
+	}
+	return f.Token, nil
+}
+
+func (h *MeetingGormRepo) SetFeedToken(userId int, token string) error {
+	f := FeedToken{
+		UserId: userId,
+		Token:  token,
+	}
+	db := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token"}),
+	}).Create(&f)
+	return db.Error
+}
+
+func (h *MeetingGormRepo) RemoveFeedToken(userId int) error {
+	return h.db.Where("user_id = ?", userId).Delete(FeedToken{}).Error
+}
+
+func (h *MeetingGormRepo) GetFeedOwner(token string) (int, error) {
+	var f FeedToken
+	db := h.db.Where("token = ?", token).First(&f)
+	if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return 0, meeting.ErrInvalidFeedToken
+	}
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	return f.UserId, nil
+}
+
+// Great-circle distance in kilometers between the meeting and the ? point (lat, lon, lat)
+const distanceExpr = "6371 * acos(least(1.0, cos(radians(?)) * cos(radians(lat)) * " +
+	"cos(radians(lon) - radians(?)) + sin(radians(?)) * sin(radians(lat))))"
+
+func (h *MeetingGormRepo) FilterNearby(params meeting.FilterParams) ([]models.Meeting, error) {
+	// Bounding box lets the planner use the geo index before computing exact distances
+	latDelta := params.RadiusKm / 111.0
+	sub := h.db.Model(&Meeting{}).
+		Select("*, "+distanceExpr+" AS distance", params.Lat, params.Lon, params.Lat).
+		Where("lat BETWEEN ? AND ?", params.Lat-latDelta, params.Lat+latDelta)
+	// Near the poles and the antimeridian the longitude box is skipped
+	cos := math.Cos(params.Lat * math.Pi / 180)
+	if lonDelta := params.RadiusKm / (111.0 * cos); cos > 0.01 &&
+		params.Lon-lonDelta >= -180 && params.Lon+lonDelta <= 180 {
+		sub = sub.Where("lon BETWEEN ? AND ?", params.Lon-lonDelta, params.Lon+lonDelta)
+	} else {
+		sub = sub.Where("lon IS NOT NULL")
+	}
+	var found []struct {
+		Id       int
+		Distance float64
+	}
+	db := DateQuery(DiscoveryQuery(h.db.Table("(?) AS nearby", sub), params), params).
+		Select("id, distance").
+		Where("distance <= ?", params.RadiusKm).
+		Where("distance > ? OR (distance = ? AND id > ?)",
+			params.PrevDistance, params.PrevDistance, params.PrevId).
+		Order("distance ASC").Order("id ASC").
+		Limit(params.CountLimit).
+		Scan(&found)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	if len(found) == 0 {
+		return []models.Meeting{}, nil
+	}
+	ids := make([]int, len(found))
+	for i, f := range found {
+		ids[i] = f.Id
+	}
+	var meetings []Meeting
+	db = h.db.
+		Where("id IN ?", ids).
+		Preload("Tags").
+		Preload("Regs").
+		Find(&meetings)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	byId := make(map[int]Meeting, len(meetings))
+	for _, m := range meetings {
+		byId[m.Id] = m
+	}
+	result := make([]models.Meeting, 0, len(found))
+	for _, f := range found {
+		m, ok := byId[f.Id]
+		if !ok {
+			continue
+		}
+		item := h.ToMeeting(m, params.UserId)
+		distance := f.Distance
+		item.Distance = &distance
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// MaxFacetValues limits every facet to its most frequent values
+const MaxFacetValues = 20
+
+func (h *MeetingGormRepo) QueryMeetings(params meeting.QueryParams) ([]models.Meeting, error) {
+	var meetings []Meeting
+	db := h.MeetingQuery(DiscoveryQuery(h.FilterQuery(params.FilterParams), params.FilterParams), params)
+	switch params.SortBy {
+	case meeting.SortByLikes:
+		db = db.
+			Where("Likes_Count < ? OR (Likes_Count = ? AND Id > ?)",
+				params.PrevLikes, params.PrevLikes, params.PrevId).
+			Order("Likes_Count DESC").Order("Id ASC")
+	default:
+		dtStr := params.PrevStart.Format("2006-01-02T15:04:05.000Z0700")
+		db = db.
+			Where("start_date > ?::timestamptz OR (start_date = ?::timestamptz AND Id > ?)",
+				dtStr, dtStr, params.PrevId).
+			Order("Start_Date ASC").Order("Id ASC")
+	}
+	db = db.Find(&meetings)
+	if db.Error != nil {
+		return []models.Meeting{}, db.Error
+	}
+	return h.ToMeetingList(meetings, params.UserId)
+}
+
+// QueryFacets counts tags and cities over all meetings matching params, ignoring pagination
+func (h *MeetingGormRepo) QueryFacets(params meeting.QueryParams) (models.MeetingFacets, error) {
+	facets := models.MeetingFacets{
+		Tags:   []models.FacetCount{},
+		Cities: []models.FacetCount{},
+	}
+	matched := func() *gorm.DB {
+		return h.MeetingQuery(DateQuery(DiscoveryQuery(h.db.Model(&Meeting{}), params.FilterParams),
+			params.FilterParams), params)
+	}
+	db := h.db.Table("meeting_tags").
+		Select("tags.name AS value, COUNT(*) AS count").
+		Joins("JOIN tags ON tags.id = meeting_tags.tag_id").
+		Where("meeting_tags.meeting_id IN (?)", matched().Select("id")).
+		Group("tags.name").
+		Order("count DESC").Order("value ASC").
+		Limit(MaxFacetValues).
+		Scan(&facets.Tags)
+	if db.Error != nil {
+		return models.MeetingFacets{}, db.Error
+	}
+	db = matched().
+		Select("city AS value, COUNT(*) AS count").
+		Group("city").
+		Order("count DESC").Order("value ASC").
+		Limit(MaxFacetValues).
+		Scan(&facets.Cities)
+	if db.Error != nil {
+		return models.MeetingFacets{}, db.Error
+	}
+	return facets, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"konami_backend/internal/pkg/meeting"
+	tagRepo "konami_backend/internal/pkg/tag/repository"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Runs against a real postgres instance, e.g.
+// TEST_DB_CONN="host=localhost user=postgres dbname=konami_test sslmode=disable" go test ./...
+func openTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DB_CONN")
+	if dsn == "" {
+		t.Skip("TEST_DB_CONN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	err = db.AutoMigrate(&tagRepo.Tag{}, &Registration{}, &Like{}, &Organizer{}, &Meeting{})
+	require.NoError(t, err)
+	return db
+}
+
+func createTestMeeting(t *testing.T, db *gorm.DB, seats int) Meeting {
+	m := Meeting{
+		Title:     "concurrency test",
+		StartDate: time.Now(),
+		EndDate:   time.Now().Add(time.Hour),
+		Seats:     seats,
+		SeatsLeft: seats,
+	}
+	require.NoError(t, db.Omit(clause.Associations).Create(&m).Error)
+	t.Cleanup(func() {
+		db.Where("meeting_id = ?", m.Id).Delete(Registration{})
+		db.Where("meeting_id = ?", m.Id).Delete(Like{})
+		db.Where("id = ?", m.Id).Delete(Meeting{})
+	})
+	return m
+}
+
+func TestSetRegConcurrent(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewMeetingGormRepoLite(db)
+	const seats = 5
+	const users = 40
+	m := createTestMeeting(t, db, seats)
+
+	var booked, full, dup int32
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		// Every user signs up twice at the same time
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func(userId int) {
+				defer wg.Done()
+				err := repo.SetReg(m.Id, userId)
+				switch {
+				case err == nil:
+					atomic.AddInt32(&booked, 1)
+				case errors.Is(err, meeting.ErrNoSeatsLeft):
+					atomic.AddInt32(&full, 1)
+				case errors.Is(err, meeting.ErrAlreadyRegistered):
+					atomic.AddInt32(&dup, 1)
+				default:
+					t.Error(err)
+				}
+			}(i + 1)
+		}
+	}
+	wg.Wait()
+
+	require.EqualValues(t, seats, booked)
+	require.EqualValues(t, 2*users, booked+full+dup)
+	var regs int64
+	require.NoError(t, db.Model(&Registration{}).Where("meeting_id = ?", m.Id).Count(&regs).Error)
+	require.EqualValues(t, seats, regs)
+	var res Meeting
+	require.NoError(t, db.Where("id = ?", m.Id).First(&res).Error)
+	require.Equal(t, 0, res.SeatsLeft)
+}
+
+func TestSetLikeConcurrent(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewMeetingGormRepoLite(db)
+	const users = 40
+	m := createTestMeeting(t, db, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func(userId int) {
+				defer wg.Done()
+				assert.NoError(t, repo.SetLike(m.Id, userId))
+			}(i + 1)
+		}
+	}
+	wg.Wait()
+	for i := 0; i < users/2; i++ {
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func(userId int) {
+				defer wg.Done()
+				assert.NoError(t, repo.RemoveLike(m.Id, userId))
+			}(i + 1)
+		}
+	}
+	wg.Wait()
+
+	var res Meeting
+	require.NoError(t, db.Where("id = ?", m.Id).First(&res).Error)
+	require.Equal(t, users/2, res.LikesCount)
+	var likes int64
+	require.NoError(t, db.Model(&Like{}).Where("meeting_id = ?", m.Id).Count(&likes).Error)
+	require.EqualValues(t, users/2, likes)
+}
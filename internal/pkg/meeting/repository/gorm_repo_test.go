@@ -4,11 +4,14 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"konami_backend/internal/pkg/meeting"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/profile"
 	"testing"
 	"time"
 )
@@ -46,6 +49,9 @@ func (s *Suite) TestGetSessions() {
 	s.mock.ExpectQuery("SELECT").
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
+	s.mock.ExpectQuery("SELECT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
 	s.mock.ExpectQuery("SELECT").
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
@@ -129,14 +135,16 @@ func (s *Suite) TestFilterTopMeet() {
 }
 
 func (s *Suite) TestFilterReg() {
-	s.mock.ExpectQuery("SELECT").
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(`JOIN registrations ON registrations.meeting_id = meetings.id ` +
+		`WHERE Start_Date >= \$1::date AND End_Date <= \$2::date ` +
+		`AND registrations.user_id = \$3 AND meetings.id > \$4 ORDER BY meetings.id ASC LIMIT 10`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
 	_, err := s.repository.FilterRegistered(meeting.FilterParams{
 		StartDate:  time.Time{},
 		EndDate:    time.Time{},
 		PrevId:     0,
-		CountLimit: 0,
+		CountLimit: 10,
 		UserId:     0,
 	})
 	require.NoError(s.T(), err)
@@ -205,6 +213,391 @@ func (s *Suite) TestGetNextMeetings() {
 	require.NoError(s.T(), err)
 }
 
+func (s *Suite) TestGetRole() {
+	s.mock.ExpectQuery("SELECT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "author_id"}).AddRow(1, 2))
+
+	s.mock.ExpectQuery("SELECT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id"}).AddRow(1, 1, 3))
+
+	s.mock.ExpectQuery("SELECT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	role, err := s.repository.GetRole(1, 3)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), meeting.RoleOrganizer, role)
+}
+
+func (s *Suite) TestGetRoleNotFound() {
+	s.mock.ExpectQuery("SELECT").
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	_, err := s.repository.GetRole(1, 3)
+	require.Equal(s.T(), meeting.ErrMeetingNotFound, err)
+}
+
+func (s *Suite) TestAddOrganizer() {
+	s.mock.ExpectQuery("INSERT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	err := s.repository.AddOrganizer(1, 3)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestRemoveOrganizerErr() {
+	s.mock.ExpectExec("DELETE").
+		WillReturnError(s.bdError)
+
+	err := s.repository.RemoveOrganizer(1, 3)
+	require.Equal(s.T(), s.bdError, err)
+}
+
+func (s *Suite) TestGetFeedMeetings() {
+	since := time.Date(2020, 11, 5, 0, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery(`SELECT "meetings"."id".* JOIN registrations ON registrations.meeting_id = meetings.id `+
+		`WHERE registrations.user_id = \$1 AND meetings.start_date >= \$2 `+
+		`ORDER BY meetings.start_date ASC,meetings.id ASC LIMIT 500`).
+		WithArgs(3, since).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	res, err := s.repository.GetFeedMeetings(3, since, 500)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), res)
+}
+
+func (s *Suite) TestCancelMeeting() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.repository.CancelMeeting(1, "reason")
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestCancelMeetingNotFound() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	err := s.repository.CancelMeeting(1, "reason")
+	require.Equal(s.T(), meeting.ErrMeetingNotFound, err)
+}
+
+func (s *Suite) TestUpdateMeetingNotFound() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT count").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectRollback()
+
+	_, err := s.repository.UpdateMeeting(testUpdateCard())
+	require.Equal(s.T(), meeting.ErrMeetingNotFound, err)
+}
+
+func (s *Suite) TestUpdateMeetingSeatsOccupied() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT count").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectRollback()
+
+	_, err := s.repository.UpdateMeeting(testUpdateCard())
+	require.Equal(s.T(), meeting.ErrSeatsOccupied, err)
+}
+
+func testUpdateCard() models.MeetingCard {
+	return models.MeetingCard{
+		Label:     &models.MeetingLabel{Id: 1},
+		StartDate: "2020-12-05T10:00:00.000Z",
+		EndDate:   "2020-12-05T12:00:00.000Z",
+		Seats:     10,
+	}
+}
+
+func (s *Suite) TestDeleteMeeting() {
+	s.mock.ExpectBegin()
+	for _, table := range []string{"registrations", "likes", "organizers", "waitlist", "reg_requests",
+		"meeting_tags", "message_reactions", "message_reads", "messages", "notifications", "reminder_deliveries", "meetings"} {
+		s.mock.ExpectExec(`DELETE FROM "?` + table + `"? WHERE`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	s.mock.ExpectCommit()
+
+	err := s.repository.DeleteMeeting(1)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestDeleteMeetingErr() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE").
+		WillReturnError(s.bdError)
+	s.mock.ExpectRollback()
+
+	err := s.repository.DeleteMeeting(1)
+	require.Equal(s.T(), s.bdError, err)
+}
+
+func (s *Suite) TestSetReg() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 3))
+	s.mock.ExpectQuery("INSERT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.SetReg(1, 2)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestSetRegNoSeats() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 0))
+	s.mock.ExpectQuery("INSERT (.+) ON CONFLICT DO NOTHING").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectExec("UPDATE \"meetings\" SET (.+) WHERE id = (.+) AND seats_left > 0").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	err := s.repository.SetReg(1, 2)
+	require.Equal(s.T(), meeting.ErrNoSeatsLeft, err)
+}
+
+func (s *Suite) TestSetRegDuplicateNoSeats() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 0))
+	s.mock.ExpectQuery("INSERT (.+) ON CONFLICT DO NOTHING").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectRollback()
+
+	err := s.repository.SetReg(1, 2)
+	require.Equal(s.T(), meeting.ErrAlreadyRegistered, err)
+}
+
+func (s *Suite) TestSetRegDuplicate() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 3))
+	s.mock.ExpectQuery("INSERT (.+) ON CONFLICT DO NOTHING").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectRollback()
+
+	err := s.repository.SetReg(1, 2)
+	require.Equal(s.T(), meeting.ErrAlreadyRegistered, err)
+}
+
+func (s *Suite) TestRemoveReg() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 1))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"waitlist\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id", "position"}))
+	s.mock.ExpectCommit()
+
+	promoted, err := s.repository.RemoveReg(1, 2)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), promoted)
+}
+
+func (s *Suite) TestRemoveRegPromotes() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 1))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"waitlist\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id", "position"}).
+			AddRow(7, 1, 5, 1))
+	s.mock.ExpectQuery("INSERT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	promoted, err := s.repository.RemoveReg(1, 2)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int{5}, promoted)
+}
+
+func (s *Suite) TestRemoveRegPromotesPastRegistered() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 1))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"waitlist\" (.+) LIMIT 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id", "position"}).
+			AddRow(7, 1, 5, 1))
+	// the first one in line is already registered and does not take the seat
+	s.mock.ExpectQuery("INSERT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectExec("DELETE FROM \"waitlist\"").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"waitlist\" (.+) LIMIT 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id", "position"}).
+			AddRow(8, 1, 6, 2))
+	s.mock.ExpectQuery("INSERT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectExec("DELETE FROM \"waitlist\"").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE \"meetings\" SET \"seats_left\"=seats_left - \\$1").
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	promoted, err := s.repository.RemoveReg(1, 2)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int{6}, promoted)
+}
+
+func (s *Suite) TestRemoveRegWaitlistApproval() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left", "approval"}).AddRow(1, 1, true))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"waitlist\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id", "position"}).
+			AddRow(7, 1, 5, 1))
+	s.mock.ExpectQuery("SELECT count(.+) FROM \"registrations\"").
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectQuery("INSERT INTO \"reg_requests\" (.+) ON CONFLICT DO NOTHING").
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectExec("DELETE FROM \"waitlist\"").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// no seat is taken and nobody is reported as registered
+	s.mock.ExpectCommit()
+
+	promoted, err := s.repository.RemoveReg(1, 2)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), promoted)
+}
+
+func (s *Suite) TestJoinWaitlist() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 0))
+	s.mock.ExpectQuery("SELECT count").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"waitlist\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id", "position"}).
+			AddRow(7, 1, 5, 2))
+	s.mock.ExpectQuery("INSERT").
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	s.mock.ExpectCommit()
+
+	err := s.repository.JoinWaitlist(1, 2)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestJoinWaitlistSeatsAvailable() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 2))
+	s.mock.ExpectRollback()
+
+	err := s.repository.JoinWaitlist(1, 2)
+	require.Equal(s.T(), meeting.ErrSeatsAvailable, err)
+}
+
+func (s *Suite) TestMoveInWaitlist() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"waitlist\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id", "position"}).
+			AddRow(7, 1, 5, 1).AddRow(8, 1, 6, 2).AddRow(9, 1, 7, 3))
+	s.mock.ExpectExec("UPDATE").
+		WithArgs(1, 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WithArgs(2, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WithArgs(3, 8).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.MoveInWaitlist(1, 7, 1)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestMoveInWaitlistNotWaiting() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"waitlist\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "meeting_id", "user_id", "position"}))
+	s.mock.ExpectRollback()
+
+	err := s.repository.MoveInWaitlist(1, 7, 1)
+	require.Equal(s.T(), meeting.ErrNotWaiting, err)
+}
+
+func (s *Suite) TestSetLikeTwice() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT (.+) ON CONFLICT DO NOTHING").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+
+	err := s.repository.SetLike(1, 2)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestRemoveLike() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnError(s.bdError)
+	s.mock.ExpectRollback()
+
+	err := s.repository.RemoveLike(1, 2)
+	require.Equal(s.T(), s.bdError, err)
+}
+
 func (s *Suite) AfterTest(_, _ string) {
 	require.NoError(s.T(), s.mock.ExpectationsWereMet())
 }
@@ -212,3 +605,274 @@ func (s *Suite) AfterTest(_, _ string) {
 func TestMeetings(t *testing.T) {
 	suite.Run(t, new(Suite))
 }
+
+func (s *Suite) TestRequestReg() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM \"meetings\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "approval"}).AddRow(1, true))
+	s.mock.ExpectQuery("SELECT count").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectQuery("INSERT (.+) ON CONFLICT DO NOTHING").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.RequestReg(1, 2)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestRequestRegCancelled() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM \"meetings\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "cancelled"}).AddRow(1, true))
+	s.mock.ExpectRollback()
+
+	err := s.repository.RequestReg(1, 2)
+	require.Equal(s.T(), meeting.ErrMeetingCancelled, err)
+}
+
+func (s *Suite) TestApproveReg() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"reg_requests\"").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 3))
+	s.mock.ExpectQuery("INSERT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.ApproveReg(1, 2)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestApproveRegNoSeats() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"reg_requests\"").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seats_left"}).AddRow(1, 0))
+	s.mock.ExpectQuery("INSERT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectExec("UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	err := s.repository.ApproveReg(1, 2)
+	require.Equal(s.T(), meeting.ErrNoSeatsLeft, err)
+}
+
+func (s *Suite) TestRejectRegNotFound() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"reg_requests\"").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.repository.RejectReg(1, 2)
+	require.Equal(s.T(), meeting.ErrNoRegRequest, err)
+}
+
+func (s *Suite) TestCreateSeries() {
+	card := func(start string) models.Meeting {
+		return models.Meeting{Card: &models.MeetingCard{
+			Label:     &models.MeetingLabel{Title: "Go meetup"},
+			AuthorId:  3,
+			StartDate: start,
+			EndDate:   start,
+		}}
+	}
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"meeting_series\"").
+		WithArgs(3, "weekly").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	for i := 1; i <= 2; i++ {
+		s.mock.ExpectQuery("INSERT INTO \"meetings\"").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i))
+		s.mock.ExpectQuery("INSERT INTO \"registrations\"").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i))
+	}
+	s.mock.ExpectCommit()
+
+	ids, err := s.repository.CreateSeries("weekly", []models.Meeting{
+		card("2020-12-01T19:00:00.000Z"),
+		card("2020-12-08T19:00:00.000Z"),
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int{1, 2}, ids)
+}
+
+func (s *Suite) TestGetSeriesFollowing() {
+	start := time.Date(2020, 12, 8, 19, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery("SELECT (.+) FROM \"meetings\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "series_id", "start_date"}).AddRow(8, 2, start))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"meeting_tags\"").
+		WillReturnRows(sqlmock.NewRows([]string{"meeting_id", "tag_id"}))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"tags\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"meetings\" WHERE series_id = (.+) AND start_date >= ").
+		WithArgs(2, start).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "series_id", "start_date"}).
+			AddRow(8, 2, start).AddRow(9, 2, start.AddDate(0, 0, 7)))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"meeting_tags\"").
+		WillReturnRows(sqlmock.NewRows([]string{"meeting_id", "tag_id"}))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"tags\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	cards, err := s.repository.GetSeriesFollowing(8)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), cards, 2)
+	require.Equal(s.T(), 9, cards[1].Label.Id)
+}
+
+func (s *Suite) TestSetFeedToken() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("INSERT INTO \"feed_tokens\" (.+) ON CONFLICT \\(\"user_id\"\\) DO UPDATE SET \"token\"").
+		WithArgs(3, "secret").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.SetFeedToken(3, "secret")
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestGetFeedOwner() {
+	s.mock.ExpectQuery("SELECT (.+) FROM \"feed_tokens\" WHERE token = ").
+		WithArgs("secret").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "token"}).AddRow(3, "secret"))
+
+	userId, err := s.repository.GetFeedOwner("secret")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 3, userId)
+
+	s.mock.ExpectQuery("SELECT (.+) FROM \"feed_tokens\" WHERE token = ").
+		WithArgs("revoked").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "token"}))
+
+	_, err = s.repository.GetFeedOwner("revoked")
+	require.Equal(s.T(), meeting.ErrInvalidFeedToken, err)
+}
+
+func (s *Suite) TestFilterNearby() {
+	params := meeting.FilterParams{
+		StartDate:    time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC),
+		CountLimit:   2,
+		UserId:       -1,
+		Lat:          55.75,
+		Lon:          37.61,
+		RadiusKm:     10,
+		PrevDistance: 0.5,
+		PrevId:       3,
+	}
+	s.mock.ExpectQuery("SELECT id, distance FROM \\(SELECT \\*, 6371 \\* acos(.+) AS distance FROM \"meetings\" " +
+		"WHERE \\(lat BETWEEN (.+)\\) AND \\(lon BETWEEN (.+)\\)\\) AS nearby WHERE cancelled = (.+) " +
+		"AND distance <= (.+) AND \\(distance > (.+) OR \\(distance = (.+) AND id > (.+)\\)\\) " +
+		"ORDER BY distance ASC,id ASC LIMIT 2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "distance"}).AddRow(5, 1.5).AddRow(4, 2.25))
+	s.mock.ExpectQuery("SELECT (.+) FROM \"meetings\" WHERE id IN").
+		WithArgs(5, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(4, "far").AddRow(5, "near"))
+	// Preloads run in no particular order
+	for i := 0; i < 3; i++ {
+		s.mock.ExpectQuery("SELECT").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+
+	meets, err := s.repository.FilterNearby(params)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), meets, 2)
+	require.Equal(s.T(), "near", meets[0].Card.Label.Title)
+	require.Equal(s.T(), 1.5, *meets[0].Distance)
+	require.Equal(s.T(), 2.25, *meets[1].Distance)
+}
+
+func (s *Suite) TestQueryMeetings() {
+	params := meeting.QueryParams{
+		FilterParams: meeting.FilterParams{
+			StartDate:  time.Date(2020, 12, 5, 0, 0, 0, 0, time.UTC),
+			EndDate:    time.Date(2020, 12, 7, 0, 0, 0, 0, time.UTC),
+			CountLimit: 10,
+			UserId:     -1,
+			PrevLikes:  100,
+		},
+		Tags:      []string{"golang", "backend"},
+		AllTags:   true,
+		City:      "Moscow",
+		FreeSeats: true,
+		AuthorId:  3,
+		Text:      "конференция",
+		SortBy:    meeting.SortByLikes,
+	}
+	s.mock.ExpectQuery("SELECT \\* FROM \"meetings\" WHERE Start_Date >= (.+) AND End_Date <= (.+) " +
+		"AND cancelled = (.+) AND id IN \\(SELECT meeting_tags.meeting_id FROM \"meeting_tags\" " +
+		"JOIN tags ON tags.id = meeting_tags.tag_id WHERE tags.name IN \\((.+),(.+)\\) " +
+		"GROUP BY \"meeting_tags\".\"meeting_id\" HAVING COUNT\\(DISTINCT tags.name\\) = (.+)\\) " +
+		"AND lower\\(city\\) = lower\\((.+)\\) AND seats_left > 0 AND \\(author_id = (.+)\\) " +
+		"AND (.+)to_tsquery(.+) AND \\(Likes_Count < (.+) OR \\(Likes_Count = (.+) AND Id > (.+)\\)\\) " +
+		"ORDER BY Likes_Count DESC,Id ASC LIMIT 10").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	meets, err := s.repository.QueryMeetings(params)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), meets)
+}
+
+func (s *Suite) TestQueryFacets() {
+	params := meeting.QueryParams{
+		FilterParams: meeting.FilterParams{
+			StartDate: time.Date(2020, 12, 5, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2020, 12, 7, 0, 0, 0, 0, time.UTC),
+		},
+		Tags: []string{"golang"},
+	}
+	s.mock.ExpectQuery("SELECT tags.name AS value, COUNT\\(\\*\\) AS count FROM \"meeting_tags\" " +
+		"JOIN tags ON tags.id = meeting_tags.tag_id WHERE meeting_tags.meeting_id IN " +
+		"\\(SELECT \"id\" FROM \"meetings\" WHERE cancelled = (.+) AND id IN \\((.+)\\)\\) " +
+		"GROUP BY \"tags\".\"name\" ORDER BY count DESC,value ASC LIMIT 20").
+		WillReturnRows(sqlmock.NewRows([]string{"value", "count"}).
+			AddRow("golang", 5).AddRow("backend", 2))
+	s.mock.ExpectQuery("SELECT city AS value, COUNT\\(\\*\\) AS count FROM \"meetings\" (.+) " +
+		"GROUP BY \"city\" ORDER BY count DESC,value ASC LIMIT 20").
+		WillReturnRows(sqlmock.NewRows([]string{"value", "count"}).AddRow("Moscow", 5))
+
+	facets, err := s.repository.QueryFacets(params)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []models.FacetCount{{Value: "golang", Count: 5}, {Value: "backend", Count: 2}}, facets.Tags)
+	require.Equal(s.T(), []models.FacetCount{{Value: "Moscow", Count: 5}}, facets.Cities)
+}
+
+func (s *Suite) TestFilterSubsLiked() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	profRepo := profile.NewMockRepository(ctrl)
+	repo := NewMeetingGormRepo(s.DB, profRepo)
+
+	params := meeting.FilterParams{UserId: 1, PrevId: 7, CountLimit: 11}
+	profRepo.EXPECT().GetUserSubscriptionIds(profile.FilterParams{ReqAuthorId: 1}).Return([]int{2, 3}, nil)
+	s.mock.ExpectQuery("SELECT \\* FROM \"meetings\" WHERE (.+) AND cancelled = (.+) " +
+		"AND id IN \\(SELECT meeting_id FROM \"likes\" WHERE user_id IN \\((.+),(.+)\\)\\) " +
+		"AND id > (.+) ORDER BY Id ASC LIMIT 11").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	meets, err := repo.FilterSubsLiked(params)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), meets)
+
+	profRepo.EXPECT().GetUserSubscriptionIds(profile.FilterParams{ReqAuthorId: 1}).Return([]int{}, nil)
+	meets, err = repo.FilterSubsRegistered(params)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), meets)
+}
+
+func (s *Suite) TestGetMemberMeetingIds() {
+	s.mock.ExpectQuery("SELECT \"id\" FROM \"meetings\" WHERE author_id = (.+) "+
+		"OR id IN \\(SELECT \"meeting_id\" FROM \"registrations\" WHERE user_id = (.+)\\) "+
+		"OR id IN \\(SELECT \"meeting_id\" FROM \"organizers\" WHERE user_id = (.+)\\) ORDER BY id ASC").
+		WithArgs(4, 4, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(3))
+
+	ids, err := s.repository.GetMemberMeetingIds(4)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int{1, 3}, ids)
+}
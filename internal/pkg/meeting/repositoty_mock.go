@@ -8,6 +8,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	models "konami_backend/internal/pkg/models"
 	reflect "reflect"
+	time "time"
 )
 
 // MockRepository is a mock of Repository interface
@@ -48,6 +49,51 @@ func (mr *MockRepositoryMockRecorder) CreateMeeting(meeting interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMeeting", reflect.TypeOf((*MockRepository)(nil).CreateMeeting), meeting)
 }
 
+// CreateSeries mocks base method
+func (m *MockRepository) CreateSeries(rule string, meetings []models.Meeting) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSeries", rule, meetings)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSeries indicates an expected call of CreateSeries
+func (mr *MockRepositoryMockRecorder) CreateSeries(rule, meetings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSeries", reflect.TypeOf((*MockRepository)(nil).CreateSeries), rule, meetings)
+}
+
+// GetSeriesFollowing mocks base method
+func (m *MockRepository) GetSeriesFollowing(meetId int) ([]models.MeetingCard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSeriesFollowing", meetId)
+	ret0, _ := ret[0].([]models.MeetingCard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSeriesFollowing indicates an expected call of GetSeriesFollowing
+func (mr *MockRepositoryMockRecorder) GetSeriesFollowing(meetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeriesFollowing", reflect.TypeOf((*MockRepository)(nil).GetSeriesFollowing), meetId)
+}
+
+// CountCoverRefs mocks base method
+func (m *MockRepository) CountCoverRefs(cover string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCoverRefs", cover)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCoverRefs indicates an expected call of CountCoverRefs
+func (mr *MockRepositoryMockRecorder) CountCoverRefs(cover interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCoverRefs", reflect.TypeOf((*MockRepository)(nil).CountCoverRefs), cover)
+}
+
 // GetMeeting mocks base method
 func (m *MockRepository) GetMeeting(meetingId, userId int, authorized bool) (models.MeetingDetails, error) {
 	m.ctrl.T.Helper()
@@ -106,11 +152,12 @@ func (mr *MockRepositoryMockRecorder) SetReg(meetId, userId interface{}) *gomock
 }
 
 // RemoveReg mocks base method
-func (m *MockRepository) RemoveReg(meetId, userId int) error {
+func (m *MockRepository) RemoveReg(meetId, userId int) ([]int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RemoveReg", meetId, userId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // RemoveReg indicates an expected call of RemoveReg
@@ -119,20 +166,221 @@ func (mr *MockRepositoryMockRecorder) RemoveReg(meetId, userId interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReg", reflect.TypeOf((*MockRepository)(nil).RemoveReg), meetId, userId)
 }
 
-// UpdateMeeting mocks base method
-func (m *MockRepository) UpdateMeeting(update models.MeetingCard) error {
+// RequestReg mocks base method
+func (m *MockRepository) RequestReg(meetId, userId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateMeeting", update)
+	ret := m.ctrl.Call(m, "RequestReg", meetId, userId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// RequestReg indicates an expected call of RequestReg
+func (mr *MockRepositoryMockRecorder) RequestReg(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestReg", reflect.TypeOf((*MockRepository)(nil).RequestReg), meetId, userId)
+}
+
+// GetRegRequests mocks base method
+func (m *MockRepository) GetRegRequests(meetId int) ([]*models.ProfileLabel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegRequests", meetId)
+	ret0, _ := ret[0].([]*models.ProfileLabel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegRequests indicates an expected call of GetRegRequests
+func (mr *MockRepositoryMockRecorder) GetRegRequests(meetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegRequests", reflect.TypeOf((*MockRepository)(nil).GetRegRequests), meetId)
+}
+
+// ApproveReg mocks base method
+func (m *MockRepository) ApproveReg(meetId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveReg", meetId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApproveReg indicates an expected call of ApproveReg
+func (mr *MockRepositoryMockRecorder) ApproveReg(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveReg", reflect.TypeOf((*MockRepository)(nil).ApproveReg), meetId, userId)
+}
+
+// RejectReg mocks base method
+func (m *MockRepository) RejectReg(meetId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectReg", meetId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectReg indicates an expected call of RejectReg
+func (mr *MockRepositoryMockRecorder) RejectReg(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectReg", reflect.TypeOf((*MockRepository)(nil).RejectReg), meetId, userId)
+}
+
+// JoinWaitlist mocks base method
+func (m *MockRepository) JoinWaitlist(meetId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JoinWaitlist", meetId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// JoinWaitlist indicates an expected call of JoinWaitlist
+func (mr *MockRepositoryMockRecorder) JoinWaitlist(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JoinWaitlist", reflect.TypeOf((*MockRepository)(nil).JoinWaitlist), meetId, userId)
+}
+
+// LeaveWaitlist mocks base method
+func (m *MockRepository) LeaveWaitlist(meetId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LeaveWaitlist", meetId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LeaveWaitlist indicates an expected call of LeaveWaitlist
+func (mr *MockRepositoryMockRecorder) LeaveWaitlist(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaveWaitlist", reflect.TypeOf((*MockRepository)(nil).LeaveWaitlist), meetId, userId)
+}
+
+// GetWaitlist mocks base method
+func (m *MockRepository) GetWaitlist(meetId int) ([]models.WaitlistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWaitlist", meetId)
+	ret0, _ := ret[0].([]models.WaitlistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWaitlist indicates an expected call of GetWaitlist
+func (mr *MockRepositoryMockRecorder) GetWaitlist(meetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWaitlist", reflect.TypeOf((*MockRepository)(nil).GetWaitlist), meetId)
+}
+
+// MoveInWaitlist mocks base method
+func (m *MockRepository) MoveInWaitlist(meetId, userId, position int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveInWaitlist", meetId, userId, position)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveInWaitlist indicates an expected call of MoveInWaitlist
+func (mr *MockRepositoryMockRecorder) MoveInWaitlist(meetId, userId, position interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveInWaitlist", reflect.TypeOf((*MockRepository)(nil).MoveInWaitlist), meetId, userId, position)
+}
+
+// GetRole mocks base method
+func (m *MockRepository) GetRole(meetId, userId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", meetId, userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole
+func (mr *MockRepositoryMockRecorder) GetRole(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockRepository)(nil).GetRole), meetId, userId)
+}
+
+// GetMemberMeetingIds mocks base method
+func (m *MockRepository) GetMemberMeetingIds(userId int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberMeetingIds", userId)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberMeetingIds indicates an expected call of GetMemberMeetingIds
+func (mr *MockRepositoryMockRecorder) GetMemberMeetingIds(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberMeetingIds", reflect.TypeOf((*MockRepository)(nil).GetMemberMeetingIds), userId)
+}
+
+// AddOrganizer mocks base method
+func (m *MockRepository) AddOrganizer(meetId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrganizer", meetId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrganizer indicates an expected call of AddOrganizer
+func (mr *MockRepositoryMockRecorder) AddOrganizer(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrganizer", reflect.TypeOf((*MockRepository)(nil).AddOrganizer), meetId, userId)
+}
+
+// RemoveOrganizer mocks base method
+func (m *MockRepository) RemoveOrganizer(meetId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveOrganizer", meetId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveOrganizer indicates an expected call of RemoveOrganizer
+func (mr *MockRepositoryMockRecorder) RemoveOrganizer(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOrganizer", reflect.TypeOf((*MockRepository)(nil).RemoveOrganizer), meetId, userId)
+}
+
+// UpdateMeeting mocks base method
+func (m *MockRepository) UpdateMeeting(update models.MeetingCard) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMeeting", update)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // UpdateMeeting indicates an expected call of UpdateMeeting
 func (mr *MockRepositoryMockRecorder) UpdateMeeting(update interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMeeting", reflect.TypeOf((*MockRepository)(nil).UpdateMeeting), update)
 }
 
+// CancelMeeting mocks base method
+func (m *MockRepository) CancelMeeting(meetId int, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelMeeting", meetId, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelMeeting indicates an expected call of CancelMeeting
+func (mr *MockRepositoryMockRecorder) CancelMeeting(meetId, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelMeeting", reflect.TypeOf((*MockRepository)(nil).CancelMeeting), meetId, reason)
+}
+
+// DeleteMeeting mocks base method
+func (m *MockRepository) DeleteMeeting(meetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMeeting", meetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMeeting indicates an expected call of DeleteMeeting
+func (mr *MockRepositoryMockRecorder) DeleteMeeting(meetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMeeting", reflect.TypeOf((*MockRepository)(nil).DeleteMeeting), meetId)
+}
+
 // GetNextMeetings mocks base method
 func (m *MockRepository) GetNextMeetings(params FilterParams) ([]models.Meeting, error) {
 	m.ctrl.T.Helper()
@@ -282,3 +530,121 @@ func (mr *MockRepositoryMockRecorder) SearchMeetings(params, meetingName, limit
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMeetings", reflect.TypeOf((*MockRepository)(nil).SearchMeetings), params, meetingName, limit)
 }
+
+// FilterNearby mocks base method
+func (m *MockRepository) FilterNearby(params FilterParams) ([]models.Meeting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterNearby", params)
+	ret0, _ := ret[0].([]models.Meeting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterNearby indicates an expected call of FilterNearby
+func (mr *MockRepositoryMockRecorder) FilterNearby(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterNearby", reflect.TypeOf((*MockRepository)(nil).FilterNearby), params)
+}
+
+// QueryMeetings mocks base method
+func (m *MockRepository) QueryMeetings(params QueryParams) ([]models.Meeting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryMeetings", params)
+	ret0, _ := ret[0].([]models.Meeting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryMeetings indicates an expected call of QueryMeetings
+func (mr *MockRepositoryMockRecorder) QueryMeetings(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryMeetings", reflect.TypeOf((*MockRepository)(nil).QueryMeetings), params)
+}
+
+// QueryFacets mocks base method
+func (m *MockRepository) QueryFacets(params QueryParams) (models.MeetingFacets, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryFacets", params)
+	ret0, _ := ret[0].(models.MeetingFacets)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryFacets indicates an expected call of QueryFacets
+func (mr *MockRepositoryMockRecorder) QueryFacets(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryFacets", reflect.TypeOf((*MockRepository)(nil).QueryFacets), params)
+}
+
+// GetFeedToken mocks base method
+func (m *MockRepository) GetFeedToken(userId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedToken", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedToken indicates an expected call of GetFeedToken
+func (mr *MockRepositoryMockRecorder) GetFeedToken(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedToken", reflect.TypeOf((*MockRepository)(nil).GetFeedToken), userId)
+}
+
+// SetFeedToken mocks base method
+func (m *MockRepository) SetFeedToken(userId int, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFeedToken", userId, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFeedToken indicates an expected call of SetFeedToken
+func (mr *MockRepositoryMockRecorder) SetFeedToken(userId, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFeedToken", reflect.TypeOf((*MockRepository)(nil).SetFeedToken), userId, token)
+}
+
+// RemoveFeedToken mocks base method
+func (m *MockRepository) RemoveFeedToken(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFeedToken", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFeedToken indicates an expected call of RemoveFeedToken
+func (mr *MockRepositoryMockRecorder) RemoveFeedToken(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFeedToken", reflect.TypeOf((*MockRepository)(nil).RemoveFeedToken), userId)
+}
+
+// GetFeedOwner mocks base method
+func (m *MockRepository) GetFeedOwner(token string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedOwner", token)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedOwner indicates an expected call of GetFeedOwner
+func (mr *MockRepositoryMockRecorder) GetFeedOwner(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedOwner", reflect.TypeOf((*MockRepository)(nil).GetFeedOwner), token)
+}
+
+// GetFeedMeetings mocks base method
+func (m *MockRepository) GetFeedMeetings(userId int, since time.Time, limit int) ([]models.Meeting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedMeetings", userId, since, limit)
+	ret0, _ := ret[0].([]models.Meeting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedMeetings indicates an expected call of GetFeedMeetings
+func (mr *MockRepositoryMockRecorder) GetFeedMeetings(userId, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedMeetings", reflect.TypeOf((*MockRepository)(nil).GetFeedMeetings), userId, since, limit)
+}
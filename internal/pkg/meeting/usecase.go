@@ -7,6 +7,15 @@ type UseCase interface {
 	CreateMeeting(authorId int, data models.MeetingData) (meetingId int, err error)
 	GetMeeting(meetingId, userId int, authorized bool) (models.MeetingDetails, error)
 	UpdateMeeting(userId int, update models.MeetingUpdate) error
+	CancelMeeting(userId int, meetId int, reason string) error
+	DeleteMeeting(userId int, meetId int) error
+	GetWaitlist(userId int, meetId int) ([]models.WaitlistEntry, error)
+	MoveInWaitlist(userId int, move models.WaitlistMove) error
+	GetRegRequests(userId int, meetId int) ([]*models.ProfileLabel, error)
+	ApproveReg(userId int, meetId int, targetId int) error
+	RejectReg(userId int, meetId int, targetId int) error
+	AddOrganizer(userId int, meetId int, targetId int) error
+	RemoveOrganizer(userId int, meetId int, targetId int) error
 	GetNextMeetings(params FilterParams) ([]models.Meeting, error)
 	GetTopMeetings(params FilterParams) ([]models.Meeting, error)
 	FilterLiked(params FilterParams) ([]models.Meeting, error)
@@ -17,4 +26,9 @@ type UseCase interface {
 	FilterTagged(params FilterParams, tags []string) ([]models.Meeting, error)
 	FilterSimilar(params FilterParams, meetingId int) ([]models.Meeting, error)
 	SearchMeetings(params FilterParams, meetingName string, limit int) ([]models.Meeting, error)
+	FilterNearby(params FilterParams) ([]models.Meeting, error)
+	QueryMeetings(params QueryParams) (models.MeetingQueryResult, error)
+	GetFeedToken(userId int) (string, error)
+	RevokeFeedToken(userId int) error
+	GetFeedMeetings(token string) ([]models.Meeting, error)
 }
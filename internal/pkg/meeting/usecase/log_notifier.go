@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"fmt"
+	"konami_backend/internal/pkg/meeting"
+	loggerPkg "konami_backend/logger"
+)
+
+type LogNotifier struct {
+	Log *loggerPkg.Logger
+}
+
+func NewLogNotifier(log *loggerPkg.Logger) meeting.Notifier {
+	return &LogNotifier{Log: log}
+}
+
+func (n *LogNotifier) WaitlistPromoted(meetId int, userIds []int) {
+	for _, userId := range userIds {
+		n.Log.LogInfo("meeting", "WaitlistPromoted",
+			fmt.Sprintf("user %d moved from waitlist to meeting %d", userId, meetId))
+	}
+}
+
+func (n *LogNotifier) MeetingEvent(eventType string, meetId int, authorId int, userId int) {
+	n.Log.LogInfo("meeting", "MeetingEvent",
+		fmt.Sprintf("user %d %s meeting %d of user %d", userId, eventType, meetId, authorId))
+}
@@ -1,20 +1,34 @@
 package usecase
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"konami_backend/internal/pkg/meeting"
 	"konami_backend/internal/pkg/models"
 	"konami_backend/internal/pkg/tag"
+	"konami_backend/internal/pkg/utils/geocoder"
+	"konami_backend/internal/pkg/utils/recurrence"
 	"konami_backend/internal/pkg/utils/uploads_handler"
+	"konami_backend/logger"
 	"strings"
+	"time"
 )
 
+const dateLayout = "2006-01-02T15:04:05.000Z0700"
+
+// FeedLimit caps the number of meetings exported to a calendar feed
+const FeedLimit = 500
+
 type MeetingUseCase struct {
 	MeetRepo         meeting.Repository
 	UploadsHandler   uploads_handler.UploadsHandler
 	TagRepo          tag.Repository
+	Notifier         meeting.Notifier
+	Geocoder         geocoder.Geocoder
+	Log              *logger.Logger
 	MeetingCoversDir string
 	defaultImgSrc    string
 }
@@ -22,6 +36,9 @@ type MeetingUseCase struct {
 func NewMeetingUseCase(MeetRepo meeting.Repository,
 	UploadsHandler uploads_handler.UploadsHandler,
 	TagRepo tag.Repository,
+	Notifier meeting.Notifier,
+	Geocoder geocoder.Geocoder,
+	Log *logger.Logger,
 	MeetingCoversDir string,
 	defaultImgSrc string) meeting.UseCase {
 
@@ -29,6 +46,9 @@ func NewMeetingUseCase(MeetRepo meeting.Repository,
 		MeetRepo:         MeetRepo,
 		UploadsHandler:   UploadsHandler,
 		TagRepo:          TagRepo,
+		Notifier:         Notifier,
+		Geocoder:         Geocoder,
+		Log:              Log,
 		MeetingCoversDir: MeetingCoversDir,
 		defaultImgSrc:    defaultImgSrc,
 	}
@@ -69,6 +89,13 @@ func (uc *MeetingUseCase) CreateMeeting(authorId int, data models.MeetingData) (
 		m.Card.Seats = *data.Seats
 	}
 	m.Card.SeatsLeft = m.Card.Seats
+	if data.Approval != nil {
+		m.Card.Approval = *data.Approval
+	}
+	err = uc.locate(m.Card, &data)
+	if err != nil {
+		return 0, err
+	}
 	if data.Tags != nil {
 		for _, tagName := range data.Tags {
 			t, err := uc.TagRepo.GetOrCreateTag(tagName)
@@ -78,9 +105,61 @@ func (uc *MeetingUseCase) CreateMeeting(authorId int, data models.MeetingData) (
 			m.Card.Tags = append(m.Card.Tags, &t)
 		}
 	}
+	if data.Recurrence != nil {
+		return uc.createSeries(*data.Recurrence, m)
+	}
 	return uc.MeetRepo.CreateMeeting(m)
 }
 
+// locate sets explicit coordinates or geocodes the meeting address
+func (uc *MeetingUseCase) locate(card *models.MeetingCard, data *models.MeetingData) error {
+	if data.Lat != nil || data.Lon != nil {
+		if data.Lat == nil || data.Lon == nil || *data.Lat < -90 || *data.Lat > 90 ||
+			*data.Lon < -180 || *data.Lon > 180 {
+			return meeting.ErrInvalidLocation
+		}
+		lat, lon := *data.Lat, *data.Lon
+		card.Lat, card.Lon = &lat, &lon
+		return nil
+	}
+	card.Lat, card.Lon = nil, nil
+	if uc.Geocoder == nil {
+		return nil
+	}
+	p, err := uc.Geocoder.Geocode(card.City, card.Address)
+	if err == nil {
+		card.Lat, card.Lon = &p.Lat, &p.Lon
+	}
+	return nil
+}
+
+func (uc *MeetingUseCase) createSeries(rule string, m models.Meeting) (int, error) {
+	r, err := recurrence.Parse(rule)
+	if err != nil {
+		return 0, err
+	}
+	start, errSt := time.Parse(dateLayout, m.Card.StartDate)
+	end, errEnd := time.Parse(dateLayout, m.Card.EndDate)
+	if errSt != nil || errEnd != nil {
+		return 0, errors.New("invalid datetime format")
+	}
+	occurrences := r.Occurrences(start)
+	meetings := make([]models.Meeting, len(occurrences))
+	for i, occStart := range occurrences {
+		card := *m.Card
+		label := *m.Card.Label
+		card.Label = &label
+		card.StartDate = occStart.Format(dateLayout)
+		card.EndDate = occStart.Add(end.Sub(start)).Format(dateLayout)
+		meetings[i] = models.Meeting{Card: &card}
+	}
+	ids, err := uc.MeetRepo.CreateSeries(rule, meetings)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
 func (uc *MeetingUseCase) GetMeeting(meetingId, userId int, authorized bool) (models.MeetingDetails, error) {
 	return uc.MeetRepo.GetMeeting(meetingId, userId, authorized)
 }
@@ -90,12 +169,25 @@ func (uc *MeetingUseCase) UpdateMeeting(userId int, update models.MeetingUpdate)
 		return errors.New("invalid update data")
 	}
 	m, err := uc.MeetRepo.GetMeeting(update.MeetId, -1, false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return meeting.ErrMeetingNotFound
+	}
 	if err != nil {
 		return errors.New("invalid meeting id")
 	}
+	if update.Fields.Card != nil {
+		role, err := uc.MeetRepo.GetRole(update.MeetId, userId)
+		if err != nil {
+			return err
+		}
+		if !meeting.IsOrganizer(role) {
+			return meeting.ErrAccessDenied
+		}
+	}
 	if update.Fields.Card != nil && update.Fields.Card.Photo != nil {
 		imgSrc := uc.MeetingCoversDir + "/" + uuid.New().String()
-		if !strings.HasSuffix(m.Card.Label.Cover, uc.defaultImgSrc) {
+		// Series occurrences share the cover file, so it is never overwritten in place
+		if !strings.HasSuffix(m.Card.Label.Cover, uc.defaultImgSrc) && m.Card.SeriesId == 0 {
 			imgSrc = strings.TrimPrefix(m.Card.Label.Cover, uc.UploadsHandler.UploadsDir+"/")
 		}
 		m.Card.Label.Cover, err = uc.UploadsHandler.UploadBase64Image(imgSrc, update.Fields.Card.Photo)
@@ -103,14 +195,11 @@ func (uc *MeetingUseCase) UpdateMeeting(userId int, update models.MeetingUpdate)
 			return err
 		}
 	}
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return meeting.ErrMeetingNotFound
-	}
-	if err != nil {
-		return err
-	}
 	if update.Fields.Like != nil && *update.Fields.Like {
 		err = uc.MeetRepo.SetLike(update.MeetId, userId)
+		if err == nil {
+			uc.notifyAuthor(meeting.EventLiked, m.Card, userId)
+		}
 	} else if update.Fields.Like != nil && !*update.Fields.Like {
 		err = uc.MeetRepo.RemoveLike(update.MeetId, userId)
 	}
@@ -118,13 +207,27 @@ func (uc *MeetingUseCase) UpdateMeeting(userId int, update models.MeetingUpdate)
 		return err
 	}
 	if update.Fields.Reg != nil && *update.Fields.Reg {
-		err = uc.MeetRepo.SetReg(update.MeetId, userId)
+		err = uc.register(update.MeetId, m.Card, userId)
 	} else if update.Fields.Reg != nil && !*update.Fields.Reg {
-		err = uc.MeetRepo.RemoveReg(update.MeetId, userId)
+		var promoted []int
+		promoted, err = uc.MeetRepo.RemoveReg(update.MeetId, userId)
+		uc.notifyPromoted(update.MeetId, promoted)
+	}
+	if err != nil {
+		return err
+	}
+	if update.Fields.Wait != nil && *update.Fields.Wait {
+		err = uc.MeetRepo.JoinWaitlist(update.MeetId, userId)
+	} else if update.Fields.Wait != nil && !*update.Fields.Wait {
+		err = uc.MeetRepo.LeaveWaitlist(update.MeetId, userId)
 	}
 	if update.Fields.Card == nil {
 		return err
 	}
+	if err != nil {
+		return err
+	}
+	origStart, origEnd := m.Card.StartDate, m.Card.EndDate
 	if update.Fields.Card.Address != nil {
 		m.Card.Address = *update.Fields.Card.Address
 	}
@@ -139,15 +242,28 @@ func (uc *MeetingUseCase) UpdateMeeting(userId int, update models.MeetingUpdate)
 	}
 	if update.Fields.Card.Seats != nil {
 		occupied := m.Card.Seats - m.Card.SeatsLeft
+		if *update.Fields.Card.Seats < occupied {
+			return meeting.ErrSeatsOccupied
+		}
 		m.Card.Seats = *update.Fields.Card.Seats
 		m.Card.SeatsLeft = m.Card.Seats - occupied
 	}
 	if update.Fields.Card.Text != nil {
 		m.Card.Text = *update.Fields.Card.Text
 	}
+	if update.Fields.Card.Approval != nil {
+		m.Card.Approval = *update.Fields.Card.Approval
+	}
 	if update.Fields.Card.Title != nil {
 		m.Card.Label.Title = *update.Fields.Card.Title
 	}
+	if update.Fields.Card.Address != nil || update.Fields.Card.City != nil ||
+		update.Fields.Card.Lat != nil || update.Fields.Card.Lon != nil {
+		err = uc.locate(m.Card, update.Fields.Card)
+		if err != nil {
+			return err
+		}
+	}
 	if update.Fields.Card.Tags != nil {
 		m.Card.Tags = []*models.Tag{}
 		for _, tagName := range update.Fields.Card.Tags {
@@ -158,7 +274,282 @@ func (uc *MeetingUseCase) UpdateMeeting(userId int, update models.MeetingUpdate)
 			m.Card.Tags = append(m.Card.Tags, &t)
 		}
 	}
-	return uc.MeetRepo.UpdateMeeting(*m.Card)
+	cards := []models.MeetingCard{*m.Card}
+	if update.Scope == meeting.ScopeFollowing && m.Card.SeriesId != 0 {
+		following, err := uc.followingCards(update, *m.Card, origStart, origEnd)
+		if err != nil {
+			return err
+		}
+		cards = append(cards, following...)
+	}
+	for _, card := range cards {
+		promoted, err := uc.MeetRepo.UpdateMeeting(card)
+		uc.notifyPromoted(card.Label.Id, promoted)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *MeetingUseCase) followingCards(update models.MeetingUpdate, edited models.MeetingCard,
+	origStart string, origEnd string) ([]models.MeetingCard, error) {
+	startShift, err := dateShift(origStart, edited.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	endShift, err := dateShift(origEnd, edited.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	series, err := uc.MeetRepo.GetSeriesFollowing(update.MeetId)
+	if err != nil {
+		return nil, err
+	}
+	data := update.Fields.Card
+	cards := []models.MeetingCard{}
+	for _, card := range series {
+		if card.Label.Id == update.MeetId {
+			continue
+		}
+		card.StartDate, err = shiftDate(card.StartDate, startShift)
+		if err != nil {
+			return nil, err
+		}
+		card.EndDate, err = shiftDate(card.EndDate, endShift)
+		if err != nil {
+			return nil, err
+		}
+		if data.Address != nil {
+			card.Address = edited.Address
+		}
+		if data.City != nil {
+			card.City = edited.City
+		}
+		if data.Text != nil {
+			card.Text = edited.Text
+		}
+		if data.Approval != nil {
+			card.Approval = edited.Approval
+		}
+		if data.Title != nil {
+			card.Label.Title = edited.Label.Title
+		}
+		if data.Photo != nil {
+			card.Label.Cover = edited.Label.Cover
+		}
+		if data.Tags != nil {
+			card.Tags = edited.Tags
+		}
+		if data.Address != nil || data.City != nil || data.Lat != nil || data.Lon != nil {
+			card.Lat, card.Lon = edited.Lat, edited.Lon
+		}
+		if data.Seats != nil {
+			occupied := card.Seats - card.SeatsLeft
+			if edited.Seats < occupied {
+				return nil, meeting.ErrSeatsOccupied
+			}
+			card.Seats = edited.Seats
+			card.SeatsLeft = edited.Seats - occupied
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+func dateShift(from string, to string) (time.Duration, error) {
+	if from == to {
+		return 0, nil
+	}
+	fromTime, errFrom := time.Parse(dateLayout, from)
+	toTime, errTo := time.Parse(dateLayout, to)
+	if errFrom != nil || errTo != nil {
+		return 0, errors.New("invalid datetime format")
+	}
+	return toTime.Sub(fromTime), nil
+}
+
+func shiftDate(date string, shift time.Duration) (string, error) {
+	if shift == 0 {
+		return date, nil
+	}
+	t, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return "", errors.New("invalid datetime format")
+	}
+	return t.Add(shift).Format(dateLayout), nil
+}
+
+func (uc *MeetingUseCase) register(meetId int, card *models.MeetingCard, userId int) error {
+	event := meeting.EventRegistered
+	err := func() error {
+		if !card.Approval {
+			return uc.MeetRepo.SetReg(meetId, userId)
+		}
+		role, err := uc.MeetRepo.GetRole(meetId, userId)
+		if err != nil {
+			return err
+		}
+		if meeting.IsOrganizer(role) {
+			return uc.MeetRepo.SetReg(meetId, userId)
+		}
+		event = meeting.EventRegRequested
+		return uc.MeetRepo.RequestReg(meetId, userId)
+	}()
+	if err == nil {
+		uc.notifyAuthor(event, card, userId)
+	}
+	return err
+}
+
+func (uc *MeetingUseCase) GetRegRequests(userId int, meetId int) ([]*models.ProfileLabel, error) {
+	role, err := uc.MeetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !meeting.IsOrganizer(role) {
+		return nil, meeting.ErrAccessDenied
+	}
+	return uc.MeetRepo.GetRegRequests(meetId)
+}
+
+func (uc *MeetingUseCase) ApproveReg(userId int, meetId int, targetId int) error {
+	role, err := uc.MeetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return err
+	}
+	if !meeting.IsOrganizer(role) {
+		return meeting.ErrAccessDenied
+	}
+	return uc.MeetRepo.ApproveReg(meetId, targetId)
+}
+
+func (uc *MeetingUseCase) RejectReg(userId int, meetId int, targetId int) error {
+	role, err := uc.MeetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return err
+	}
+	if !meeting.IsOrganizer(role) {
+		return meeting.ErrAccessDenied
+	}
+	return uc.MeetRepo.RejectReg(meetId, targetId)
+}
+
+func (uc *MeetingUseCase) notifyAuthor(eventType string, card *models.MeetingCard, userId int) {
+	if uc.Notifier != nil && card.AuthorId != userId {
+		uc.Notifier.MeetingEvent(eventType, card.Label.Id, card.AuthorId, userId)
+	}
+}
+
+func (uc *MeetingUseCase) notifyPromoted(meetId int, promoted []int) {
+	if len(promoted) > 0 && uc.Notifier != nil {
+		uc.Notifier.WaitlistPromoted(meetId, promoted)
+	}
+}
+
+func (uc *MeetingUseCase) GetWaitlist(userId int, meetId int) ([]models.WaitlistEntry, error) {
+	role, err := uc.MeetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !meeting.IsOrganizer(role) {
+		return nil, meeting.ErrAccessDenied
+	}
+	return uc.MeetRepo.GetWaitlist(meetId)
+}
+
+func (uc *MeetingUseCase) MoveInWaitlist(userId int, move models.WaitlistMove) error {
+	role, err := uc.MeetRepo.GetRole(move.MeetId, userId)
+	if err != nil {
+		return err
+	}
+	if !meeting.IsOrganizer(role) {
+		return meeting.ErrAccessDenied
+	}
+	if move.Position < 1 {
+		return errors.New("invalid waitlist position")
+	}
+	return uc.MeetRepo.MoveInWaitlist(move.MeetId, move.UserId, move.Position)
+}
+
+func (uc *MeetingUseCase) CancelMeeting(userId int, meetId int, reason string) error {
+	role, err := uc.MeetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return err
+	}
+	if !meeting.IsOrganizer(role) {
+		return meeting.ErrAccessDenied
+	}
+	return uc.MeetRepo.CancelMeeting(meetId, strings.TrimSpace(reason))
+}
+
+func (uc *MeetingUseCase) DeleteMeeting(userId int, meetId int) error {
+	role, err := uc.MeetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return err
+	}
+	if !meeting.IsOrganizer(role) {
+		return meeting.ErrAccessDenied
+	}
+	m, err := uc.MeetRepo.GetMeeting(meetId, -1, false)
+	if err != nil {
+		return err
+	}
+	err = uc.MeetRepo.DeleteMeeting(meetId)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(m.Card.Label.Cover, uc.defaultImgSrc) {
+		return nil
+	}
+	// the meeting is gone already, a leftover cover must not fail the request
+	if m.Card.SeriesId != 0 {
+		refs, err := uc.MeetRepo.CountCoverRefs(m.Card.Label.Cover)
+		if err != nil {
+			uc.Log.LogError("meeting/usecase", "DeleteMeeting", err)
+			return nil
+		}
+		if refs > 0 {
+			return nil
+		}
+	}
+	if err = uc.UploadsHandler.RemoveImage(m.Card.Label.Cover); err != nil {
+		uc.Log.LogError("meeting/usecase", "DeleteMeeting", err)
+	}
+	return nil
+}
+
+func (uc *MeetingUseCase) AddOrganizer(userId int, meetId int, targetId int) error {
+	role, err := uc.MeetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return err
+	}
+	if role != meeting.RoleAuthor {
+		return meeting.ErrAccessDenied
+	}
+	targetRole, err := uc.MeetRepo.GetRole(meetId, targetId)
+	if err != nil {
+		return err
+	}
+	if meeting.IsOrganizer(targetRole) {
+		return nil
+	}
+	if targetRole != meeting.RoleParticipant {
+		return meeting.ErrNotParticipant
+	}
+	return uc.MeetRepo.AddOrganizer(meetId, targetId)
+}
+
+func (uc *MeetingUseCase) RemoveOrganizer(userId int, meetId int, targetId int) error {
+	role, err := uc.MeetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return err
+	}
+	// Co-organizers are allowed to step down themselves
+	if role != meeting.RoleAuthor && !(role == meeting.RoleOrganizer && userId == targetId) {
+		return meeting.ErrAccessDenied
+	}
+	return uc.MeetRepo.RemoveOrganizer(meetId, targetId)
 }
 
 func (uc *MeetingUseCase) GetNextMeetings(params meeting.FilterParams) ([]models.Meeting, error) {
@@ -201,3 +592,50 @@ func (uc *MeetingUseCase) SearchMeetings(params meeting.FilterParams,
 	meetingName string, limit int) ([]models.Meeting, error) {
 	return uc.MeetRepo.SearchMeetings(params, meetingName, limit)
 }
+
+func (uc *MeetingUseCase) FilterNearby(params meeting.FilterParams) ([]models.Meeting, error) {
+	return uc.MeetRepo.FilterNearby(params)
+}
+
+func (uc *MeetingUseCase) QueryMeetings(params meeting.QueryParams) (models.MeetingQueryResult, error) {
+	meets, err := uc.MeetRepo.QueryMeetings(params)
+	if err != nil {
+		return models.MeetingQueryResult{}, err
+	}
+	facets, err := uc.MeetRepo.QueryFacets(params)
+	if err != nil {
+		return models.MeetingQueryResult{}, err
+	}
+	return models.MeetingQueryResult{Meetings: meets, Facets: facets}, nil
+}
+
+func (uc *MeetingUseCase) GetFeedToken(userId int) (string, error) {
+	token, err := uc.MeetRepo.GetFeedToken(userId)
+	if err == nil {
+		return token, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	raw := make([]byte, 32)
+	if _, err = rand.Read(raw); err != nil {
+		return "", err
+	}
+	token = hex.EncodeToString(raw)
+	return token, uc.MeetRepo.SetFeedToken(userId, token)
+}
+
+func (uc *MeetingUseCase) RevokeFeedToken(userId int) error {
+	return uc.MeetRepo.RemoveFeedToken(userId)
+}
+
+func (uc *MeetingUseCase) GetFeedMeetings(token string) ([]models.Meeting, error) {
+	if token == "" {
+		return nil, meeting.ErrInvalidFeedToken
+	}
+	userId, err := uc.MeetRepo.GetFeedOwner(token)
+	if err != nil {
+		return nil, err
+	}
+	return uc.MeetRepo.GetFeedMeetings(userId, time.Now().AddDate(0, -1, 0), FeedLimit)
+}
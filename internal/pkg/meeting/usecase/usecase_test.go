@@ -4,13 +4,21 @@ import (
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"io/ioutil"
 	"konami_backend/internal/pkg/meeting"
 	"konami_backend/internal/pkg/models"
 	"konami_backend/internal/pkg/tag"
+	"konami_backend/internal/pkg/utils/geocoder"
+	"konami_backend/internal/pkg/utils/recurrence"
 	uploadsHandlerPkg "konami_backend/internal/pkg/utils/uploads_handler"
+	"konami_backend/logger"
 	"testing"
+	"time"
 )
 
+var testLog = logger.NewLogger(ioutil.Discard)
+
 func TestTag(t *testing.T) {
 	t.Run("TestOnUsedToken", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -20,7 +28,7 @@ func TestTag(t *testing.T) {
 
 		uploadsHandler := uploadsHandlerPkg.NewUploadsHandler("uploadsDir")
 
-		uc := NewMeetingUseCase(mRep, uploadsHandler, tagRep, "test", "test")
+		uc := NewMeetingUseCase(mRep, uploadsHandler, tagRep, nil, nil, testLog, "test", "test")
 
 		mRep.EXPECT().GetMeeting(1, 1, true).
 			Return(models.MeetingDetails{}, nil)
@@ -162,15 +170,385 @@ func TestTag(t *testing.T) {
 
 		mRep.EXPECT().GetMeeting(1, -1, false).
 			Return(testM, nil)
+		mRep.EXPECT().GetRole(1, 3).
+			Return(meeting.RoleOrganizer, nil)
 		mRep.EXPECT().SetLike(1, 3).
 			Return(nil)
 		mRep.EXPECT().SetReg(1, 3).
 			Return(nil)
 
 		mRep.EXPECT().UpdateMeeting(*testM.Card).
-			Return(nil)
+			Return(nil, nil)
 
 		err = uc.UpdateMeeting(3, testUpdModels)
 		assert.NoError(t, err)
+
+		mRep.EXPECT().GetMeeting(1, -1, false).
+			Return(testM, nil)
+		mRep.EXPECT().GetRole(1, 5).
+			Return(meeting.RoleParticipant, nil)
+
+		err = uc.UpdateMeeting(5, testUpdModels)
+		assert.Equal(t, meeting.ErrAccessDenied, err)
+	})
+
+	t.Run("Organizers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, nil, nil, testLog, "test", "test")
+
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		mRep.EXPECT().AddOrganizer(1, 4).Return(nil)
+		err := uc.AddOrganizer(3, 1, 4)
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().GetRole(1, 5).Return(meeting.RoleGuest, nil)
+		err = uc.AddOrganizer(3, 1, 5)
+		assert.Equal(t, meeting.ErrNotParticipant, err)
+
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleOrganizer, nil)
+		err = uc.AddOrganizer(4, 1, 5)
+		assert.Equal(t, meeting.ErrAccessDenied, err)
+
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleOrganizer, nil)
+		mRep.EXPECT().RemoveOrganizer(1, 4).Return(nil)
+		err = uc.RemoveOrganizer(4, 1, 4)
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleOrganizer, nil)
+		err = uc.RemoveOrganizer(4, 1, 6)
+		assert.Equal(t, meeting.ErrAccessDenied, err)
+	})
+
+	t.Run("CancelDelete", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, nil, nil, testLog, "test", "default.png")
+
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleOrganizer, nil)
+		mRep.EXPECT().CancelMeeting(1, "rain").Return(nil)
+		err := uc.CancelMeeting(3, 1, "  rain ")
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		err = uc.CancelMeeting(4, 1, "rain")
+		assert.Equal(t, meeting.ErrAccessDenied, err)
+
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		err = uc.DeleteMeeting(4, 1)
+		assert.Equal(t, meeting.ErrAccessDenied, err)
+
+		testM := models.MeetingDetails{
+			Card: &models.MeetingCard{
+				Label: &models.MeetingLabel{Id: 1, Cover: "assets/default.png"},
+			},
+		}
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().DeleteMeeting(1).Return(nil)
+		err = uc.DeleteMeeting(3, 1)
+		assert.NoError(t, err)
+
+		// the meeting is deleted even if its cover is not
+		testM.Card.Label.Cover = "../etc/passwd"
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().DeleteMeeting(1).Return(nil)
+		err = uc.DeleteMeeting(3, 1)
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetRole(2, 3).Return(meeting.RoleGuest, meeting.ErrMeetingNotFound)
+		err = uc.DeleteMeeting(3, 2)
+		assert.Equal(t, meeting.ErrMeetingNotFound, err)
+	})
+
+	t.Run("Waitlist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		notifier := meeting.NewMockNotifier(ctrl)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, notifier, nil, testLog, "test", "test")
+
+		testM := models.MeetingDetails{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 1}}}
+		wait := true
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().JoinWaitlist(1, 3).Return(meeting.ErrSeatsAvailable)
+		err := uc.UpdateMeeting(3, models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Wait: &wait}})
+		assert.Equal(t, meeting.ErrSeatsAvailable, err)
+
+		reg := false
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().RemoveReg(1, 3).Return([]int{5, 6}, nil)
+		notifier.EXPECT().WaitlistPromoted(1, []int{5, 6})
+		err = uc.UpdateMeeting(3, models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Reg: &reg}})
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		_, err = uc.GetWaitlist(4, 1)
+		assert.Equal(t, meeting.ErrAccessDenied, err)
+
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().GetWaitlist(1).Return([]models.WaitlistEntry{{Position: 1}}, nil)
+		list, err := uc.GetWaitlist(3, 1)
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().MoveInWaitlist(1, 5, 1).Return(nil)
+		err = uc.MoveInWaitlist(3, models.WaitlistMove{MeetId: 1, UserId: 5, Position: 1})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Approval", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, nil, nil, testLog, "test", "test")
+
+		testM := models.MeetingDetails{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 1}, Approval: true}}
+		reg := true
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleGuest, nil)
+		mRep.EXPECT().RequestReg(1, 4).Return(nil)
+		err := uc.UpdateMeeting(4, models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Reg: &reg}})
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().SetReg(1, 3).Return(nil)
+		err = uc.UpdateMeeting(3, models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Reg: &reg}})
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleGuest, nil)
+		err = uc.ApproveReg(4, 1, 4)
+		assert.Equal(t, meeting.ErrAccessDenied, err)
+
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().ApproveReg(1, 4).Return(meeting.ErrNoSeatsLeft)
+		err = uc.ApproveReg(3, 1, 4)
+		assert.Equal(t, meeting.ErrNoSeatsLeft, err)
+
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleOrganizer, nil)
+		mRep.EXPECT().RejectReg(1, 4).Return(nil)
+		err = uc.RejectReg(3, 1, 4)
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().GetRegRequests(1).Return([]*models.ProfileLabel{{Id: 4}}, nil)
+		requests, err := uc.GetRegRequests(3, 1)
+		assert.NoError(t, err)
+		assert.Len(t, requests, 1)
+	})
+
+	t.Run("NotifyAuthor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		notifier := meeting.NewMockNotifier(ctrl)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, notifier, nil, testLog, "test", "test")
+
+		testM := models.MeetingDetails{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 1}, AuthorId: 3, Approval: true}}
+		like, reg := true, true
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().SetLike(1, 4).Return(nil)
+		notifier.EXPECT().MeetingEvent(meeting.EventLiked, 1, 3, 4)
+		err := uc.UpdateMeeting(4, models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Like: &like}})
+		assert.NoError(t, err)
+
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().GetRole(1, 4).Return(meeting.RoleGuest, nil)
+		mRep.EXPECT().RequestReg(1, 4).Return(nil)
+		notifier.EXPECT().MeetingEvent(meeting.EventRegRequested, 1, 3, 4)
+		err = uc.UpdateMeeting(4, models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Reg: &reg}})
+		assert.NoError(t, err)
+
+		testM.Card.Approval = false
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().SetReg(1, 4).Return(meeting.ErrNoSeatsLeft)
+		err = uc.UpdateMeeting(4, models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Reg: &reg}})
+		assert.Equal(t, meeting.ErrNoSeatsLeft, err)
+
+		mRep.EXPECT().GetMeeting(1, -1, false).Return(testM, nil)
+		mRep.EXPECT().SetLike(1, 3).Return(nil)
+		err = uc.UpdateMeeting(3, models.MeetingUpdate{MeetId: 1, Fields: &models.MeetUpdateFields{Like: &like}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Series", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, nil, nil, testLog, "test", "test")
+
+		title, text, addr, city := "Go meetup", "text", "addr", "Moscow"
+		start, end := "2020-12-01T19:00:00.000Z", "2020-12-01T21:00:00.000Z"
+		rule := "FREQ=WEEKLY;COUNT=3"
+		data := models.MeetingData{Title: &title, Text: &text, Address: &addr, City: &city,
+			Start: &start, End: &end, Recurrence: &rule}
+		mRep.EXPECT().CreateSeries(rule, gomock.Any()).
+			DoAndReturn(func(rule string, meetings []models.Meeting) ([]int, error) {
+				assert.Len(t, meetings, 3)
+				assert.Equal(t, "2020-12-15T19:00:00.000Z", meetings[2].Card.StartDate)
+				assert.Equal(t, "2020-12-15T21:00:00.000Z", meetings[2].Card.EndDate)
+				assert.Equal(t, "Go meetup", meetings[2].Card.Label.Title)
+				return []int{7, 8, 9}, nil
+			})
+		id, err := uc.CreateMeeting(3, data)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, id)
+
+		badRule := "FREQ=HOURLY"
+		data.Recurrence = &badRule
+		_, err = uc.CreateMeeting(3, data)
+		assert.Equal(t, recurrence.ErrInvalidRule, err)
+
+		card := func(id int, start, end string) *models.MeetingCard {
+			return &models.MeetingCard{Label: &models.MeetingLabel{Id: id, Title: "Go meetup"},
+				StartDate: start, EndDate: end, Seats: 10, SeatsLeft: 8, SeriesId: 2}
+		}
+		testM := models.MeetingDetails{Card: card(8, "2020-12-08T19:00:00.000Z", "2020-12-08T21:00:00.000Z")}
+		newStart, newTitle := "2020-12-08T18:00:00.000Z", "Go meetup #2"
+		update := models.MeetingUpdate{MeetId: 8, Scope: meeting.ScopeFollowing,
+			Fields: &models.MeetUpdateFields{Card: &models.MeetingData{Start: &newStart, Title: &newTitle}}}
+		mRep.EXPECT().GetMeeting(8, -1, false).Return(testM, nil)
+		mRep.EXPECT().GetRole(8, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().GetSeriesFollowing(8).Return([]models.MeetingCard{
+			*card(8, "2020-12-08T19:00:00.000Z", "2020-12-08T21:00:00.000Z"),
+			*card(9, "2020-12-15T19:00:00.000Z", "2020-12-15T21:00:00.000Z"),
+		}, nil)
+		edited := card(8, newStart, "2020-12-08T21:00:00.000Z")
+		edited.Label.Title = newTitle
+		following := card(9, "2020-12-15T18:00:00.000Z", "2020-12-15T21:00:00.000Z")
+		following.Label.Title = newTitle
+		gomock.InOrder(
+			mRep.EXPECT().UpdateMeeting(*edited).Return(nil, nil),
+			mRep.EXPECT().UpdateMeeting(*following).Return(nil, nil),
+		)
+		err = uc.UpdateMeeting(3, update)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Feed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, nil, nil, testLog, "test", "test")
+
+		var saved string
+		mRep.EXPECT().GetFeedToken(3).Return("", gorm.ErrRecordNotFound)
+		mRep.EXPECT().SetFeedToken(3, gomock.Any()).
+			DoAndReturn(func(userId int, token string) error {
+				saved = token
+				return nil
+			})
+		token, err := uc.GetFeedToken(3)
+		assert.NoError(t, err)
+		assert.Len(t, token, 64)
+		assert.Equal(t, saved, token)
+
+		mRep.EXPECT().GetFeedToken(3).Return(token, nil)
+		again, err := uc.GetFeedToken(3)
+		assert.NoError(t, err)
+		assert.Equal(t, token, again)
+
+		mRep.EXPECT().GetFeedOwner(token).Return(3, nil)
+		mRep.EXPECT().GetFeedMeetings(3, gomock.Any(), FeedLimit).
+			DoAndReturn(func(userId int, since time.Time, limit int) ([]models.Meeting, error) {
+				assert.WithinDuration(t, time.Now().AddDate(0, -1, 0), since, time.Minute)
+				return []models.Meeting{}, nil
+			})
+		_, err = uc.GetFeedMeetings(token)
+		assert.NoError(t, err)
+
+		_, err = uc.GetFeedMeetings("")
+		assert.Equal(t, meeting.ErrInvalidFeedToken, err)
+
+		mRep.EXPECT().RemoveFeedToken(3).Return(nil)
+		assert.NoError(t, uc.RevokeFeedToken(3))
+	})
+
+	t.Run("Location", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		geo := geocoder.NewCityGeocoder(geocoder.DefaultCities)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, nil, geo, testLog, "test", "test")
+
+		title, text, addr, city := "Go meetup", "text", "Tverskaya 1", "Moscow"
+		start, end := "2020-12-01T19:00:00.000Z", "2020-12-01T21:00:00.000Z"
+		data := models.MeetingData{Title: &title, Text: &text, Address: &addr, City: &city,
+			Start: &start, End: &end}
+		mRep.EXPECT().CreateMeeting(gomock.Any()).
+			DoAndReturn(func(m models.Meeting) (int, error) {
+				assert.Equal(t, geocoder.DefaultCities["moscow"].Lat, *m.Card.Lat)
+				assert.Equal(t, geocoder.DefaultCities["moscow"].Lon, *m.Card.Lon)
+				return 1, nil
+			})
+		_, err := uc.CreateMeeting(3, data)
+		assert.NoError(t, err)
+
+		lat := 91.0
+		data.Lat = &lat
+		_, err = uc.CreateMeeting(3, data)
+		assert.Equal(t, meeting.ErrInvalidLocation, err)
+
+		lat, lon := 55.1, 37.2
+		data.Lon = &lon
+		mRep.EXPECT().CreateMeeting(gomock.Any()).
+			DoAndReturn(func(m models.Meeting) (int, error) {
+				assert.Equal(t, 55.1, *m.Card.Lat)
+				assert.Equal(t, 37.2, *m.Card.Lon)
+				return 2, nil
+			})
+		_, err = uc.CreateMeeting(3, data)
+		assert.NoError(t, err)
+
+		unknown := "Atlantis"
+		testM := models.MeetingDetails{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 2},
+			City: "Moscow", Lat: &lat, Lon: &lon}}
+		mRep.EXPECT().GetMeeting(2, -1, false).Return(testM, nil)
+		mRep.EXPECT().GetRole(2, 3).Return(meeting.RoleAuthor, nil)
+		mRep.EXPECT().UpdateMeeting(gomock.Any()).
+			DoAndReturn(func(card models.MeetingCard) ([]int, error) {
+				assert.Nil(t, card.Lat)
+				assert.Nil(t, card.Lon)
+				return nil, nil
+			})
+		err = uc.UpdateMeeting(3, models.MeetingUpdate{MeetId: 2,
+			Fields: &models.MeetUpdateFields{Card: &models.MeetingData{City: &unknown}}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mRep := meeting.NewMockRepository(ctrl)
+		tagRep := tag.NewMockRepository(ctrl)
+		uc := NewMeetingUseCase(mRep, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), tagRep, nil, nil, testLog, "test", "test")
+
+		params := meeting.QueryParams{Tags: []string{"golang"}, City: "Moscow"}
+		meets := []models.Meeting{{Card: &models.MeetingCard{Label: &models.MeetingLabel{Id: 1}}}}
+		facets := models.MeetingFacets{Tags: []models.FacetCount{{Value: "golang", Count: 1}}}
+		mRep.EXPECT().QueryMeetings(params).Return(meets, nil)
+		mRep.EXPECT().QueryFacets(params).Return(facets, nil)
+		res, err := uc.QueryMeetings(params)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MeetingQueryResult{Meetings: meets, Facets: facets}, res)
+
+		mRep.EXPECT().QueryMeetings(params).Return(nil, errors.New("db"))
+		_, err = uc.QueryMeetings(params)
+		assert.Error(t, err)
 	})
 }
@@ -77,6 +77,134 @@ func (mr *MockUseCaseMockRecorder) UpdateMeeting(userId, update interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMeeting", reflect.TypeOf((*MockUseCase)(nil).UpdateMeeting), userId, update)
 }
 
+// CancelMeeting mocks base method
+func (m *MockUseCase) CancelMeeting(userId, meetId int, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelMeeting", userId, meetId, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelMeeting indicates an expected call of CancelMeeting
+func (mr *MockUseCaseMockRecorder) CancelMeeting(userId, meetId, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelMeeting", reflect.TypeOf((*MockUseCase)(nil).CancelMeeting), userId, meetId, reason)
+}
+
+// DeleteMeeting mocks base method
+func (m *MockUseCase) DeleteMeeting(userId, meetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMeeting", userId, meetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMeeting indicates an expected call of DeleteMeeting
+func (mr *MockUseCaseMockRecorder) DeleteMeeting(userId, meetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMeeting", reflect.TypeOf((*MockUseCase)(nil).DeleteMeeting), userId, meetId)
+}
+
+// GetWaitlist mocks base method
+func (m *MockUseCase) GetWaitlist(userId, meetId int) ([]models.WaitlistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWaitlist", userId, meetId)
+	ret0, _ := ret[0].([]models.WaitlistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWaitlist indicates an expected call of GetWaitlist
+func (mr *MockUseCaseMockRecorder) GetWaitlist(userId, meetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWaitlist", reflect.TypeOf((*MockUseCase)(nil).GetWaitlist), userId, meetId)
+}
+
+// MoveInWaitlist mocks base method
+func (m *MockUseCase) MoveInWaitlist(userId int, move models.WaitlistMove) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveInWaitlist", userId, move)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveInWaitlist indicates an expected call of MoveInWaitlist
+func (mr *MockUseCaseMockRecorder) MoveInWaitlist(userId, move interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveInWaitlist", reflect.TypeOf((*MockUseCase)(nil).MoveInWaitlist), userId, move)
+}
+
+// GetRegRequests mocks base method
+func (m *MockUseCase) GetRegRequests(userId, meetId int) ([]*models.ProfileLabel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegRequests", userId, meetId)
+	ret0, _ := ret[0].([]*models.ProfileLabel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegRequests indicates an expected call of GetRegRequests
+func (mr *MockUseCaseMockRecorder) GetRegRequests(userId, meetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegRequests", reflect.TypeOf((*MockUseCase)(nil).GetRegRequests), userId, meetId)
+}
+
+// ApproveReg mocks base method
+func (m *MockUseCase) ApproveReg(userId, meetId, targetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveReg", userId, meetId, targetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApproveReg indicates an expected call of ApproveReg
+func (mr *MockUseCaseMockRecorder) ApproveReg(userId, meetId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveReg", reflect.TypeOf((*MockUseCase)(nil).ApproveReg), userId, meetId, targetId)
+}
+
+// RejectReg mocks base method
+func (m *MockUseCase) RejectReg(userId, meetId, targetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectReg", userId, meetId, targetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectReg indicates an expected call of RejectReg
+func (mr *MockUseCaseMockRecorder) RejectReg(userId, meetId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectReg", reflect.TypeOf((*MockUseCase)(nil).RejectReg), userId, meetId, targetId)
+}
+
+// AddOrganizer mocks base method
+func (m *MockUseCase) AddOrganizer(userId, meetId, targetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrganizer", userId, meetId, targetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrganizer indicates an expected call of AddOrganizer
+func (mr *MockUseCaseMockRecorder) AddOrganizer(userId, meetId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrganizer", reflect.TypeOf((*MockUseCase)(nil).AddOrganizer), userId, meetId, targetId)
+}
+
+// RemoveOrganizer mocks base method
+func (m *MockUseCase) RemoveOrganizer(userId, meetId, targetId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveOrganizer", userId, meetId, targetId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveOrganizer indicates an expected call of RemoveOrganizer
+func (mr *MockUseCaseMockRecorder) RemoveOrganizer(userId, meetId, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOrganizer", reflect.TypeOf((*MockUseCase)(nil).RemoveOrganizer), userId, meetId, targetId)
+}
+
 // GetNextMeetings mocks base method
 func (m *MockUseCase) GetNextMeetings(params FilterParams) ([]models.Meeting, error) {
 	m.ctrl.T.Helper()
@@ -226,3 +354,77 @@ func (mr *MockUseCaseMockRecorder) SearchMeetings(params, meetingName, limit int
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMeetings", reflect.TypeOf((*MockUseCase)(nil).SearchMeetings), params, meetingName, limit)
 }
+
+// FilterNearby mocks base method
+func (m *MockUseCase) FilterNearby(params FilterParams) ([]models.Meeting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterNearby", params)
+	ret0, _ := ret[0].([]models.Meeting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterNearby indicates an expected call of FilterNearby
+func (mr *MockUseCaseMockRecorder) FilterNearby(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterNearby", reflect.TypeOf((*MockUseCase)(nil).FilterNearby), params)
+}
+
+// QueryMeetings mocks base method
+func (m *MockUseCase) QueryMeetings(params QueryParams) (models.MeetingQueryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryMeetings", params)
+	ret0, _ := ret[0].(models.MeetingQueryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryMeetings indicates an expected call of QueryMeetings
+func (mr *MockUseCaseMockRecorder) QueryMeetings(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryMeetings", reflect.TypeOf((*MockUseCase)(nil).QueryMeetings), params)
+}
+
+// GetFeedToken mocks base method
+func (m *MockUseCase) GetFeedToken(userId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedToken", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedToken indicates an expected call of GetFeedToken
+func (mr *MockUseCaseMockRecorder) GetFeedToken(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedToken", reflect.TypeOf((*MockUseCase)(nil).GetFeedToken), userId)
+}
+
+// RevokeFeedToken mocks base method
+func (m *MockUseCase) RevokeFeedToken(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFeedToken", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFeedToken indicates an expected call of RevokeFeedToken
+func (mr *MockUseCaseMockRecorder) RevokeFeedToken(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFeedToken", reflect.TypeOf((*MockUseCase)(nil).RevokeFeedToken), userId)
+}
+
+// GetFeedMeetings mocks base method
+func (m *MockUseCase) GetFeedMeetings(token string) ([]models.Meeting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedMeetings", token)
+	ret0, _ := ret[0].([]models.Meeting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedMeetings indicates an expected call of GetFeedMeetings
+func (mr *MockUseCaseMockRecorder) GetFeedMeetings(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedMeetings", reflect.TypeOf((*MockUseCase)(nil).GetFeedMeetings), token)
+}
@@ -0,0 +1,33 @@
+//go:generate mockgen -source=broker.go -destination=./broker_mock.go -package=message
+package message
+
+import "encoding/json"
+
+// Event is a WebSocket frame addressed to every client in a meeting room,
+// or to every connection of a single user when UserId is set.
+// A Recheck event carries no frame: the user's access to the meeting room has changed,
+// without UserId the meeting itself may be gone.
+type Event struct {
+	MeetId  int    `json:"meetId"`
+	UserId  int    `json:"userId,omitempty"`
+	Recheck bool   `json:"recheck,omitempty"`
+	Data    []byte `json:"data"`
+}
+
+// Frame is the envelope of every event sent over the socket
+type Frame struct {
+	Payload interface{} `json:"payload"`
+	MsgType string      `json:"type"`
+}
+
+func EncodeFrame(msgType string, payload interface{}) ([]byte, error) {
+	return json.Marshal(Frame{Payload: payload, MsgType: msgType})
+}
+
+// Broker fans events out to every server instance
+type Broker interface {
+	Publish(e Event) error
+	// Subscribe starts delivering published events to handler in the background
+	Subscribe(handler func(e Event)) error
+	Close() error
+}
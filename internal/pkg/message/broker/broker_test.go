@@ -0,0 +1,68 @@
+package broker
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"konami_backend/internal/pkg/message"
+	"konami_backend/logger"
+	"testing"
+	"time"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	b := NewMemoryBroker()
+	var got []message.Event
+	require.NoError(t, b.Subscribe(func(e message.Event) {
+		got = append(got, e)
+	}))
+	require.NoError(t, b.Publish(message.Event{MeetId: 1, Data: []byte("hi")}))
+	require.Equal(t, []message.Event{{MeetId: 1, Data: []byte("hi")}}, got)
+
+	require.NoError(t, b.Close())
+	require.NoError(t, b.Publish(message.Event{MeetId: 2}))
+	require.Len(t, got, 1)
+}
+
+func TestRedisBroker(t *testing.T) {
+	srv, err := miniredis.Run()
+	require.NoError(t, err)
+	defer srv.Close()
+
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", srv.Addr())
+		},
+	}
+	log := logger.NewLogger(ioutil.Discard)
+	publisher := NewRedisBroker(pool, ChatChannel, log)
+	subscriber := NewRedisBroker(pool, ChatChannel, log)
+
+	events := make(chan message.Event, 1)
+	require.NoError(t, subscriber.Subscribe(func(e message.Event) {
+		events <- e
+	}))
+	require.NoError(t, publisher.Publish(message.Event{MeetId: 3, Data: []byte(`{"type":"chatMessage"}`)}))
+
+	select {
+	case e := <-events:
+		require.Equal(t, 3, e.MeetId)
+		require.Equal(t, `{"type":"chatMessage"}`, string(e.Data))
+	case <-time.After(time.Second):
+		t.Fatal("event was not delivered")
+	}
+	require.NoError(t, subscriber.Close())
+	require.NoError(t, publisher.Close())
+}
+
+func TestRedisBrokerUnavailable(t *testing.T) {
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", "127.0.0.1:1")
+		},
+	}
+	b := NewRedisBroker(pool, ChatChannel, logger.NewLogger(ioutil.Discard))
+	require.Error(t, b.Subscribe(func(e message.Event) {}))
+	require.Error(t, b.Publish(message.Event{MeetId: 1}))
+}
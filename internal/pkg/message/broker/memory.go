@@ -0,0 +1,39 @@
+package broker
+
+import (
+	"konami_backend/internal/pkg/message"
+	"sync"
+)
+
+// MemoryBroker delivers events within a single process
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers []func(e message.Event)
+}
+
+func NewMemoryBroker() message.Broker {
+	return &MemoryBroker{}
+}
+
+func (b *MemoryBroker) Publish(e message.Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers {
+		handler(e)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(handler func(e message.Event)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = nil
+	return nil
+}
@@ -0,0 +1,153 @@
+package broker
+
+import (
+	"encoding/json"
+	"github.com/gomodule/redigo/redis"
+	"konami_backend/internal/pkg/message"
+	"konami_backend/logger"
+	"sync"
+	"time"
+)
+
+const ChatChannel = "konami:chat"
+
+const resubscribeDelay = time.Second
+
+// RedisBroker relays events through a Redis pub/sub channel,
+// so that every instance delivers them to its local sockets
+type RedisBroker struct {
+	pool    *redis.Pool
+	channel string
+	log     *logger.Logger
+
+	mu     sync.Mutex
+	conn   *redis.PubSubConn
+	closed bool
+}
+
+func NewRedisBroker(pool *redis.Pool, channel string, log *logger.Logger) message.Broker {
+	return &RedisBroker{pool: pool, channel: channel, log: log}
+}
+
+func (b *RedisBroker) Publish(e message.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	conn := b.pool.Get()
+	defer conn.Close()
+	_, err = conn.Do("PUBLISH", b.channel, data)
+	return err
+}
+
+func (b *RedisBroker) subscribe() (*redis.PubSubConn, error) {
+	psc := &redis.PubSubConn{Conn: b.pool.Get()}
+	if err := psc.Subscribe(b.channel); err != nil {
+		_ = psc.Close()
+		return nil, err
+	}
+	// wait for the confirmation so that no event published afterwards is missed
+	switch v := psc.Receive().(type) {
+	case redis.Subscription:
+		return psc, nil
+	case error:
+		_ = psc.Close()
+		return nil, v
+	default:
+		_ = psc.Close()
+		return nil, redis.ErrNil
+	}
+}
+
+func (b *RedisBroker) Subscribe(handler func(e message.Event)) error {
+	psc, err := b.subscribe()
+	if err != nil {
+		return err
+	}
+	if !b.setConn(psc) {
+		return psc.Close()
+	}
+	go b.receive(psc, handler)
+	return nil
+}
+
+// setConn reports false when the broker has already been closed
+func (b *RedisBroker) setConn(psc *redis.PubSubConn) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return false
+	}
+	b.conn = psc
+	return true
+}
+
+func (b *RedisBroker) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+func (b *RedisBroker) receive(psc *redis.PubSubConn, handler func(e message.Event)) {
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			var e message.Event
+			if err := json.Unmarshal(v.Data, &e); err != nil {
+				b.log.LogError("message/broker", "receive", err)
+				continue
+			}
+			handler(e)
+		case redis.Subscription:
+			if v.Count == 0 {
+				b.closeConn(psc)
+				return
+			}
+		case error:
+			b.closeConn(psc)
+			if b.isClosed() {
+				return
+			}
+			b.log.LogError("message/broker", "receive", v)
+			psc = b.resubscribe()
+			if psc == nil {
+				return
+			}
+		}
+	}
+}
+
+// closeConn is serialized with the Unsubscribe call in Close, both write to the connection
+func (b *RedisBroker) closeConn(psc *redis.PubSubConn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_ = psc.Close()
+}
+
+// resubscribe retries until the connection is restored or the broker is closed
+func (b *RedisBroker) resubscribe() *redis.PubSubConn {
+	for !b.isClosed() {
+		time.Sleep(resubscribeDelay)
+		psc, err := b.subscribe()
+		if err != nil {
+			b.log.LogError("message/broker", "resubscribe", err)
+			continue
+		}
+		if !b.setConn(psc) {
+			_ = psc.Close()
+			return nil
+		}
+		return psc
+	}
+	return nil
+}
+
+func (b *RedisBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	if b.conn == nil {
+		return nil
+	}
+	return b.conn.Unsubscribe()
+}
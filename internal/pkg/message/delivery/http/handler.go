@@ -2,35 +2,61 @@ package http
 
 import (
 	"bytes"
+	"errors"
 	"github.com/gorilla/websocket"
+	"konami_backend/internal/pkg/meeting"
 	"konami_backend/internal/pkg/message"
 	"konami_backend/internal/pkg/middleware"
 	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/utils/cursor"
 	hu "konami_backend/internal/pkg/utils/http_utils"
+	"konami_backend/internal/pkg/utils/ratelimit"
 	"konami_backend/logger"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type MessageHandler struct {
 	MessageUC  message.UseCase
 	Log        *logger.Logger
 	MaxReqSize int64
-	clients    map[*websocket.Conn]bool
-	msgChan    chan *models.Message
-	upgrader   websocket.Upgrader
+	Cursors    *cursor.Signer
+	Broker     message.Broker
+	// SendLimiter throttles SendMessage per user
+	SendLimiter *ratelimit.Limiter
+	hub         *Hub
+	upgrader    websocket.Upgrader
 }
 
-func NewMessageHandler(messageUC message.UseCase, log *logger.Logger, maxReqSize int64) MessageHandler {
+const (
+	CursorByTimestamp = "timestamp"
+	DefMessageLimit   = 50
+	MaxMessageLimit   = 100
+	SendBurst         = 10
+	SendWindow        = 10 * time.Second
+)
+
+func NewMessageHandler(messageUC message.UseCase, log *logger.Logger,
+	maxReqSize int64, cursors *cursor.Signer, allowedOrigins []string, broker message.Broker) MessageHandler {
+	origins := make(map[string]bool)
+	for _, o := range allowedOrigins {
+		origins[o] = true
+	}
 	return MessageHandler{
-		MessageUC:  messageUC,
-		Log:        log,
-		MaxReqSize: maxReqSize,
-		clients:    make(map[*websocket.Conn]bool),
-		msgChan:    make(chan *models.Message),
+		MessageUC:   messageUC,
+		Log:         log,
+		MaxReqSize:  maxReqSize,
+		Cursors:     cursors,
+		Broker:      broker,
+		SendLimiter: ratelimit.NewLimiter(SendBurst, SendWindow),
+		hub:         NewHub(),
 		upgrader: websocket.Upgrader{
+			// Non-browser clients send no Origin and are not exposed to cross-site hijacking
 			CheckOrigin: func(r *http.Request) bool {
-				return true
+				origin := r.Header.Get("Origin")
+				return origin == "" || origins[origin]
 			},
 		},
 	}
@@ -47,6 +73,11 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
 		return
 	}
+	if ok, wait := h.SendLimiter.Allow(strconv.Itoa(userId)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusTooManyRequests, ErrMsg: "too many messages"})
+		return
+	}
 	msg := &models.Message{}
 	buf := new(bytes.Buffer)
 	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
@@ -58,58 +89,399 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	msg.AuthorId = userId
-	_, err = h.MessageUC.CreateMessage(*msg)
+	saved, err := h.MessageUC.CreateMessage(*msg)
 	if err != nil {
-		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		h.writeChangeError(w, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	go h.PublishMsg(msg)
+	hu.WriteJson(w, saved)
+	go h.PublishMsg(&saved)
 }
 
 func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
 	mId, err := strconv.Atoi(r.URL.Query().Get("meetId"))
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
 		return
 	}
-	messages, err := h.MessageUC.GetMessages(mId)
+	// The history is as private as the live room
+	member, err := h.MessageUC.IsMember(mId, userId)
+	if errors.Is(err, meeting.ErrMeetingNotFound) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+		return
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	if !member {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusForbidden, ErrMsg: "only participants can read the chat"})
+		return
+	}
+	params := message.FilterParams{MeetingId: mId}
+	params.Before, _ = strconv.Atoi(r.URL.Query().Get("before"))
+	params.After, _ = strconv.Atoi(r.URL.Query().Get("after"))
+	if params.Before > 0 || params.After > 0 {
+		h.getHistoryWindow(w, r, params)
+		return
+	}
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		c, err := h.Cursors.Decode(token, CursorByTimestamp)
+		if err != nil {
+			hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+			return
+		}
+		params.PrevId, params.PrevTimestamp = c.Id, c.Start
+	}
+	params.CountLimit = messageLimit(r) + 1
+	messages, err := h.MessageUC.GetMessages(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, messages)
+	page := h.Page(messages, params)
+	if !hu.PageRequested(r) {
+		hu.WriteJson(w, page.Messages)
+		return
+	}
+	hu.WriteJson(w, page)
+}
+
+// messageLimit reads the page size, the history is never returned in one response
+func messageLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = DefMessageLimit
+	}
+	if limit > MaxMessageLimit {
+		limit = MaxMessageLimit
+	}
+	return limit
+}
+
+// getHistoryWindow serves the messages right before or after the given message ids
+func (h *MessageHandler) getHistoryWindow(w http.ResponseWriter, r *http.Request, params message.FilterParams) {
+	limit := messageLimit(r)
+	params.CountLimit = limit + 1
+	messages, err := h.MessageUC.GetMessages(params)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	page := models.MessagePage{Messages: messages}
+	switch {
+	case len(messages) <= limit:
+	case params.After == 0:
+		// Older messages come first, so the extra one is at the head
+		page.Messages, page.HasMore = messages[1:], true
+	default:
+		page.Messages, page.HasMore = messages[:limit], true
+	}
+	if page.Messages == nil {
+		page.Messages = []models.Message{}
+	}
+	hu.WriteJson(w, page)
+}
+
+func (h *MessageHandler) Page(messages []models.Message, params message.FilterParams) models.MessagePage {
+	page := models.MessagePage{Messages: messages}
+	if page.Messages == nil {
+		page.Messages = []models.Message{}
+	}
+	if params.CountLimit == 0 || len(messages) < params.CountLimit {
+		return page
+	}
+	page.Messages = messages[:params.CountLimit-1]
+	page.HasMore = true
+	last := page.Messages[len(page.Messages)-1]
+	c := cursor.Cursor{Kind: CursorByTimestamp, Id: last.Id}
+	c.Start, _ = time.Parse("2006-01-02T15:04:05.000Z0700", last.Timestamp)
+	page.NextCursor = h.Cursors.Encode(c)
+	return page
+}
+
+func (h *MessageHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	edit := &models.MessageEdit{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = edit.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	msg, err := h.MessageUC.EditMessage(userId, *edit)
+	if err != nil {
+		h.writeChangeError(w, err)
+		return
+	}
+	hu.WriteJson(w, msg)
+	go h.Publish(msg.MeetingId, "messageEdited", msg)
+}
+
+func (h *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	msgId, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	msg, err := h.MessageUC.DeleteMessage(userId, msgId)
+	if err != nil {
+		h.writeChangeError(w, err)
+		return
+	}
+	hu.WriteJson(w, msg)
+	go h.Publish(msg.MeetingId, "messageDeleted", msg)
+}
+
+func (h *MessageHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	h.changeReaction(w, r, h.MessageUC.AddReaction, "reactionAdded")
+}
+
+func (h *MessageHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	h.changeReaction(w, r, h.MessageUC.RemoveReaction, "reactionRemoved")
+}
+
+func (h *MessageHandler) changeReaction(w http.ResponseWriter, r *http.Request,
+	change func(userId int, msgId int, emoji string) (models.MessageReaction, error), msgType string) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	req := &models.MessageReaction{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = req.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	reaction, err := change(userId, req.MessageId, req.Emoji)
+	if err != nil {
+		h.writeChangeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	go h.Publish(reaction.MeetId, msgType, reaction)
+}
+
+func (h *MessageHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	receipt := &models.ReadReceipt{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = receipt.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	receipt.UserId = userId
+	err = h.MessageUC.MarkRead(*receipt)
+	if err != nil {
+		h.writeChangeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	go h.Publish(receipt.MeetId, "messageRead", receipt)
+}
+
+func (h *MessageHandler) GetUnreadCounts(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	counts, err := h.MessageUC.GetUnreadCounts(userId)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	hu.WriteJson(w, counts)
+}
+
+func (h *MessageHandler) writeChangeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, message.ErrMessageNotFound), errors.Is(err, meeting.ErrMeetingNotFound):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+	case errors.Is(err, message.ErrAccessDenied):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusForbidden, ErrMsg: err.Error()})
+	case errors.Is(err, message.ErrInvalidReaction), errors.Is(err, message.ErrEmptyMessage),
+		errors.Is(err, message.ErrMessageTooLong):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+	case errors.Is(err, message.ErrMessageDeleted):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusConflict, ErrMsg: err.Error()})
+	default:
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+	}
 }
 
 func (h *MessageHandler) Upgrade(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
 	ws, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		h.Log.LogError("message/delivery/http", "Upgrade", err)
-	} else {
-		h.clients[ws] = true
+		return
+	}
+	c := newClient(h.hub, ws, userId)
+	h.hub.register <- c
+	go c.writePump()
+	go c.readPump(h.handleCommand)
+}
+
+func (h *MessageHandler) handleCommand(c *Client, data []byte) {
+	cmd := models.RoomCommand{}
+	if err := cmd.UnmarshalJSON(data); err != nil {
+		c.reply("error", roomStatus{Error: "invalid command"})
+		return
+	}
+	switch cmd.Type {
+	case models.RoomSubscribe:
+		member, err := h.MessageUC.IsMember(cmd.MeetId, c.userId)
+		if err != nil || !member {
+			c.reply("error", roomStatus{MeetId: cmd.MeetId, Error: "access denied"})
+			return
+		}
+		h.hub.join <- membership{client: c, meetId: cmd.MeetId}
+		c.setJoined(cmd.MeetId, true)
+		c.reply("subscribed", roomStatus{MeetId: cmd.MeetId})
+	case models.RoomUnsubscribe:
+		h.hub.leave <- membership{client: c, meetId: cmd.MeetId}
+		c.setJoined(cmd.MeetId, false)
+		c.reply("unsubscribed", roomStatus{MeetId: cmd.MeetId})
+	case models.RoomTyping:
+		if !c.isJoined(cmd.MeetId) {
+			c.reply("error", roomStatus{MeetId: cmd.MeetId, Error: "not subscribed"})
+			return
+		}
+		h.Publish(cmd.MeetId, "typing", models.Typing{MeetId: cmd.MeetId, UserId: c.userId})
+	case models.RoomRead:
+		receipt := models.ReadReceipt{MeetId: cmd.MeetId, UserId: c.userId, MessageId: cmd.MessageId}
+		if err := h.MessageUC.MarkRead(receipt); err != nil {
+			c.reply("error", roomStatus{MeetId: cmd.MeetId, Error: err.Error()})
+			return
+		}
+		h.Publish(cmd.MeetId, "messageRead", receipt)
+	default:
+		c.reply("error", roomStatus{MeetId: cmd.MeetId, Error: "unknown command"})
+	}
+}
+
+// Publish sends an event to the meeting room on every server instance
+func (h *MessageHandler) Publish(meetId int, msgType string, payload interface{}) {
+	data, err := message.EncodeFrame(msgType, payload)
+	if err == nil {
+		err = h.Broker.Publish(message.Event{MeetId: meetId, Data: data})
+	}
+	if err != nil {
+		h.Log.LogError("message/delivery/http", "Publish", err)
+	}
+}
+
+// recheckMember drops the user's connections from a meeting room they no longer belong to
+func (h *MessageHandler) recheckMember(meetId int, userId int) {
+	member, err := h.MessageUC.IsMember(meetId, userId)
+	if err == nil && member {
+		return
+	}
+	if err != nil && !errors.Is(err, meeting.ErrMeetingNotFound) {
+		h.Log.LogError("message/delivery/http", "recheckMember", err)
+	}
+	data, err := message.EncodeFrame("unsubscribed", roomStatus{MeetId: meetId, Error: "access revoked"})
+	if err != nil {
+		return
 	}
+	h.hub.Evict(meetId, userId, data)
+}
+
+// recheckRoom closes a meeting room once the meeting is deleted
+func (h *MessageHandler) recheckRoom(meetId int) {
+	_, err := h.MessageUC.IsMember(meetId, 0)
+	if !errors.Is(err, meeting.ErrMeetingNotFound) {
+		if err != nil {
+			h.Log.LogError("message/delivery/http", "recheckRoom", err)
+		}
+		return
+	}
+	data, err := message.EncodeFrame("unsubscribed", roomStatus{MeetId: meetId, Error: "meeting deleted"})
+	if err != nil {
+		return
+	}
+	h.hub.Evict(meetId, 0, data)
 }
 
 func (h *MessageHandler) PublishMsg(msg *models.Message) {
-	h.msgChan <- msg
+	h.Publish(msg.MeetingId, "chatMessage", msg)
 }
 
 func (h *MessageHandler) ServeWS() {
-	for {
-		msg := <-h.msgChan
-		resp := struct {
-			Payload *models.Message `json:"payload"`
-			MsgType string          `json:"type"`
-		}{Payload: msg, MsgType: "chatMessage"}
-		for client := range h.clients {
-			err := client.WriteJSON(resp)
-			if err != nil {
-				err = client.Close()
-				delete(h.clients, client)
-				if err != nil {
-					h.Log.LogError("message/delivery/http", "ServeWS", err)
-				}
-			}
+	err := h.Broker.Subscribe(func(e message.Event) {
+		if e.Recheck && e.UserId == 0 {
+			go h.recheckRoom(e.MeetId)
+			return
+		}
+		if e.Recheck {
+			go h.recheckMember(e.MeetId, e.UserId)
+			return
 		}
+		if e.UserId != 0 {
+			h.hub.SendToUser(e.UserId, e.Data)
+			return
+		}
+		h.hub.Broadcast(e.MeetId, e.Data)
+	})
+	if err != nil {
+		h.Log.LogError("message/delivery/http", "ServeWS", err)
 	}
+	h.hub.Run()
 }
@@ -4,18 +4,31 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
 	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
+	"io/ioutil"
+	"konami_backend/internal/pkg/meeting"
 	"konami_backend/internal/pkg/message"
+	"konami_backend/internal/pkg/message/broker"
 	messageRepoPkg "konami_backend/internal/pkg/message/repository"
 	messageUseCasePkg "konami_backend/internal/pkg/message/usecase"
 	"konami_backend/internal/pkg/middleware"
 	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/utils/cursor"
+	"konami_backend/internal/pkg/utils/ratelimit"
+	"konami_backend/logger"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
-var testHandler MessageHandler
+var testHandler = NewMessageHandler(nil, nil, 0, nil, nil, broker.NewMemoryBroker())
+
+var testReader = []middleware.RouteArgs{{Key: middleware.UserID, Value: 4}}
 
 func TestSessions(t *testing.T) {
 	t.Run("SendMes", func(t *testing.T) {
@@ -42,7 +55,7 @@ func TestSessions(t *testing.T) {
 
 		testHandler.MaxReqSize = 10000
 
-		m.EXPECT().CreateMessage(*msg).Return(0, nil)
+		m.EXPECT().CreateMessage(*msg).Return(*msg, nil)
 
 		apitest.New("Get-All-Ok").
 			Handler(handler).
@@ -78,7 +91,7 @@ func TestSessions(t *testing.T) {
 
 		testHandler.MaxReqSize = 10000
 
-		m.EXPECT().CreateMessage(*msg).Return(0, errors.New("err"))
+		m.EXPECT().CreateMessage(*msg).Return(models.Message{}, errors.New("err"))
 
 		apitest.New("Get-All-Ok").
 			Handler(handler).
@@ -167,22 +180,25 @@ func TestSessions(t *testing.T) {
 	t.Run("SendMesBad3", func(t *testing.T) {
 		db := &gorm.DB{}
 		msgRepo := messageRepoPkg.NewMeetingGormRepo(db)
-		msgUC := messageUseCasePkg.NewMessageUseCase(msgRepo)
-		_ = NewMessageHandler(msgUC, nil, 0)
+		msgUC := messageUseCasePkg.NewMessageUseCase(msgRepo, nil, nil)
+		_ = NewMessageHandler(msgUC, nil, 0, nil, nil, nil)
 	})
 
 	t.Run("GetMessage", func(t *testing.T) {
 		var args []middleware.QueryArgs
 		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "4"})
-		handler := middleware.SetVars(testHandler.GetMessages, args)
+		handler := middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)
 
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		m := message.NewMockUseCase(ctrl)
 		testHandler.MessageUC = m
+		m.EXPECT().IsMember(4, 4).Return(true, nil).AnyTimes()
 
-		m.EXPECT().GetMessages(4).Return([]models.Message{}, nil)
+		// the history is paged even when the client asks for no limit
+		m.EXPECT().GetMessages(message.FilterParams{MeetingId: 4, CountLimit: DefMessageLimit + 1}).
+			Return([]models.Message{}, nil)
 		apitest.New("Get-All-Ok").
 			Handler(handler).
 			Method("Get").
@@ -190,20 +206,33 @@ func TestSessions(t *testing.T) {
 			Expect(t).
 			Status(http.StatusOK).
 			End()
+
+		args = append(args, middleware.QueryArgs{Key: "limit", Value: "1000"})
+		m.EXPECT().GetMessages(message.FilterParams{MeetingId: 4, CountLimit: MaxMessageLimit + 1}).
+			Return([]models.Message{}, nil)
+		apitest.New("Get-Limit-Capped").
+			Handler(middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)).
+			Method("Get").
+			URL("/people").
+			Expect(t).
+			Status(http.StatusOK).
+			End()
 	})
 
 	t.Run("GetMessageBad1", func(t *testing.T) {
 		var args []middleware.QueryArgs
 		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "4"})
-		handler := middleware.SetVars(testHandler.GetMessages, args)
+		handler := middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)
 
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		m := message.NewMockUseCase(ctrl)
 		testHandler.MessageUC = m
+		m.EXPECT().IsMember(4, 4).Return(true, nil).AnyTimes()
 
-		m.EXPECT().GetMessages(4).Return([]models.Message{}, errors.New("Err"))
+		m.EXPECT().GetMessages(message.FilterParams{MeetingId: 4, CountLimit: DefMessageLimit + 1}).
+			Return([]models.Message{}, errors.New("Err"))
 		apitest.New("Get-All-Ok").
 			Handler(handler).
 			Method("Get").
@@ -215,13 +244,14 @@ func TestSessions(t *testing.T) {
 
 	t.Run("GetMessageBad1", func(t *testing.T) {
 		var args []middleware.QueryArgs
-		handler := middleware.SetVars(testHandler.GetMessages, args)
+		handler := middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)
 
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		m := message.NewMockUseCase(ctrl)
 		testHandler.MessageUC = m
+		m.EXPECT().IsMember(4, 4).Return(true, nil).AnyTimes()
 
 		apitest.New("Get-All-Ok").
 			Handler(handler).
@@ -231,4 +261,655 @@ func TestSessions(t *testing.T) {
 			Status(http.StatusBadRequest).
 			End()
 	})
+
+	t.Run("GetMessagesAccess", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "4"})
+		args = append(args, middleware.QueryArgs{Key: "before", Value: "9"})
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		testHandler.MessageUC = m
+
+		apitest.New("GetMessagesAnonymous").
+			Handler(middleware.SetVars(testHandler.GetMessages, args)).
+			Method("Get").
+			URL("/messages").
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+
+		handler := middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)
+		for _, c := range []struct {
+			member bool
+			err    error
+			status int
+		}{
+			{false, nil, http.StatusForbidden},
+			{false, meeting.ErrMeetingNotFound, http.StatusNotFound},
+			{false, errors.New("err"), http.StatusInternalServerError},
+		} {
+			m.EXPECT().IsMember(4, 4).Return(c.member, c.err)
+			apitest.New("GetMessagesAccess").
+				Handler(handler).
+				Method("Get").
+				URL("/messages").
+				Expect(t).
+				Status(c.status).
+				End()
+		}
+	})
+
+	t.Run("GetMessagesCursor", func(t *testing.T) {
+		testHandler.Cursors = cursor.NewSigner([]byte("test"))
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "4"})
+		args = append(args, middleware.QueryArgs{Key: "limit", Value: "1"})
+		args = append(args, middleware.QueryArgs{Key: "cursor", Value: ""})
+		handler := middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		testHandler.MessageUC = m
+		m.EXPECT().IsMember(4, 4).Return(true, nil).AnyTimes()
+
+		testMsgs := []models.Message{
+			{Id: 3, MeetingId: 4, Timestamp: "2020-12-05T10:00:00.000Z"},
+			{Id: 5, MeetingId: 4, Timestamp: "2020-12-05T10:00:00.000Z"},
+		}
+		m.EXPECT().GetMessages(message.FilterParams{MeetingId: 4, CountLimit: 2}).Return(testMsgs, nil)
+
+		var page models.MessagePage
+		apitest.New("GetMessagesCursor").
+			Handler(handler).
+			Method("Get").
+			URL("/messages").
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(&page)
+		if !page.HasMore || len(page.Messages) != 1 {
+			t.Fatalf("unexpected page: %+v", page)
+		}
+
+		args[2].Value = page.NextCursor
+		handler = middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)
+		m.EXPECT().GetMessages(message.FilterParams{MeetingId: 4, PrevId: 3, CountLimit: 2,
+			PrevTimestamp: time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)}).Return(testMsgs[1:], nil)
+		testPageJSON, _ := json.Marshal(models.MessagePage{Messages: testMsgs[1:]})
+
+		apitest.New("GetMessagesCursor").
+			Handler(handler).
+			Method("Get").
+			URL("/messages").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testPageJSON)).
+			End()
+
+		// legacy clients keep getting a bare array
+		handler = middleware.SetVarsAndMux(testHandler.GetMessages, args[:2], testReader)
+		m.EXPECT().GetMessages(message.FilterParams{MeetingId: 4, CountLimit: 2}).Return(testMsgs, nil)
+		testJSON, _ := json.Marshal(testMsgs[:1])
+
+		apitest.New("GetMessagesLegacy").
+			Handler(handler).
+			Method("Get").
+			URL("/messages").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testJSON)).
+			End()
+	})
+
+	t.Run("RoomBroadcast", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		h := NewMessageHandler(m, logger.NewLogger(ioutil.Discard), 0, nil,
+			[]string{"http://onmeet.ru:3000"}, broker.NewMemoryBroker())
+		go h.ServeWS()
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		srv := httptest.NewServer(middleware.SetMuxVars(h.Upgrade, args))
+		defer srv.Close()
+		url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+		_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://evil.com"}})
+		require.Error(t, err)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		member, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://onmeet.ru:3000"}})
+		require.NoError(t, err)
+		defer member.Close()
+		outsider, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer outsider.Close()
+
+		m.EXPECT().IsMember(1, 4).Return(true, nil)
+		m.EXPECT().IsMember(2, 4).Return(false, nil)
+
+		var event struct {
+			Payload map[string]interface{} `json:"payload"`
+			MsgType string                 `json:"type"`
+		}
+		require.NoError(t, member.WriteJSON(models.RoomCommand{Type: models.RoomSubscribe, MeetId: 1}))
+		require.NoError(t, member.ReadJSON(&event))
+		require.Equal(t, "subscribed", event.MsgType)
+
+		require.NoError(t, outsider.WriteJSON(models.RoomCommand{Type: models.RoomSubscribe, MeetId: 2}))
+		require.NoError(t, outsider.ReadJSON(&event))
+		require.Equal(t, "error", event.MsgType)
+
+		h.PublishMsg(&models.Message{Id: 7, MeetingId: 1, Text: "hello"})
+		require.NoError(t, member.ReadJSON(&event))
+		require.Equal(t, "chatMessage", event.MsgType)
+		require.Equal(t, "hello", event.Payload["text"])
+
+		// the outsider is not subscribed to the room, so nothing arrives
+		require.NoError(t, outsider.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+		_, _, err = outsider.ReadMessage()
+		require.Error(t, err)
+	})
+
+	t.Run("UpgradeUnauthorized", func(t *testing.T) {
+		handler := middleware.SetMuxVars(testHandler.Upgrade, nil)
+
+		apitest.New("UpgradeUnauthorized").
+			Handler(handler).
+			Method("Get").
+			URL("/ws").
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("HubDropsSlowConsumer", func(t *testing.T) {
+		hub := NewHub()
+		go hub.Run()
+
+		c := newClient(hub, nil, 4)
+		c.send = make(chan []byte, 1)
+		hub.register <- c
+		hub.join <- membership{client: c, meetId: 1}
+		hub.Broadcast(1, []byte("1"))
+		hub.Broadcast(1, []byte("2"))
+		hub.Broadcast(2, []byte("3"))
+
+		_, ok := <-c.send
+		require.True(t, ok)
+		select {
+		case _, ok = <-c.send:
+			require.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("slow client was not disconnected")
+		}
+	})
+
+	t.Run("MemberEvicted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		shared := broker.NewMemoryBroker()
+		h := NewMessageHandler(m, logger.NewLogger(ioutil.Discard), 0, nil, nil, shared)
+		go h.ServeWS()
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		srv := httptest.NewServer(middleware.SetMuxVars(h.Upgrade, args))
+		defer srv.Close()
+
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		var event struct {
+			Payload map[string]interface{} `json:"payload"`
+			MsgType string                 `json:"type"`
+		}
+		m.EXPECT().IsMember(1, 4).Return(true, nil)
+		require.NoError(t, ws.WriteJSON(models.RoomCommand{Type: models.RoomSubscribe, MeetId: 1}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "subscribed", event.MsgType)
+
+		// the user unregistered on another instance
+		m.EXPECT().IsMember(1, 4).Return(false, nil)
+		require.NoError(t, shared.Publish(message.Event{MeetId: 1, UserId: 4, Recheck: true}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "unsubscribed", event.MsgType)
+
+		require.NoError(t, ws.WriteJSON(models.RoomCommand{Type: models.RoomTyping, MeetId: 1}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "error", event.MsgType)
+
+		h.PublishMsg(&models.Message{Id: 7, MeetingId: 1, Text: "hello"})
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+		_, _, err = ws.ReadMessage()
+		require.Error(t, err)
+	})
+
+	t.Run("RoomClosed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		shared := broker.NewMemoryBroker()
+		h := NewMessageHandler(m, logger.NewLogger(ioutil.Discard), 0, nil, nil, shared)
+		go h.ServeWS()
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		srv := httptest.NewServer(middleware.SetMuxVars(h.Upgrade, args))
+		defer srv.Close()
+
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		var event struct {
+			Payload map[string]interface{} `json:"payload"`
+			MsgType string                 `json:"type"`
+		}
+		m.EXPECT().IsMember(1, 4).Return(true, nil)
+		require.NoError(t, ws.WriteJSON(models.RoomCommand{Type: models.RoomSubscribe, MeetId: 1}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "subscribed", event.MsgType)
+
+		// the meeting was deleted on another instance
+		m.EXPECT().IsMember(1, 0).Return(false, meeting.ErrMeetingNotFound)
+		require.NoError(t, shared.Publish(message.Event{MeetId: 1, Recheck: true}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "unsubscribed", event.MsgType)
+		require.Equal(t, "meeting deleted", event.Payload["error"])
+
+		h.PublishMsg(&models.Message{Id: 7, MeetingId: 1, Text: "hello"})
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+		_, _, err = ws.ReadMessage()
+		require.Error(t, err)
+	})
+
+	t.Run("CrossInstanceDelivery", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		shared := broker.NewMemoryBroker()
+		log := logger.NewLogger(ioutil.Discard)
+		first := NewMessageHandler(m, log, 0, nil, nil, shared)
+		second := NewMessageHandler(m, log, 0, nil, nil, shared)
+		go first.ServeWS()
+		go second.ServeWS()
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		srv := httptest.NewServer(middleware.SetMuxVars(second.Upgrade, args))
+		defer srv.Close()
+
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		m.EXPECT().IsMember(1, 4).Return(true, nil)
+		var event struct {
+			Payload map[string]interface{} `json:"payload"`
+			MsgType string                 `json:"type"`
+		}
+		require.NoError(t, ws.WriteJSON(models.RoomCommand{Type: models.RoomSubscribe, MeetId: 1}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "subscribed", event.MsgType)
+
+		first.PublishMsg(&models.Message{Id: 9, MeetingId: 1, Text: "from another instance"})
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "chatMessage", event.MsgType)
+		require.Equal(t, "from another instance", event.Payload["text"])
+	})
+
+	t.Run("GetMessagesBefore", func(t *testing.T) {
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "meetId", Value: "4"})
+		args = append(args, middleware.QueryArgs{Key: "before", Value: "9"})
+		args = append(args, middleware.QueryArgs{Key: "limit", Value: "2"})
+		handler := middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		testHandler.MessageUC = m
+		m.EXPECT().IsMember(4, 4).Return(true, nil).AnyTimes()
+
+		testMsgs := []models.Message{{Id: 5, MeetingId: 4}, {Id: 6, MeetingId: 4}, {Id: 8, MeetingId: 4}}
+		m.EXPECT().GetMessages(message.FilterParams{MeetingId: 4, Before: 9, CountLimit: 3}).Return(testMsgs, nil)
+		testPageJSON, _ := json.Marshal(models.MessagePage{Messages: testMsgs[1:], HasMore: true})
+
+		apitest.New("GetMessagesBefore").
+			Handler(handler).
+			Method("Get").
+			URL("/messages").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testPageJSON)).
+			End()
+
+		args = args[:1]
+		args = append(args, middleware.QueryArgs{Key: "after", Value: "5"})
+		args = append(args, middleware.QueryArgs{Key: "limit", Value: "1000"})
+		handler = middleware.SetVarsAndMux(testHandler.GetMessages, args, testReader)
+		m.EXPECT().GetMessages(message.FilterParams{MeetingId: 4, After: 5, CountLimit: MaxMessageLimit + 1}).
+			Return(testMsgs[1:], nil)
+		testPageJSON, _ = json.Marshal(models.MessagePage{Messages: testMsgs[1:]})
+
+		apitest.New("GetMessagesAfter").
+			Handler(handler).
+			Method("Get").
+			URL("/messages").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testPageJSON)).
+			End()
+	})
+
+	t.Run("EditDeleteMessage", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		shared := broker.NewMemoryBroker()
+		h := NewMessageHandler(m, logger.NewLogger(ioutil.Discard), 10000, nil, nil, shared)
+		events := make(chan message.Event, 2)
+		require.NoError(t, shared.Subscribe(func(e message.Event) { events <- e }))
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+		edited := models.Message{Id: 3, AuthorId: 4, MeetingId: 1, Text: "fixed", Edited: true}
+		m.EXPECT().EditMessage(4, models.MessageEdit{Id: 3, Text: "fixed"}).Return(edited, nil)
+		editedJSON, _ := json.Marshal(edited)
+
+		apitest.New("EditMessage").
+			Handler(middleware.SetMuxVars(h.EditMessage, args)).
+			Method("Patch").
+			URL("/message").
+			Body(`{"id": 3, "text": "fixed"}`).
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(editedJSON)).
+			End()
+
+		m.EXPECT().EditMessage(4, models.MessageEdit{Id: 5, Text: "x"}).Return(models.Message{}, message.ErrAccessDenied)
+		apitest.New("EditForeignMessage").
+			Handler(middleware.SetMuxVars(h.EditMessage, args)).
+			Method("Patch").
+			URL("/message").
+			Body(`{"id": 5, "text": "x"}`).
+			Expect(t).
+			Status(http.StatusForbidden).
+			End()
+
+		var event struct {
+			Payload models.Message `json:"payload"`
+			MsgType string         `json:"type"`
+		}
+		select {
+		case e := <-events:
+			require.Equal(t, 1, e.MeetId)
+			require.NoError(t, json.Unmarshal(e.Data, &event))
+			require.Equal(t, "messageEdited", event.MsgType)
+			require.True(t, event.Payload.Edited)
+		case <-time.After(time.Second):
+			t.Fatal("edit event was not published")
+		}
+
+		m.EXPECT().DeleteMessage(4, 3).Return(models.Message{Id: 3, AuthorId: 4, MeetingId: 1, Deleted: true}, nil)
+		apitest.New("DeleteMessage").
+			Handler(middleware.SetVarsAndMux(h.DeleteMessage,
+				[]middleware.QueryArgs{{Key: "id", Value: "3"}}, args)).
+			Method("Delete").
+			URL("/message").
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		select {
+		case e := <-events:
+			require.NoError(t, json.Unmarshal(e.Data, &event))
+			require.Equal(t, "messageDeleted", event.MsgType)
+			require.True(t, event.Payload.Deleted)
+		case <-time.After(time.Second):
+			t.Fatal("delete event was not published")
+		}
+
+		m.EXPECT().DeleteMessage(4, 7).Return(models.Message{}, message.ErrMessageNotFound)
+		apitest.New("DeleteMissingMessage").
+			Handler(middleware.SetVarsAndMux(h.DeleteMessage,
+				[]middleware.QueryArgs{{Key: "id", Value: "7"}}, args)).
+			Method("Delete").
+			URL("/message").
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+	})
+
+	t.Run("Reactions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		shared := broker.NewMemoryBroker()
+		h := NewMessageHandler(m, logger.NewLogger(ioutil.Discard), 10000, nil, nil, shared)
+		events := make(chan message.Event, 1)
+		require.NoError(t, shared.Subscribe(func(e message.Event) { events <- e }))
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+		reaction := models.MessageReaction{MessageId: 3, MeetId: 1, UserId: 4, Emoji: "👍"}
+		m.EXPECT().AddReaction(4, 3, "👍").Return(reaction, nil)
+		apitest.New("AddReaction").
+			Handler(middleware.SetMuxVars(h.AddReaction, args)).
+			Method("Post").
+			URL("/message/reactions").
+			Body(`{"messageId": 3, "emoji": "👍"}`).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		var event struct {
+			Payload models.MessageReaction `json:"payload"`
+			MsgType string                 `json:"type"`
+		}
+		select {
+		case e := <-events:
+			require.NoError(t, json.Unmarshal(e.Data, &event))
+			require.Equal(t, "reactionAdded", event.MsgType)
+			require.Equal(t, reaction, event.Payload)
+		case <-time.After(time.Second):
+			t.Fatal("reaction event was not published")
+		}
+
+		m.EXPECT().RemoveReaction(4, 3, "a").Return(models.MessageReaction{}, message.ErrInvalidReaction)
+		apitest.New("RemoveInvalidReaction").
+			Handler(middleware.SetMuxVars(h.RemoveReaction, args)).
+			Method("Delete").
+			URL("/message/reactions").
+			Body(`{"messageId": 3, "emoji": "a"}`).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		apitest.New("ReactionNoCSRF").
+			Handler(middleware.SetMuxVars(h.AddReaction, args[:1])).
+			Method("Post").
+			URL("/message/reactions").
+			Body(`{"messageId": 3, "emoji": "👍"}`).
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("HubSendsToUser", func(t *testing.T) {
+		hub := NewHub()
+		go hub.Run()
+
+		first := newClient(hub, nil, 4)
+		second := newClient(hub, nil, 4)
+		other := newClient(hub, nil, 5)
+		hub.register <- first
+		hub.register <- second
+		hub.register <- other
+		hub.SendToUser(4, []byte("dm"))
+		hub.Broadcast(1, []byte("room"))
+
+		require.Equal(t, []byte("dm"), <-first.send)
+		require.Equal(t, []byte("dm"), <-second.send)
+		select {
+		case <-other.send:
+			t.Fatal("direct event leaked to another user")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		hub.unregister <- first
+		hub.SendToUser(4, []byte("again"))
+		require.Equal(t, []byte("again"), <-second.send)
+	})
+
+	t.Run("TypingAndReadEvents", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		h := NewMessageHandler(m, logger.NewLogger(ioutil.Discard), 0, nil, nil, broker.NewMemoryBroker())
+		go h.ServeWS()
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		srv := httptest.NewServer(middleware.SetMuxVars(h.Upgrade, args))
+		defer srv.Close()
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		var event struct {
+			Payload map[string]interface{} `json:"payload"`
+			MsgType string                 `json:"type"`
+		}
+		require.NoError(t, ws.WriteJSON(models.RoomCommand{Type: models.RoomTyping, MeetId: 1}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "error", event.MsgType)
+
+		m.EXPECT().IsMember(1, 4).Return(true, nil)
+		require.NoError(t, ws.WriteJSON(models.RoomCommand{Type: models.RoomSubscribe, MeetId: 1}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "subscribed", event.MsgType)
+
+		require.NoError(t, ws.WriteJSON(models.RoomCommand{Type: models.RoomTyping, MeetId: 1}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "typing", event.MsgType)
+		require.Equal(t, float64(4), event.Payload["userId"])
+
+		m.EXPECT().MarkRead(models.ReadReceipt{MeetId: 1, UserId: 4, MessageId: 9}).Return(nil)
+		require.NoError(t, ws.WriteJSON(models.RoomCommand{Type: models.RoomRead, MeetId: 1, MessageId: 9}))
+		require.NoError(t, ws.ReadJSON(&event))
+		require.Equal(t, "messageRead", event.MsgType)
+		require.Equal(t, float64(9), event.Payload["messageId"])
+	})
+
+	t.Run("MarkReadAndUnread", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		h := NewMessageHandler(m, logger.NewLogger(ioutil.Discard), 10000, nil, nil, broker.NewMemoryBroker())
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+		m.EXPECT().MarkRead(models.ReadReceipt{MeetId: 1, UserId: 4, MessageId: 9}).Return(nil)
+		apitest.New("MarkRead").
+			Handler(middleware.SetMuxVars(h.MarkRead, args)).
+			Method("Post").
+			URL("/messages/read").
+			Body(`{"meetId": 1, "messageId": 9}`).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		m.EXPECT().MarkRead(models.ReadReceipt{MeetId: 2, UserId: 4, MessageId: 9}).Return(message.ErrAccessDenied)
+		apitest.New("MarkReadForeign").
+			Handler(middleware.SetMuxVars(h.MarkRead, args)).
+			Method("Post").
+			URL("/messages/read").
+			Body(`{"meetId": 2, "messageId": 9}`).
+			Expect(t).
+			Status(http.StatusForbidden).
+			End()
+
+		counts := []models.UnreadCount{{MeetId: 1, Unread: 2, LastReadId: 9}}
+		m.EXPECT().GetUnreadCounts(4).Return(counts, nil)
+		countsJSON, _ := json.Marshal(counts)
+		apitest.New("GetUnreadCounts").
+			Handler(middleware.SetMuxVars(h.GetUnreadCounts, args)).
+			Method("Get").
+			URL("/messages/unread").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(countsJSON)).
+			End()
+	})
+
+	t.Run("SendMessageValidation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := message.NewMockUseCase(ctrl)
+		h := NewMessageHandler(m, logger.NewLogger(ioutil.Discard), 10000, nil, nil, broker.NewMemoryBroker())
+		h.SendLimiter = ratelimit.NewLimiter(2, time.Minute)
+
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+		handler := middleware.SetMuxVars(h.SendMessage, args)
+
+		m.EXPECT().CreateMessage(models.Message{AuthorId: 4, MeetingId: 2, Text: "hi"}).
+			Return(models.Message{}, message.ErrAccessDenied)
+		apitest.New("SendToForeignMeeting").
+			Handler(handler).
+			Method("Post").
+			URL("/message").
+			Body(`{"meetId": 2, "text": "hi"}`).
+			Expect(t).
+			Status(http.StatusForbidden).
+			End()
+
+		m.EXPECT().CreateMessage(models.Message{AuthorId: 4, MeetingId: 1, Text: ""}).
+			Return(models.Message{}, message.ErrEmptyMessage)
+		apitest.New("SendEmpty").
+			Handler(handler).
+			Method("Post").
+			URL("/message").
+			Body(`{"meetId": 1, "text": ""}`).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		apitest.New("SendTooFast").
+			Handler(handler).
+			Method("Post").
+			URL("/message").
+			Body(`{"meetId": 1, "text": "hi"}`).
+			Expect(t).
+			Status(http.StatusTooManyRequests).
+			Header("Retry-After", "30").
+			End()
+	})
 }
@@ -0,0 +1,258 @@
+package http
+
+import (
+	"github.com/gorilla/websocket"
+	"konami_backend/internal/pkg/message"
+	"sync"
+	"time"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxCommandSize = 512
+	sendBufferSize = 256
+)
+
+type roomStatus struct {
+	MeetId int    `json:"meetId"`
+	Error  string `json:"error,omitempty"`
+}
+
+// routedEvent goes to a meeting room, or to a single user when userId is set
+type routedEvent struct {
+	meetId int
+	userId int
+	data   []byte
+}
+
+type clientEvent struct {
+	client *Client
+	data   []byte
+}
+
+type membership struct {
+	client *Client
+	meetId int
+}
+
+// Hub owns room membership; all maps are accessed from the Run goroutine only
+type Hub struct {
+	clients    map[*Client]bool
+	rooms      map[int]map[*Client]bool
+	users      map[int]map[*Client]bool
+	register   chan *Client
+	unregister chan *Client
+	join       chan membership
+	leave      chan membership
+	broadcast  chan routedEvent
+	direct     chan clientEvent
+	evict      chan routedEvent
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients:    make(map[*Client]bool),
+		rooms:      make(map[int]map[*Client]bool),
+		users:      make(map[int]map[*Client]bool),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		join:       make(chan membership),
+		leave:      make(chan membership),
+		broadcast:  make(chan routedEvent),
+		direct:     make(chan clientEvent),
+		evict:      make(chan routedEvent),
+	}
+}
+
+func (h *Hub) Run() {
+	for {
+		select {
+		case c := <-h.register:
+			h.clients[c] = true
+			if h.users[c.userId] == nil {
+				h.users[c.userId] = make(map[*Client]bool)
+			}
+			h.users[c.userId][c] = true
+		case c := <-h.unregister:
+			h.drop(c)
+		case m := <-h.join:
+			if !h.clients[m.client] {
+				continue
+			}
+			if h.rooms[m.meetId] == nil {
+				h.rooms[m.meetId] = make(map[*Client]bool)
+			}
+			h.rooms[m.meetId][m.client] = true
+			m.client.rooms[m.meetId] = true
+		case m := <-h.leave:
+			h.leaveRoom(m.client, m.meetId)
+		case e := <-h.direct:
+			if h.clients[e.client] {
+				h.deliver(e.client, e.data)
+			}
+		case e := <-h.evict:
+			targets := h.users[e.userId]
+			if e.userId == 0 {
+				targets = h.rooms[e.meetId]
+			}
+			for c := range targets {
+				if c.rooms[e.meetId] {
+					h.leaveRoom(c, e.meetId)
+					c.setJoined(e.meetId, false)
+					h.deliver(c, e.data)
+				}
+			}
+		case e := <-h.broadcast:
+			targets := h.rooms[e.meetId]
+			if e.userId != 0 {
+				targets = h.users[e.userId]
+			}
+			for c := range targets {
+				h.deliver(c, e.data)
+			}
+		}
+	}
+}
+
+// deliver never blocks: slow consumers are disconnected instead
+func (h *Hub) deliver(c *Client, data []byte) {
+	select {
+	case c.send <- data:
+	default:
+		h.drop(c)
+	}
+}
+
+func (h *Hub) leaveRoom(c *Client, meetId int) {
+	delete(h.rooms[meetId], c)
+	if len(h.rooms[meetId]) == 0 {
+		delete(h.rooms, meetId)
+	}
+	delete(c.rooms, meetId)
+}
+
+func (h *Hub) drop(c *Client) {
+	if !h.clients[c] {
+		return
+	}
+	for meetId := range c.rooms {
+		h.leaveRoom(c, meetId)
+	}
+	delete(h.users[c.userId], c)
+	if len(h.users[c.userId]) == 0 {
+		delete(h.users, c.userId)
+	}
+	delete(h.clients, c)
+	close(c.send)
+}
+
+// Broadcast sends an encoded event to every client subscribed to the meeting room
+func (h *Hub) Broadcast(meetId int, data []byte) {
+	h.broadcast <- routedEvent{meetId: meetId, data: data}
+}
+
+// Evict removes every connection of the user from the meeting room and tells them about it,
+// a zero userId closes the room for everyone
+func (h *Hub) Evict(meetId int, userId int, data []byte) {
+	h.evict <- routedEvent{meetId: meetId, userId: userId, data: data}
+}
+
+// SendToUser sends an encoded event to every connection of the user
+func (h *Hub) SendToUser(userId int, data []byte) {
+	h.broadcast <- routedEvent{userId: userId, data: data}
+}
+
+type Client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	userId int
+	send   chan []byte
+	// rooms is owned by the hub goroutine
+	rooms map[int]bool
+	// joined mirrors rooms for the read pump, which handles client commands
+	mu     sync.Mutex
+	joined map[int]bool
+}
+
+func newClient(hub *Hub, conn *websocket.Conn, userId int) *Client {
+	return &Client{
+		hub:    hub,
+		conn:   conn,
+		userId: userId,
+		send:   make(chan []byte, sendBufferSize),
+		rooms:  make(map[int]bool),
+		joined: make(map[int]bool),
+	}
+}
+
+func (c *Client) setJoined(meetId int, joined bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if joined {
+		c.joined[meetId] = true
+	} else {
+		delete(c.joined, meetId)
+	}
+}
+
+func (c *Client) isJoined(meetId int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.joined[meetId]
+}
+
+// reply sends an event to this client only
+func (c *Client) reply(msgType string, payload interface{}) {
+	data, err := message.EncodeFrame(msgType, payload)
+	if err != nil {
+		return
+	}
+	c.hub.direct <- clientEvent{client: c, data: data}
+}
+
+func (c *Client) readPump(handle func(c *Client, data []byte)) {
+	defer func() {
+		c.hub.unregister <- c
+		_ = c.conn.Close()
+	}()
+	c.conn.SetReadLimit(maxCommandSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		handle(c, data)
+	}
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
+	for {
+		select {
+		case data, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -1,9 +1,36 @@
 //go:generate mockgen -source=repository.go -destination=./repositoty_mock.go -package=message
 package message
 
-import "konami_backend/internal/pkg/models"
+import (
+	"errors"
+	"konami_backend/internal/pkg/models"
+	"time"
+)
+
+var ErrMessageNotFound = errors.New("message not found")
+var ErrAccessDenied = errors.New("access denied")
+var ErrMessageDeleted = errors.New("message deleted")
+var ErrInvalidReaction = errors.New("invalid reaction")
+var ErrEmptyMessage = errors.New("empty message")
+var ErrMessageTooLong = errors.New("message is too long")
+
+type FilterParams struct {
+	MeetingId     int
+	PrevId        int
+	PrevTimestamp time.Time
+	CountLimit    int
+	// Before and After are message ids bounding the history window
+	Before int
+	After  int
+}
 
 type Repository interface {
 	SaveMessage(message models.Message) (int, error)
-	GetMessages(meetingId int) ([]models.Message, error)
+	GetMessages(params FilterParams) ([]models.Message, error)
+	GetMessage(msgId int) (models.Message, error)
+	UpdateMessage(message models.Message) error
+	AddReaction(msgId int, userId int, emoji string) error
+	RemoveReaction(msgId int, userId int, emoji string) error
+	MarkRead(userId int, meetId int, msgId int) error
+	GetUnreadCounts(userId int, meetIds []int) ([]models.UnreadCount, error)
 }
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"konami_backend/internal/pkg/message"
 	"konami_backend/internal/pkg/models"
 	"time"
@@ -21,12 +23,37 @@ type Message struct {
 	MeetingId int
 	Text      string
 	Timestamp time.Time
+	Edited    bool
+	Deleted   bool
 }
 
 func (m *Message) TableName() string {
 	return "messages"
 }
 
+type Reaction struct {
+	Id        int    `gorm:"primaryKey;autoIncrement;"`
+	MessageId int    `gorm:"uniqueIndex:reaction_message_user_emoji;"`
+	UserId    int    `gorm:"uniqueIndex:reaction_message_user_emoji;"`
+	Emoji     string `gorm:"uniqueIndex:reaction_message_user_emoji;"`
+}
+
+func (r *Reaction) TableName() string {
+	return "message_reactions"
+}
+
+// ReadMarker is the last message of the meeting chat the user has seen
+type ReadMarker struct {
+	Id        int `gorm:"primaryKey;autoIncrement;"`
+	UserId    int `gorm:"uniqueIndex:read_user_meeting;"`
+	MeetingId int `gorm:"uniqueIndex:read_user_meeting;"`
+	MessageId int
+}
+
+func (r *ReadMarker) TableName() string {
+	return "message_reads"
+}
+
 func ToModel(obj Message) models.Message {
 	return models.Message{
 		Id:        obj.Id,
@@ -34,6 +61,8 @@ func ToModel(obj Message) models.Message {
 		MeetingId: obj.MeetingId,
 		Text:      obj.Text,
 		Timestamp: obj.Timestamp.Format("2006-01-02T15:04:05.000Z0700"),
+		Edited:    obj.Edited,
+		Deleted:   obj.Deleted,
 	}
 }
 
@@ -63,12 +92,31 @@ func (h *MessageGormRepo) SaveMessage(message models.Message) (int, error) {
 	return m.Id, nil
 }
 
-func (h *MessageGormRepo) GetMessages(meetingId int) ([]models.Message, error) {
+func (h *MessageGormRepo) GetMessages(params message.FilterParams) ([]models.Message, error) {
 	var messages []Message
-	bd := h.db.
-		Where("Meeting_Id = ?", meetingId).
-		Find(&messages)
-	err := bd.Error
+	bd := h.db.Where("Meeting_Id = ?", params.MeetingId)
+	if params.PrevId > 0 {
+		bd = bd.Where("Timestamp > ? OR (Timestamp = ? AND Id > ?)",
+			params.PrevTimestamp, params.PrevTimestamp, params.PrevId)
+	}
+	if params.After > 0 {
+		bd = bd.Where("(Timestamp, Id) > (?)",
+			h.db.Model(&Message{}).Select("Timestamp, Id").Where("Id = ?", params.After))
+	}
+	if params.Before > 0 {
+		bd = bd.Where("(Timestamp, Id) < (?)",
+			h.db.Model(&Message{}).Select("Timestamp, Id").Where("Id = ?", params.Before))
+	}
+	if params.CountLimit > 0 {
+		bd = bd.Limit(params.CountLimit)
+	}
+	// The window preceding a message is taken newest first and reversed below
+	if params.Before > 0 && params.After == 0 {
+		bd = bd.Order("Timestamp DESC").Order("Id DESC")
+	} else {
+		bd = bd.Order("Timestamp ASC").Order("Id ASC")
+	}
+	err := bd.Find(&messages).Error
 	if err != nil {
 		return nil, err
 	}
@@ -76,5 +124,153 @@ func (h *MessageGormRepo) GetMessages(meetingId int) ([]models.Message, error) {
 	for i, msg := range messages {
 		res[i] = ToModel(msg)
 	}
+	if params.Before > 0 && params.After == 0 {
+		for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+			res[i], res[j] = res[j], res[i]
+		}
+	}
+	err = h.loadReactions(res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (h *MessageGormRepo) loadReactions(messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	ids := make([]int, len(messages))
+	pos := make(map[int]int, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.Id
+		pos[msg.Id] = i
+	}
+	var reactions []Reaction
+	err := h.db.Where("message_id IN ?", ids).Order("id ASC").Find(&reactions).Error
+	if err != nil {
+		return err
+	}
+	for _, r := range reactions {
+		msg := &messages[pos[r.MessageId]]
+		found := false
+		for j := range msg.Reactions {
+			if msg.Reactions[j].Emoji == r.Emoji {
+				msg.Reactions[j].UserIds = append(msg.Reactions[j].UserIds, r.UserId)
+				found = true
+				break
+			}
+		}
+		if !found {
+			msg.Reactions = append(msg.Reactions, models.Reaction{Emoji: r.Emoji, UserIds: []int{r.UserId}})
+		}
+	}
+	return nil
+}
+
+func (h *MessageGormRepo) GetMessage(msgId int) (models.Message, error) {
+	var msg Message
+	err := h.db.Where("id = ?", msgId).First(&msg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Message{}, message.ErrMessageNotFound
+	}
+	if err != nil {
+		return models.Message{}, err
+	}
+	res := []models.Message{ToModel(msg)}
+	err = h.loadReactions(res)
+	return res[0], err
+}
+
+func (h *MessageGormRepo) UpdateMessage(msg models.Message) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&Message{}).
+			Where("id = ?", msg.Id).
+			Updates(map[string]interface{}{
+				"text":    msg.Text,
+				"edited":  msg.Edited,
+				"deleted": msg.Deleted,
+			})
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return message.ErrMessageNotFound
+		}
+		if !msg.Deleted {
+			return nil
+		}
+		// A tombstone keeps no trace of who reacted to it
+		return tx.Where("message_id = ?", msg.Id).Delete(&Reaction{}).Error
+	})
+}
+
+func (h *MessageGormRepo) AddReaction(msgId int, userId int, emoji string) error {
+	r := Reaction{MessageId: msgId, UserId: userId, Emoji: emoji}
+	return h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&r).Error
+}
+
+func (h *MessageGormRepo) RemoveReaction(msgId int, userId int, emoji string) error {
+	return h.db.
+		Where("message_id = ?", msgId).
+		Where("user_id = ?", userId).
+		Where("emoji = ?", emoji).
+		Delete(&Reaction{}).Error
+}
+
+// MarkRead moves the user's read marker forward only
+func (h *MessageGormRepo) MarkRead(userId int, meetId int, msgId int) error {
+	r := ReadMarker{UserId: userId, MeetingId: meetId, MessageId: msgId}
+	return h.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "meeting_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"message_id": gorm.Expr("GREATEST(message_reads.message_id, excluded.message_id)"),
+		}),
+	}).Create(&r).Error
+}
+
+type unreadCount struct {
+	MeetingId int
+	Count     int
+}
+
+func (h *MessageGormRepo) GetUnreadCounts(userId int, meetIds []int) ([]models.UnreadCount, error) {
+	res := make([]models.UnreadCount, len(meetIds))
+	if len(meetIds) == 0 {
+		return res, nil
+	}
+	var markers []ReadMarker
+	err := h.db.
+		Where("user_id = ?", userId).
+		Where("meeting_id IN ?", meetIds).
+		Find(&markers).Error
+	if err != nil {
+		return nil, err
+	}
+	var counts []unreadCount
+	err = h.db.Model(&Message{}).
+		Select("messages.meeting_id, COUNT(*) AS count").
+		Joins("LEFT JOIN message_reads ON message_reads.meeting_id = messages.meeting_id "+
+			"AND message_reads.user_id = ?", userId).
+		Where("messages.meeting_id IN ?", meetIds).
+		Where("messages.author_id <> ?", userId).
+		Where("messages.deleted = ?", false).
+		Where("messages.id > COALESCE(message_reads.message_id, 0)").
+		Group("messages.meeting_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	pos := make(map[int]int, len(meetIds))
+	for i, id := range meetIds {
+		res[i].MeetId = id
+		pos[id] = i
+	}
+	for _, m := range markers {
+		res[pos[m.MeetingId]].LastReadId = m.MessageId
+	}
+	for _, c := range counts {
+		res[pos[c.MeetingId]].Unread = c.Count
+	}
 	return res, nil
 }
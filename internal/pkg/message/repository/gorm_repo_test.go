@@ -11,6 +11,7 @@ import (
 	"konami_backend/internal/pkg/message"
 	"konami_backend/internal/pkg/models"
 	"testing"
+	"time"
 )
 
 type Suite struct {
@@ -46,8 +47,10 @@ func (s *Suite) SetupSuite() {
 func (s *Suite) TestGetSessions() {
 	s.mock.ExpectQuery("SELECT").
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT \\* FROM \"message_reactions\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
-	_, err := s.repository.GetMessages(0)
+	_, err := s.repository.GetMessages(message.FilterParams{})
 	require.NoError(s.T(), err)
 }
 
@@ -55,7 +58,7 @@ func (s *Suite) TestGetSessionsError() {
 	s.mock.ExpectQuery("SELECT").
 		WillReturnError(s.bdError)
 
-	_, err := s.repository.GetMessages(0)
+	_, err := s.repository.GetMessages(message.FilterParams{})
 	require.Error(s.T(), err)
 	require.Equal(s.T(), err, s.bdError)
 }
@@ -113,3 +116,125 @@ func (s *Suite) AfterTest(_, _ string) {
 func TestMeetings(t *testing.T) {
 	suite.Run(t, new(Suite))
 }
+
+func (s *Suite) TestGetMessagesPage() {
+	prev := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery("SELECT \\* FROM \"messages\" WHERE Meeting_Id = (.+) " +
+		"AND \\(Timestamp > (.+) OR \\(Timestamp = (.+) AND Id > (.+)\\)\\) " +
+		"ORDER BY Timestamp ASC,Id ASC LIMIT 5").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "timestamp"}).AddRow(4, "hi", prev))
+	s.mock.ExpectQuery("SELECT \\* FROM \"message_reactions\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	msgs, err := s.repository.GetMessages(message.FilterParams{
+		MeetingId: 1, PrevId: 3, PrevTimestamp: prev, CountLimit: 5})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), msgs, 1)
+	require.Equal(s.T(), 4, msgs[0].Id)
+}
+
+func (s *Suite) TestGetMessagesBefore() {
+	ts := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery("SELECT \\* FROM \"messages\" WHERE Meeting_Id = (.+) " +
+		"AND \\(Timestamp, Id\\) < \\(SELECT Timestamp, Id FROM \"messages\" WHERE Id = (.+)\\) " +
+		"ORDER BY Timestamp DESC,Id DESC LIMIT 3").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "timestamp"}).
+			AddRow(8, "b", ts).AddRow(7, "a", ts))
+	s.mock.ExpectQuery("SELECT \\* FROM \"message_reactions\" WHERE message_id IN \\((.+),(.+)\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "message_id", "user_id", "emoji"}).
+			AddRow(1, 7, 2, "👍").AddRow(2, 7, 3, "👍").AddRow(3, 8, 2, "🔥"))
+
+	msgs, err := s.repository.GetMessages(message.FilterParams{MeetingId: 1, Before: 9, CountLimit: 3})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), msgs, 2)
+	require.Equal(s.T(), 7, msgs[0].Id)
+	require.Equal(s.T(), []models.Reaction{{Emoji: "👍", UserIds: []int{2, 3}}}, msgs[0].Reactions)
+	require.Equal(s.T(), []models.Reaction{{Emoji: "🔥", UserIds: []int{2}}}, msgs[1].Reactions)
+}
+
+func (s *Suite) TestGetMessage() {
+	s.mock.ExpectQuery("SELECT \\* FROM \"messages\" WHERE id = (.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "author_id", "edited"}).AddRow(3, 2, true))
+	s.mock.ExpectQuery("SELECT \\* FROM \"message_reactions\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	msg, err := s.repository.GetMessage(3)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, msg.AuthorId)
+	require.True(s.T(), msg.Edited)
+
+	s.mock.ExpectQuery("SELECT \\* FROM \"messages\" WHERE id = (.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = s.repository.GetMessage(4)
+	require.Equal(s.T(), message.ErrMessageNotFound, err)
+}
+
+func (s *Suite) TestUpdateMessage() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"messages\" SET (.+) WHERE id = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	err := s.repository.UpdateMessage(models.Message{Id: 3, Text: "fixed", Edited: true})
+	require.NoError(s.T(), err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"messages\"").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+	err = s.repository.UpdateMessage(models.Message{Id: 4, Deleted: true})
+	require.Equal(s.T(), message.ErrMessageNotFound, err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"messages\"").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE FROM \"message_reactions\" WHERE message_id = (.+)").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+	err = s.repository.UpdateMessage(models.Message{Id: 5, Deleted: true})
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestReactions() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"message_reactions\" (.+) ON CONFLICT DO NOTHING").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), s.repository.AddReaction(3, 2, "👍"))
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"message_reactions\" WHERE message_id = (.+) " +
+		"AND user_id = (.+) AND emoji = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), s.repository.RemoveReaction(3, 2, "👍"))
+}
+
+func (s *Suite) TestMarkRead() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"message_reads\" (.+) ON CONFLICT \\(\"user_id\",\"meeting_id\"\\) "+
+		"DO UPDATE SET \"message_id\"=GREATEST\\(message_reads.message_id, excluded.message_id\\)").
+		WithArgs(4, 1, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	require.NoError(s.T(), s.repository.MarkRead(4, 1, 9))
+}
+
+func (s *Suite) TestGetUnreadCounts() {
+	s.mock.ExpectQuery("SELECT \\* FROM \"message_reads\" WHERE user_id = (.+) AND meeting_id IN \\((.+),(.+)\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "meeting_id", "message_id"}).AddRow(1, 4, 2, 7))
+	s.mock.ExpectQuery("SELECT messages.meeting_id, COUNT\\(\\*\\) AS count FROM \"messages\" " +
+		"LEFT JOIN message_reads (.+) WHERE messages.meeting_id IN \\((.+),(.+)\\) " +
+		"AND \\(messages.author_id <> (.+)\\) AND messages.deleted = (.+) " +
+		"AND messages.id > COALESCE\\(message_reads.message_id, 0\\) GROUP BY \"messages\".\"meeting_id\"").
+		WillReturnRows(sqlmock.NewRows([]string{"meeting_id", "count"}).AddRow(1, 5))
+
+	counts, err := s.repository.GetUnreadCounts(4, []int{1, 2})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []models.UnreadCount{{MeetId: 1, Unread: 5}, {MeetId: 2, LastReadId: 7}}, counts)
+
+	counts, err = s.repository.GetUnreadCounts(4, nil)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), counts)
+}
@@ -49,16 +49,102 @@ func (mr *MockRepositoryMockRecorder) SaveMessage(message interface{}) *gomock.C
 }
 
 // GetMessages mocks base method
-func (m *MockRepository) GetMessages(meetingId int) ([]models.Message, error) {
+func (m *MockRepository) GetMessages(params FilterParams) ([]models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMessages", meetingId)
+	ret := m.ctrl.Call(m, "GetMessages", params)
 	ret0, _ := ret[0].([]models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetMessages indicates an expected call of GetMessages
-func (mr *MockRepositoryMockRecorder) GetMessages(meetingId interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetMessages(params interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessages", reflect.TypeOf((*MockRepository)(nil).GetMessages), meetingId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessages", reflect.TypeOf((*MockRepository)(nil).GetMessages), params)
+}
+
+// GetMessage mocks base method
+func (m *MockRepository) GetMessage(msgId int) (models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessage", msgId)
+	ret0, _ := ret[0].(models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessage indicates an expected call of GetMessage
+func (mr *MockRepositoryMockRecorder) GetMessage(msgId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockRepository)(nil).GetMessage), msgId)
+}
+
+// UpdateMessage mocks base method
+func (m *MockRepository) UpdateMessage(message models.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessage", message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMessage indicates an expected call of UpdateMessage
+func (mr *MockRepositoryMockRecorder) UpdateMessage(message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessage", reflect.TypeOf((*MockRepository)(nil).UpdateMessage), message)
+}
+
+// AddReaction mocks base method
+func (m *MockRepository) AddReaction(msgId, userId int, emoji string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", msgId, userId, emoji)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddReaction indicates an expected call of AddReaction
+func (mr *MockRepositoryMockRecorder) AddReaction(msgId, userId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockRepository)(nil).AddReaction), msgId, userId, emoji)
+}
+
+// RemoveReaction mocks base method
+func (m *MockRepository) RemoveReaction(msgId, userId int, emoji string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", msgId, userId, emoji)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction
+func (mr *MockRepositoryMockRecorder) RemoveReaction(msgId, userId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockRepository)(nil).RemoveReaction), msgId, userId, emoji)
+}
+
+// MarkRead mocks base method
+func (m *MockRepository) MarkRead(userId, meetId, msgId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", userId, meetId, msgId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead
+func (mr *MockRepositoryMockRecorder) MarkRead(userId, meetId, msgId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockRepository)(nil).MarkRead), userId, meetId, msgId)
+}
+
+// GetUnreadCounts mocks base method
+func (m *MockRepository) GetUnreadCounts(userId int, meetIds []int) ([]models.UnreadCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnreadCounts", userId, meetIds)
+	ret0, _ := ret[0].([]models.UnreadCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnreadCounts indicates an expected call of GetUnreadCounts
+func (mr *MockRepositoryMockRecorder) GetUnreadCounts(userId, meetIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnreadCounts", reflect.TypeOf((*MockRepository)(nil).GetUnreadCounts), userId, meetIds)
 }
@@ -1,9 +1,33 @@
 //go:generate mockgen -source=usecase.go -destination=./usecase_mock.go -package=message
 package message
 
-import "konami_backend/internal/pkg/models"
+import (
+	"konami_backend/internal/pkg/models"
+	"strings"
+	"unicode/utf8"
+)
+
+const MaxMessageLen = 2000
 
 type UseCase interface {
-	CreateMessage(message models.Message) (int, error)
-	GetMessages(meetingId int) ([]models.Message, error)
+	CreateMessage(message models.Message) (models.Message, error)
+	GetMessages(params FilterParams) ([]models.Message, error)
+	IsMember(meetId int, userId int) (bool, error)
+	EditMessage(userId int, edit models.MessageEdit) (models.Message, error)
+	DeleteMessage(userId int, msgId int) (models.Message, error)
+	AddReaction(userId int, msgId int, emoji string) (models.MessageReaction, error)
+	RemoveReaction(userId int, msgId int, emoji string) (models.MessageReaction, error)
+	MarkRead(receipt models.ReadReceipt) error
+	GetUnreadCounts(userId int) ([]models.UnreadCount, error)
+}
+
+// ValidateText checks the text of a meeting chat or a direct message
+func ValidateText(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(text) > MaxMessageLen {
+		return ErrMessageTooLong
+	}
+	return nil
 }
@@ -1,22 +1,197 @@
 package usecase
 
 import (
+	"konami_backend/internal/pkg/meeting"
 	"konami_backend/internal/pkg/message"
 	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+const maxEmojiLen = 32
+
 type MessageUseCase struct {
-	repo message.Repository
+	repo     message.Repository
+	meetRepo meeting.Repository
+	notifier notification.Emitter
+	now      func() time.Time
+}
+
+func NewMessageUseCase(mRepo message.Repository, meetRepo meeting.Repository,
+	notifier notification.Emitter) message.UseCase {
+	return MessageUseCase{repo: mRepo, meetRepo: meetRepo, notifier: notifier, now: time.Now}
+}
+
+// CreateMessage stores a message from a meeting member; the timestamp is always set by the server
+func (u MessageUseCase) CreateMessage(msg models.Message) (models.Message, error) {
+	err := message.ValidateText(msg.Text)
+	if err != nil {
+		return models.Message{}, err
+	}
+	member, err := u.IsMember(msg.MeetingId, msg.AuthorId)
+	if err != nil {
+		return models.Message{}, err
+	}
+	if !member {
+		return models.Message{}, message.ErrAccessDenied
+	}
+	msg.Id = 0
+	msg.Edited, msg.Deleted, msg.Reactions = false, false, nil
+	msg.Timestamp = u.now().UTC().Format("2006-01-02T15:04:05.000Z0700")
+	msg.Id, err = u.repo.SaveMessage(msg)
+	if err != nil {
+		return models.Message{}, err
+	}
+	u.notifyAuthor(msg)
+	return msg, nil
+}
+
+// notifyAuthor tells the meeting author about a new message in its chat
+func (u MessageUseCase) notifyAuthor(msg models.Message) {
+	if u.notifier == nil {
+		return
+	}
+	m, err := u.meetRepo.GetMeeting(msg.MeetingId, msg.AuthorId, true)
+	if err != nil || m.Card == nil || m.Card.AuthorId == msg.AuthorId {
+		return
+	}
+	u.notifier.Emit(notification.Event{
+		Type:    notification.TypeChatMessage,
+		UserId:  m.Card.AuthorId,
+		ActorId: msg.AuthorId,
+		MeetId:  msg.MeetingId,
+	})
+}
+
+func (u MessageUseCase) GetMessages(params message.FilterParams) ([]models.Message, error) {
+	return u.repo.GetMessages(params)
+}
+
+// IsMember tells whether the user may join the meeting chat room:
+// registered participants and organizers only
+func (u MessageUseCase) IsMember(meetId int, userId int) (bool, error) {
+	role, err := u.meetRepo.GetRole(meetId, userId)
+	if err != nil {
+		return false, err
+	}
+	return role == meeting.RoleParticipant || meeting.IsOrganizer(role), nil
+}
+
+func (u MessageUseCase) authorMessage(userId int, msgId int) (models.Message, error) {
+	msg, err := u.repo.GetMessage(msgId)
+	if err != nil {
+		return models.Message{}, err
+	}
+	if msg.AuthorId != userId {
+		return models.Message{}, message.ErrAccessDenied
+	}
+	if msg.Deleted {
+		return models.Message{}, message.ErrMessageDeleted
+	}
+	return msg, nil
+}
+
+func (u MessageUseCase) EditMessage(userId int, edit models.MessageEdit) (models.Message, error) {
+	err := message.ValidateText(edit.Text)
+	if err != nil {
+		return models.Message{}, err
+	}
+	msg, err := u.authorMessage(userId, edit.Id)
+	if err != nil {
+		return models.Message{}, err
+	}
+	msg.Text = edit.Text
+	msg.Edited = true
+	return msg, u.repo.UpdateMessage(msg)
+}
+
+// DeleteMessage keeps the message in the history as a tombstone without text
+func (u MessageUseCase) DeleteMessage(userId int, msgId int) (models.Message, error) {
+	msg, err := u.authorMessage(userId, msgId)
+	if err != nil {
+		return models.Message{}, err
+	}
+	msg.Text = ""
+	msg.Deleted = true
+	msg.Reactions = nil
+	return msg, u.repo.UpdateMessage(msg)
+}
+
+func (u MessageUseCase) reactionTarget(userId int, msgId int, emoji string) (models.MessageReaction, error) {
+	if !isEmoji(emoji) {
+		return models.MessageReaction{}, message.ErrInvalidReaction
+	}
+	msg, err := u.repo.GetMessage(msgId)
+	if err != nil {
+		return models.MessageReaction{}, err
+	}
+	if msg.Deleted {
+		return models.MessageReaction{}, message.ErrMessageDeleted
+	}
+	member, err := u.IsMember(msg.MeetingId, userId)
+	if err != nil {
+		return models.MessageReaction{}, err
+	}
+	if !member {
+		return models.MessageReaction{}, message.ErrAccessDenied
+	}
+	return models.MessageReaction{MessageId: msgId, MeetId: msg.MeetingId, UserId: userId, Emoji: emoji}, nil
+}
+
+func (u MessageUseCase) AddReaction(userId int, msgId int, emoji string) (models.MessageReaction, error) {
+	r, err := u.reactionTarget(userId, msgId, emoji)
+	if err != nil {
+		return models.MessageReaction{}, err
+	}
+	return r, u.repo.AddReaction(msgId, userId, emoji)
+}
+
+func (u MessageUseCase) RemoveReaction(userId int, msgId int, emoji string) (models.MessageReaction, error) {
+	r, err := u.reactionTarget(userId, msgId, emoji)
+	if err != nil {
+		return models.MessageReaction{}, err
+	}
+	return r, u.repo.RemoveReaction(msgId, userId, emoji)
 }
 
-func NewMessageUseCase(mRepo message.Repository) message.UseCase {
-	return MessageUseCase{repo: mRepo}
+// isEmoji accepts a short sequence of pictographic runes, modifiers and joiners;
+// plain letters, digits and ASCII punctuation are rejected
+func isEmoji(s string) bool {
+	if s == "" || len(s) > maxEmojiLen || !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x2000 || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
 }
 
-func (u MessageUseCase) CreateMessage(message models.Message) (int, error) {
-	return u.repo.SaveMessage(message)
+func (u MessageUseCase) MarkRead(receipt models.ReadReceipt) error {
+	member, err := u.IsMember(receipt.MeetId, receipt.UserId)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return message.ErrAccessDenied
+	}
+	msg, err := u.repo.GetMessage(receipt.MessageId)
+	if err != nil {
+		return err
+	}
+	if msg.MeetingId != receipt.MeetId {
+		return message.ErrMessageNotFound
+	}
+	return u.repo.MarkRead(receipt.UserId, receipt.MeetId, receipt.MessageId)
 }
 
-func (u MessageUseCase) GetMessages(meetingId int) ([]models.Message, error) {
-	return u.repo.GetMessages(meetingId)
+func (u MessageUseCase) GetUnreadCounts(userId int) ([]models.UnreadCount, error) {
+	meetIds, err := u.meetRepo.GetMemberMeetingIds(userId)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetUnreadCounts(userId, meetIds)
 }
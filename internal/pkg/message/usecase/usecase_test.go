@@ -3,9 +3,13 @@ package usecase
 import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"konami_backend/internal/pkg/meeting"
 	"konami_backend/internal/pkg/message"
 	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTag(t *testing.T) {
@@ -14,10 +18,10 @@ func TestTag(t *testing.T) {
 		defer ctrl.Finish()
 		tagRepo := message.NewMockRepository(ctrl)
 
-		ta := NewMessageUseCase(tagRepo)
+		ta := NewMessageUseCase(tagRepo, nil, nil)
 
-		tagRepo.EXPECT().GetMessages(0)
-		_, err := ta.GetMessages(0)
+		tagRepo.EXPECT().GetMessages(message.FilterParams{})
+		_, err := ta.GetMessages(message.FilterParams{})
 		assert.NoError(t, err)
 
 		gg := models.Message{
@@ -28,8 +32,184 @@ func TestTag(t *testing.T) {
 			Timestamp: "",
 		}
 
-		tagRepo.EXPECT().SaveMessage(gg)
 		_, err = ta.CreateMessage(gg)
+		assert.Equal(t, message.ErrEmptyMessage, err)
+	})
+
+	t.Run("CreateMessage", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msgRepo := message.NewMockRepository(ctrl)
+		meetRepo := meeting.NewMockRepository(ctrl)
+		uc := MessageUseCase{repo: msgRepo, meetRepo: meetRepo, now: func() time.Time {
+			return time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+		}}
+
+		msg := models.Message{Id: 99, AuthorId: 4, MeetingId: 1, Text: "hi", Timestamp: "1999-01-01T00:00:00.000Z"}
+		saved := models.Message{AuthorId: 4, MeetingId: 1, Text: "hi", Timestamp: "2020-12-05T10:00:00.000Z"}
+		meetRepo.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		msgRepo.EXPECT().SaveMessage(saved).Return(7, nil)
+		res, err := uc.CreateMessage(msg)
+		assert.NoError(t, err)
+		saved.Id = 7
+		assert.Equal(t, saved, res)
+
+		meetRepo.EXPECT().GetRole(1, 5).Return(meeting.RoleGuest, nil)
+		_, err = uc.CreateMessage(models.Message{AuthorId: 5, MeetingId: 1, Text: "hi"})
+		assert.Equal(t, message.ErrAccessDenied, err)
+
+		meetRepo.EXPECT().GetRole(2, 4).Return(meeting.RoleGuest, meeting.ErrMeetingNotFound)
+		_, err = uc.CreateMessage(models.Message{AuthorId: 4, MeetingId: 2, Text: "hi"})
+		assert.Equal(t, meeting.ErrMeetingNotFound, err)
+
+		_, err = uc.CreateMessage(models.Message{AuthorId: 4, MeetingId: 1, Text: strings.Repeat("ы", message.MaxMessageLen+1)})
+		assert.Equal(t, message.ErrMessageTooLong, err)
+		_, err = uc.EditMessage(4, models.MessageEdit{Id: 3, Text: " \n"})
+		assert.Equal(t, message.ErrEmptyMessage, err)
+	})
+
+	t.Run("CreateMessageNotifies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msgRepo := message.NewMockRepository(ctrl)
+		meetRepo := meeting.NewMockRepository(ctrl)
+		notifier := notification.NewMockUseCase(ctrl)
+		uc := NewMessageUseCase(msgRepo, meetRepo, notifier)
+
+		meetM := models.MeetingDetails{Card: &models.MeetingCard{AuthorId: 3}}
+		meetRepo.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		msgRepo.EXPECT().SaveMessage(gomock.Any()).Return(7, nil)
+		meetRepo.EXPECT().GetMeeting(1, 4, true).Return(meetM, nil)
+		notifier.EXPECT().Emit(notification.Event{Type: notification.TypeChatMessage, UserId: 3, ActorId: 4, MeetId: 1})
+		_, err := uc.CreateMessage(models.Message{AuthorId: 4, MeetingId: 1, Text: "hi"})
+		assert.NoError(t, err)
+
+		meetRepo.EXPECT().GetRole(1, 3).Return(meeting.RoleAuthor, nil)
+		msgRepo.EXPECT().SaveMessage(gomock.Any()).Return(8, nil)
+		meetRepo.EXPECT().GetMeeting(1, 3, true).Return(meetM, nil)
+		_, err = uc.CreateMessage(models.Message{AuthorId: 3, MeetingId: 1, Text: "hi"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("IsMember", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msgRepo := message.NewMockRepository(ctrl)
+		meetRepo := meeting.NewMockRepository(ctrl)
+		uc := NewMessageUseCase(msgRepo, meetRepo, nil)
+
+		meetRepo.EXPECT().GetRole(1, 2).Return(meeting.RoleParticipant, nil)
+		ok, err := uc.IsMember(1, 2)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		meetRepo.EXPECT().GetRole(1, 3).Return(meeting.RoleOrganizer, nil)
+		ok, _ = uc.IsMember(1, 3)
+		assert.True(t, ok)
+
+		meetRepo.EXPECT().GetRole(1, 4).Return(meeting.RoleGuest, nil)
+		ok, _ = uc.IsMember(1, 4)
+		assert.False(t, ok)
+
+		meetRepo.EXPECT().GetRole(5, 2).Return(meeting.RoleGuest, meeting.ErrMeetingNotFound)
+		_, err = uc.IsMember(5, 2)
+		assert.Equal(t, meeting.ErrMeetingNotFound, err)
+	})
+
+	t.Run("EditDelete", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msgRepo := message.NewMockRepository(ctrl)
+		uc := NewMessageUseCase(msgRepo, nil, nil)
+		msg := models.Message{Id: 3, AuthorId: 2, MeetingId: 1, Text: "helo",
+			Reactions: []models.Reaction{{Emoji: "👍", UserIds: []int{4}}}}
+
+		msgRepo.EXPECT().GetMessage(3).Return(msg, nil)
+		edited := msg
+		edited.Text, edited.Edited = "hello", true
+		msgRepo.EXPECT().UpdateMessage(edited).Return(nil)
+		res, err := uc.EditMessage(2, models.MessageEdit{Id: 3, Text: "hello"})
+		assert.NoError(t, err)
+		assert.Equal(t, edited, res)
+
+		msgRepo.EXPECT().GetMessage(3).Return(msg, nil)
+		_, err = uc.EditMessage(5, models.MessageEdit{Id: 3, Text: "hijack"})
+		assert.Equal(t, message.ErrAccessDenied, err)
+
+		msgRepo.EXPECT().GetMessage(4).Return(models.Message{}, message.ErrMessageNotFound)
+		_, err = uc.DeleteMessage(2, 4)
+		assert.Equal(t, message.ErrMessageNotFound, err)
+
+		msgRepo.EXPECT().GetMessage(3).Return(msg, nil)
+		msgRepo.EXPECT().UpdateMessage(models.Message{Id: 3, AuthorId: 2, MeetingId: 1, Deleted: true}).Return(nil)
+		res, err = uc.DeleteMessage(2, 3)
+		assert.NoError(t, err)
+		assert.True(t, res.Deleted)
+		assert.Empty(t, res.Text)
+
+		msgRepo.EXPECT().GetMessage(3).Return(res, nil)
+		_, err = uc.EditMessage(2, models.MessageEdit{Id: 3, Text: "again"})
+		assert.Equal(t, message.ErrMessageDeleted, err)
+	})
+
+	t.Run("Reactions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msgRepo := message.NewMockRepository(ctrl)
+		meetRepo := meeting.NewMockRepository(ctrl)
+		uc := NewMessageUseCase(msgRepo, meetRepo, nil)
+		msg := models.Message{Id: 3, AuthorId: 2, MeetingId: 1}
+
+		_, err := uc.AddReaction(4, 3, "a")
+		assert.Equal(t, message.ErrInvalidReaction, err)
+		_, err = uc.AddReaction(4, 3, "")
+		assert.Equal(t, message.ErrInvalidReaction, err)
+
+		msgRepo.EXPECT().GetMessage(3).Return(msg, nil)
+		meetRepo.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		msgRepo.EXPECT().AddReaction(3, 4, "👍").Return(nil)
+		r, err := uc.AddReaction(4, 3, "👍")
+		assert.NoError(t, err)
+		assert.Equal(t, models.MessageReaction{MessageId: 3, MeetId: 1, UserId: 4, Emoji: "👍"}, r)
+
+		msgRepo.EXPECT().GetMessage(3).Return(msg, nil)
+		meetRepo.EXPECT().GetRole(1, 5).Return(meeting.RoleGuest, nil)
+		_, err = uc.AddReaction(5, 3, "👍")
+		assert.Equal(t, message.ErrAccessDenied, err)
+
+		msgRepo.EXPECT().GetMessage(3).Return(msg, nil)
+		meetRepo.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		msgRepo.EXPECT().RemoveReaction(3, 4, "❤️").Return(nil)
+		_, err = uc.RemoveReaction(4, 3, "❤️")
+		assert.NoError(t, err)
+	})
+
+	t.Run("ReadReceipts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msgRepo := message.NewMockRepository(ctrl)
+		meetRepo := meeting.NewMockRepository(ctrl)
+		uc := NewMessageUseCase(msgRepo, meetRepo, nil)
+
+		meetRepo.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		msgRepo.EXPECT().GetMessage(9).Return(models.Message{Id: 9, MeetingId: 1}, nil)
+		msgRepo.EXPECT().MarkRead(4, 1, 9).Return(nil)
+		assert.NoError(t, uc.MarkRead(models.ReadReceipt{MeetId: 1, UserId: 4, MessageId: 9}))
+
+		meetRepo.EXPECT().GetRole(1, 4).Return(meeting.RoleParticipant, nil)
+		msgRepo.EXPECT().GetMessage(10).Return(models.Message{Id: 10, MeetingId: 2}, nil)
+		err := uc.MarkRead(models.ReadReceipt{MeetId: 1, UserId: 4, MessageId: 10})
+		assert.Equal(t, message.ErrMessageNotFound, err)
+
+		meetRepo.EXPECT().GetRole(1, 5).Return(meeting.RoleGuest, nil)
+		err = uc.MarkRead(models.ReadReceipt{MeetId: 1, UserId: 5, MessageId: 9})
+		assert.Equal(t, message.ErrAccessDenied, err)
+
+		counts := []models.UnreadCount{{MeetId: 1, Unread: 3}, {MeetId: 2}}
+		meetRepo.EXPECT().GetMemberMeetingIds(4).Return([]int{1, 2}, nil)
+		msgRepo.EXPECT().GetUnreadCounts(4, []int{1, 2}).Return(counts, nil)
+		res, err := uc.GetUnreadCounts(4)
 		assert.NoError(t, err)
+		assert.Equal(t, counts, res)
 	})
 }
@@ -34,10 +34,10 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 }
 
 // CreateMessage mocks base method
-func (m *MockUseCase) CreateMessage(message models.Message) (int, error) {
+func (m *MockUseCase) CreateMessage(message models.Message) (models.Message, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateMessage", message)
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -49,16 +49,120 @@ func (mr *MockUseCaseMockRecorder) CreateMessage(message interface{}) *gomock.Ca
 }
 
 // GetMessages mocks base method
-func (m *MockUseCase) GetMessages(meetingId int) ([]models.Message, error) {
+func (m *MockUseCase) GetMessages(params FilterParams) ([]models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMessages", meetingId)
+	ret := m.ctrl.Call(m, "GetMessages", params)
 	ret0, _ := ret[0].([]models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetMessages indicates an expected call of GetMessages
-func (mr *MockUseCaseMockRecorder) GetMessages(meetingId interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) GetMessages(params interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessages", reflect.TypeOf((*MockUseCase)(nil).GetMessages), meetingId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessages", reflect.TypeOf((*MockUseCase)(nil).GetMessages), params)
+}
+
+// IsMember mocks base method
+func (m *MockUseCase) IsMember(meetId, userId int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsMember", meetId, userId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsMember indicates an expected call of IsMember
+func (mr *MockUseCaseMockRecorder) IsMember(meetId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsMember", reflect.TypeOf((*MockUseCase)(nil).IsMember), meetId, userId)
+}
+
+// EditMessage mocks base method
+func (m *MockUseCase) EditMessage(userId int, edit models.MessageEdit) (models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditMessage", userId, edit)
+	ret0, _ := ret[0].(models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EditMessage indicates an expected call of EditMessage
+func (mr *MockUseCaseMockRecorder) EditMessage(userId, edit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditMessage", reflect.TypeOf((*MockUseCase)(nil).EditMessage), userId, edit)
+}
+
+// DeleteMessage mocks base method
+func (m *MockUseCase) DeleteMessage(userId, msgId int) (models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessage", userId, msgId)
+	ret0, _ := ret[0].(models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMessage indicates an expected call of DeleteMessage
+func (mr *MockUseCaseMockRecorder) DeleteMessage(userId, msgId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessage", reflect.TypeOf((*MockUseCase)(nil).DeleteMessage), userId, msgId)
+}
+
+// AddReaction mocks base method
+func (m *MockUseCase) AddReaction(userId, msgId int, emoji string) (models.MessageReaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", userId, msgId, emoji)
+	ret0, _ := ret[0].(models.MessageReaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReaction indicates an expected call of AddReaction
+func (mr *MockUseCaseMockRecorder) AddReaction(userId, msgId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockUseCase)(nil).AddReaction), userId, msgId, emoji)
+}
+
+// RemoveReaction mocks base method
+func (m *MockUseCase) RemoveReaction(userId, msgId int, emoji string) (models.MessageReaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", userId, msgId, emoji)
+	ret0, _ := ret[0].(models.MessageReaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction
+func (mr *MockUseCaseMockRecorder) RemoveReaction(userId, msgId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockUseCase)(nil).RemoveReaction), userId, msgId, emoji)
+}
+
+// MarkRead mocks base method
+func (m *MockUseCase) MarkRead(receipt models.ReadReceipt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", receipt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead
+func (mr *MockUseCaseMockRecorder) MarkRead(receipt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockUseCase)(nil).MarkRead), receipt)
+}
+
+// GetUnreadCounts mocks base method
+func (m *MockUseCase) GetUnreadCounts(userId int) ([]models.UnreadCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnreadCounts", userId)
+	ret0, _ := ret[0].([]models.UnreadCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnreadCounts indicates an expected call of GetUnreadCounts
+func (mr *MockUseCaseMockRecorder) GetUnreadCounts(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnreadCounts", reflect.TypeOf((*MockUseCase)(nil).GetUnreadCounts), userId)
 }
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"konami_backend/internal/pkg/profile"
+	hu "konami_backend/internal/pkg/utils/http_utils"
 	"konami_backend/proto/auth"
 	"net/http"
 )
@@ -47,3 +48,25 @@ func (am *AuthMiddleware) Auth(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequireVerified keeps accounts without a verified email away from the handler,
+// anonymous requests are left for the handler to reject
+func (am *AuthMiddleware) RequireVerified(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := r.Context().Value(UserID).(int)
+		if !ok {
+			next(w, r)
+			return
+		}
+		verified, err := am.ProfileUC.IsEmailVerified(userId)
+		if err != nil {
+			hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+			return
+		}
+		if !verified {
+			hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusForbidden, ErrMsg: "email is not verified"})
+			return
+		}
+		next(w, r)
+	}
+}
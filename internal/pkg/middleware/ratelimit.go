@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/gomodule/redigo/redis"
+	"io/ioutil"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/profile"
+	hu "konami_backend/internal/pkg/utils/http_utils"
+	"konami_backend/internal/pkg/utils/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LogInIPBurst     = 30
+	LogInIPWindow    = time.Minute
+	LogInLoginBurst  = 10
+	LogInLoginWindow = time.Minute
+	SignUpIPBurst    = 5
+	SignUpIPWindow   = time.Hour
+	ResetIPBurst     = 10
+	ResetIPWindow    = time.Hour
+	ResetLoginBurst  = 3
+	ResetLoginWindow = time.Hour
+	TokenIPBurst     = 20
+	TokenIPWindow    = time.Hour
+)
+
+var LogInLockout = ratelimit.LockoutPolicy{
+	Threshold: 5,
+	Base:      30 * time.Second,
+	Max:       time.Hour,
+	Memory:    24 * time.Hour,
+}
+
+type RateLimitMiddleware struct {
+	LogInByIP    ratelimit.Bucket
+	LogInByLogin ratelimit.Bucket
+	SignUpByIP   ratelimit.Bucket
+	ResetByIP    ratelimit.Bucket
+	ResetByLogin ratelimit.Bucket
+	TokenByIP    ratelimit.Bucket
+	Lockout      ratelimit.Lockout
+	Accounts     profile.UseCase
+	MaxReqSize   int64
+}
+
+// NewRateLimitMiddleware shares limits between instances through redis,
+// without a pool every instance counts on its own
+func NewRateLimitMiddleware(pool *redis.Pool, accounts profile.UseCase, maxReqSize int64) RateLimitMiddleware {
+	if pool == nil {
+		return RateLimitMiddleware{
+			LogInByIP:    ratelimit.NewLimiter(LogInIPBurst, LogInIPWindow),
+			LogInByLogin: ratelimit.NewLimiter(LogInLoginBurst, LogInLoginWindow),
+			SignUpByIP:   ratelimit.NewLimiter(SignUpIPBurst, SignUpIPWindow),
+			ResetByIP:    ratelimit.NewLimiter(ResetIPBurst, ResetIPWindow),
+			ResetByLogin: ratelimit.NewLimiter(ResetLoginBurst, ResetLoginWindow),
+			TokenByIP:    ratelimit.NewLimiter(TokenIPBurst, TokenIPWindow),
+			Lockout:      ratelimit.NewMemoryLockout(LogInLockout),
+			Accounts:     accounts,
+			MaxReqSize:   maxReqSize,
+		}
+	}
+	return RateLimitMiddleware{
+		LogInByIP:    ratelimit.NewRedisLimiter(pool, "ratelimit:login:ip:", LogInIPBurst, LogInIPWindow),
+		LogInByLogin: ratelimit.NewRedisLimiter(pool, "ratelimit:login:user:", LogInLoginBurst, LogInLoginWindow),
+		SignUpByIP:   ratelimit.NewRedisLimiter(pool, "ratelimit:signup:ip:", SignUpIPBurst, SignUpIPWindow),
+		ResetByIP:    ratelimit.NewRedisLimiter(pool, "ratelimit:reset:ip:", ResetIPBurst, ResetIPWindow),
+		ResetByLogin: ratelimit.NewRedisLimiter(pool, "ratelimit:reset:user:", ResetLoginBurst, ResetLoginWindow),
+		TokenByIP:    ratelimit.NewRedisLimiter(pool, "ratelimit:token:ip:", TokenIPBurst, TokenIPWindow),
+		Lockout:      ratelimit.NewRedisLockout(pool, "lockout:login:", LogInLockout),
+		Accounts:     accounts,
+		MaxReqSize:   maxReqSize,
+	}
+}
+
+func tooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusTooManyRequests, ErrMsg: "too many requests"})
+}
+
+// LimitIP spends a token of the client address from the bucket before every request
+func (rm *RateLimitMiddleware) LimitIP(b ratelimit.Bucket, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := b.Allow(hu.ClientIP(r)); !ok {
+			tooManyRequests(w, wait)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// peekBody reads the request body and leaves a copy of it for the handler
+func (rm *RateLimitMiddleware) peekBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, rm.MaxReqSize))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(buf.Bytes()))
+	return buf.Bytes(), nil
+}
+
+// accountKey makes the login and the verified email of an account share limits,
+// identifiers of unknown accounts are only normalized
+func (rm *RateLimitMiddleware) accountKey(login string) string {
+	userId, err := rm.Accounts.ResolveLogin(login)
+	if err != nil {
+		return "login:" + strings.ToLower(strings.TrimSpace(login))
+	}
+	return "user:" + strconv.Itoa(userId)
+}
+
+// LimitLogin spends a token of the login from the credentials in the body and
+// keeps a login locked out after repeated failures. The handler is expected to
+// answer 400 on wrong credentials and 201 on success.
+func (rm *RateLimitMiddleware) LimitLogin(b ratelimit.Bucket, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var creds models.Credentials
+		body, err := rm.peekBody(w, r)
+		if err != nil {
+			hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+			return
+		}
+		// malformed bodies are rejected by the handler
+		if creds.UnmarshalJSON(body) != nil || creds.Login == "" {
+			next(w, r)
+			return
+		}
+		login := rm.accountKey(creds.Login)
+		if wait := rm.Lockout.Locked(login); wait > 0 {
+			tooManyRequests(w, wait)
+			return
+		}
+		if ok, wait := b.Allow(login); !ok {
+			tooManyRequests(w, wait)
+			return
+		}
+		rec := statusRecorder{w, http.StatusOK}
+		next(&rec, r)
+		switch rec.status {
+		case http.StatusBadRequest:
+			rm.Lockout.Fail(login)
+		case http.StatusCreated:
+			rm.Lockout.Reset(login)
+		}
+	}
+}
+
+// LimitReset spends a token of the account a password reset is requested for,
+// so nobody can flood a mailbox with reset links
+func (rm *RateLimitMiddleware) LimitReset(b ratelimit.Bucket, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.PasswordResetRequest
+		body, err := rm.peekBody(w, r)
+		if err != nil {
+			hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+			return
+		}
+		if req.UnmarshalJSON(body) != nil || req.Login == "" {
+			next(w, r)
+			return
+		}
+		if ok, wait := b.Allow(rm.accountKey(req.Login)); !ok {
+			tooManyRequests(w, wait)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/steinfletcher/apitest"
+	"konami_backend/internal/pkg/profile"
+	"konami_backend/internal/pkg/utils/ratelimit"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	byIP := ratelimit.NewLimiter(2, time.Minute)
+	byIP.Now = func() time.Time { return now }
+	lockout := ratelimit.NewMemoryLockout(ratelimit.LockoutPolicy{
+		Threshold: 1, Base: time.Minute, Max: time.Hour, Memory: time.Hour,
+	})
+	lockout.Now = byIP.Now
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	accounts := profile.NewMockUseCase(ctrl)
+	accounts.EXPECT().ResolveLogin(gomock.Any()).DoAndReturn(func(login string) (int, error) {
+		if login == "Ivan" || login == "Ivan@Mail.ru" {
+			return 7, nil
+		}
+		return 0, profile.ErrUserNonExistent
+	}).AnyTimes()
+	rm := RateLimitMiddleware{Lockout: lockout, Accounts: accounts, MaxReqSize: 100}
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	handler := rm.LimitIP(byIP, ok)
+	for _, status := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		apitest.New("LimitIP").
+			HandlerFunc(handler).
+			Method("POST").
+			URL("/signup").
+			Expect(t).
+			Status(status).
+			End()
+	}
+	apitest.New("LimitIPRetryAfter").
+		HandlerFunc(handler).
+		Method("POST").
+		URL("/signup").
+		Expect(t).
+		Status(http.StatusTooManyRequests).
+		Header("Retry-After", "30").
+		End()
+
+	byLogin := ratelimit.NewLimiter(100, time.Minute)
+	byLogin.Now = byIP.Now
+	logIn := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+	handler = rm.LimitLogin(byLogin, logIn)
+	body := `{"login": "Ivan", "password": "qwerty"}`
+
+	// the first failure is free, the second one locks the login out
+	for _, status := range []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusTooManyRequests} {
+		apitest.New("LogInFail").
+			HandlerFunc(handler).
+			Method("POST").
+			URL("/login").
+			Query("fail", "1").
+			Body(body).
+			Expect(t).
+			Status(status).
+			End()
+	}
+	// the verified email of the account shares the lockout with its login
+	apitest.New("LogInLocked").
+		HandlerFunc(handler).
+		Method("POST").
+		URL("/login").
+		Body(`{"login": "Ivan@Mail.ru", "password": "qwerty"}`).
+		Expect(t).
+		Status(http.StatusTooManyRequests).
+		Header("Retry-After", "60").
+		End()
+
+	now = now.Add(time.Minute)
+	apitest.New("LogInAfterLockout").
+		HandlerFunc(handler).
+		Method("POST").
+		URL("/login").
+		Body(body).
+		Expect(t).
+		Status(http.StatusCreated).
+		End()
+	if lockout.Locked("user:7") != 0 || lockout.Fail("user:7") != 0 {
+		t.Error("successful login must reset the lockout")
+	}
+
+	apitest.New("LogInUnknown").
+		HandlerFunc(handler).
+		Method("POST").
+		URL("/login").
+		Query("fail", "1").
+		Body(`{"login": "Petr ", "password": "qwerty"}`).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+	if lockout.Fail("login:petr") == 0 {
+		t.Error("unknown logins must be counted by their normalized form")
+	}
+
+	apitest.New("LogInTooLarge").
+		HandlerFunc(handler).
+		Method("POST").
+		URL("/login").
+		Body(`{"login": "` + strings.Repeat("a", 100) + `"}`).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+
+	apitest.New("LogInMalformed").
+		HandlerFunc(handler).
+		Method("POST").
+		URL("/login").
+		Body("{").
+		Expect(t).
+		Status(http.StatusCreated).
+		End()
+
+	byAccount := ratelimit.NewLimiter(1, time.Minute)
+	byAccount.Now = byIP.Now
+	handler = rm.LimitReset(byAccount, ok)
+	// the login and the verified email of an account share one budget
+	for _, c := range []struct {
+		login  string
+		status int
+	}{
+		{"Ivan", http.StatusOK},
+		{"Ivan@Mail.ru", http.StatusTooManyRequests},
+		{"Petr", http.StatusOK},
+		{"petr ", http.StatusTooManyRequests},
+	} {
+		apitest.New("LimitReset").
+			HandlerFunc(handler).
+			Method("POST").
+			URL("/password/reset").
+			Body(`{"login": "` + c.login + `"}`).
+			Expect(t).
+			Status(c.status).
+			End()
+	}
+}
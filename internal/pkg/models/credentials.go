@@ -5,4 +5,6 @@ package models
 type Credentials struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	// Email is only read on signup, the login field accepts a verified email on login
+	Email string `json:"email,omitempty"`
 }
@@ -40,6 +40,8 @@ func easyjson5b679028DecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out
 			out.Login = string(in.String())
 		case "password":
 			out.Password = string(in.String())
+		case "email":
+			out.Email = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -64,6 +66,11 @@ func easyjson5b679028EncodeKonamiBackendInternalPkgModels(out *jwriter.Writer, i
 		out.RawString(prefix)
 		out.String(string(in.Password))
 	}
+	if in.Email != "" {
+		const prefix string = ",\"email\":"
+		out.RawString(prefix)
+		out.String(string(in.Email))
+	}
 	out.RawByte('}')
 }
 
@@ -0,0 +1,22 @@
+//go:generate easyjson dialog.go
+package models
+
+//easyjson:json
+type DirectMessage struct {
+	Id          int    `json:"id"`
+	AuthorId    int    `json:"authorId"`
+	RecipientId int    `json:"recipientId"`
+	Text        string `json:"text"`
+	Timestamp   string `json:"timestamp"`
+}
+
+type Conversation struct {
+	Peer        ProfileLabel   `json:"peer"`
+	LastMessage *DirectMessage `json:"lastMessage"`
+	Unread      int            `json:"unread"`
+}
+
+//easyjson:json
+type UserBlock struct {
+	TargetId int `json:"targetId"`
+}
@@ -0,0 +1,179 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson6bf45bf4DecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out *UserBlock) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "targetId":
+			out.TargetId = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6bf45bf4EncodeKonamiBackendInternalPkgModels(out *jwriter.Writer, in UserBlock) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"targetId\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.TargetId))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UserBlock) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6bf45bf4EncodeKonamiBackendInternalPkgModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UserBlock) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6bf45bf4EncodeKonamiBackendInternalPkgModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UserBlock) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6bf45bf4DecodeKonamiBackendInternalPkgModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UserBlock) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6bf45bf4DecodeKonamiBackendInternalPkgModels(l, v)
+}
+func easyjson6bf45bf4DecodeKonamiBackendInternalPkgModels1(in *jlexer.Lexer, out *DirectMessage) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = int(in.Int())
+		case "authorId":
+			out.AuthorId = int(in.Int())
+		case "recipientId":
+			out.RecipientId = int(in.Int())
+		case "text":
+			out.Text = string(in.String())
+		case "timestamp":
+			out.Timestamp = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6bf45bf4EncodeKonamiBackendInternalPkgModels1(out *jwriter.Writer, in DirectMessage) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Id))
+	}
+	{
+		const prefix string = ",\"authorId\":"
+		out.RawString(prefix)
+		out.Int(int(in.AuthorId))
+	}
+	{
+		const prefix string = ",\"recipientId\":"
+		out.RawString(prefix)
+		out.Int(int(in.RecipientId))
+	}
+	{
+		const prefix string = ",\"text\":"
+		out.RawString(prefix)
+		out.String(string(in.Text))
+	}
+	{
+		const prefix string = ",\"timestamp\":"
+		out.RawString(prefix)
+		out.String(string(in.Timestamp))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v DirectMessage) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6bf45bf4EncodeKonamiBackendInternalPkgModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v DirectMessage) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6bf45bf4EncodeKonamiBackendInternalPkgModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *DirectMessage) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6bf45bf4DecodeKonamiBackendInternalPkgModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *DirectMessage) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6bf45bf4DecodeKonamiBackendInternalPkgModels1(l, v)
+}
@@ -0,0 +1,18 @@
+//go:generate easyjson email.go
+package models
+
+//easyjson:json
+type EmailStatus struct {
+	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+}
+
+//easyjson:json
+type EmailUpdate struct {
+	Email string `json:"email"`
+}
+
+//easyjson:json
+type EmailVerify struct {
+	Token string `json:"token"`
+}
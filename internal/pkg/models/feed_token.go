@@ -0,0 +1,5 @@
+package models
+
+type FeedToken struct {
+	Token string `json:"token"`
+}
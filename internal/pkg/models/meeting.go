@@ -1,7 +1,8 @@
 package models
 
 type Meeting struct {
-	Card *MeetingCard `json:"card"`
-	Like bool         `json:"isLiked"`
-	Reg  bool         `json:"isRegistered"`
+	Card     *MeetingCard `json:"card"`
+	Like     bool         `json:"isLiked"`
+	Reg      bool         `json:"isRegistered"`
+	Distance *float64     `json:"distanceKm,omitempty"`
 }
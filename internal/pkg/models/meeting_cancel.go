@@ -0,0 +1,8 @@
+//go:generate easyjson meeting_cancel.go
+package models
+
+//easyjson:json
+type MeetingCancel struct {
+	MeetId int    `json:"meetId"`
+	Reason string `json:"reason"`
+}
@@ -1,16 +1,22 @@
 package models
 
 type MeetingCard struct {
-	Label      *MeetingLabel `json:"label"`
-	AuthorId   int           `json:"authorId"`
-	Text       string        `json:"text"`
-	Tags       []*Tag        `json:"tags"`
-	Address    string        `json:"address"`
-	City       string        `json:"city"`
-	StartDate  string        `json:"startDate"`
-	EndDate    string        `json:"endDate"`
-	Seats      int           `json:"seats"`
-	SeatsLeft  int           `json:"seatsLeft"`
-	RegsCount  int           `json:"regsCount"`
-	LikesCount int           `json:"likesCount"`
+	Label        *MeetingLabel `json:"label"`
+	AuthorId     int           `json:"authorId"`
+	Text         string        `json:"text"`
+	Tags         []*Tag        `json:"tags"`
+	Address      string        `json:"address"`
+	City         string        `json:"city"`
+	StartDate    string        `json:"startDate"`
+	EndDate      string        `json:"endDate"`
+	Seats        int           `json:"seats"`
+	SeatsLeft    int           `json:"seatsLeft"`
+	RegsCount    int           `json:"regsCount"`
+	LikesCount   int           `json:"likesCount"`
+	Cancelled    bool          `json:"isCancelled"`
+	CancelReason string        `json:"cancelReason"`
+	Approval     bool          `json:"approvalRequired"`
+	SeriesId     int           `json:"seriesId"`
+	Lat          *float64      `json:"lat"`
+	Lon          *float64      `json:"lon"`
 }
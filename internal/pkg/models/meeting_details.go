@@ -4,5 +4,9 @@ type MeetingDetails struct {
 	Card          *MeetingCard    `json:"card"`
 	Like          bool            `json:"isLiked"`
 	Reg           bool            `json:"isRegistered"`
+	Pending       bool            `json:"isPending"`
+	Role          string          `json:"role"`
+	WaitlistPos   int             `json:"waitlistPosition"`
 	Registrations []*ProfileLabel `json:"registrations"`
+	Organizers    []*ProfileLabel `json:"organizers"`
 }
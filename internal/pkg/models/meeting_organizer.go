@@ -0,0 +1,8 @@
+//go:generate easyjson meeting_organizer.go
+package models
+
+//easyjson:json
+type MeetingOrganizer struct {
+	MeetId int `json:"meetId"`
+	UserId int `json:"userId"`
+}
@@ -0,0 +1,18 @@
+package models
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type MeetingFacets struct {
+	Tags   []FacetCount `json:"tags"`
+	Cities []FacetCount `json:"cities"`
+}
+
+type MeetingQueryResult struct {
+	Meetings   []Meeting     `json:"meetings"`
+	Facets     MeetingFacets `json:"facets"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+	HasMore    bool          `json:"hasMore"`
+}
@@ -4,25 +4,31 @@ package models
 type MeetUpdateFields struct {
 	Reg  *bool        `json:"isRegistered"`
 	Like *bool        `json:"isLiked"`
+	Wait *bool        `json:"isWaiting"`
 	Card *MeetingData `json:"card"`
 }
 
 //easyjson:json
 type MeetingUpdate struct {
 	MeetId int               `json:"meetId"`
+	Scope  string            `json:"scope"`
 	Fields *MeetUpdateFields `json:"fields"`
 }
 
 //easyjson:json
 type MeetingData struct {
-	Address   *string  `json:"address"`
-	City      *string  `json:"city"`
-	Start     *string  `json:"start"`
-	End       *string  `json:"end"`
-	Text      *string  `json:"meet-description"`
-	Tags      []string `json:"meetingTags"`
-	Title     *string  `json:"name"`
-	Photo     *string  `json:"photo"`
-	Seats     *int     `json:"seats"`
-	SeatsLeft *int     `json:"seatsLeft"`
+	Address    *string  `json:"address"`
+	City       *string  `json:"city"`
+	Start      *string  `json:"start"`
+	End        *string  `json:"end"`
+	Text       *string  `json:"meet-description"`
+	Tags       []string `json:"meetingTags"`
+	Title      *string  `json:"name"`
+	Photo      *string  `json:"photo"`
+	Seats      *int     `json:"seats"`
+	SeatsLeft  *int     `json:"seatsLeft"`
+	Approval   *bool    `json:"approvalRequired"`
+	Recurrence *string  `json:"recurrence"`
+	Lat        *float64 `json:"lat"`
+	Lon        *float64 `json:"lon"`
 }
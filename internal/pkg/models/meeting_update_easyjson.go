@@ -38,6 +38,8 @@ func easyjson7da70205DecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out
 		switch key {
 		case "meetId":
 			out.MeetId = int(in.Int())
+		case "scope":
+			out.Scope = string(in.String())
 		case "fields":
 			if in.IsNull() {
 				in.Skip()
@@ -67,6 +69,11 @@ func easyjson7da70205EncodeKonamiBackendInternalPkgModels(out *jwriter.Writer, i
 		out.RawString(prefix[1:])
 		out.Int(int(in.MeetId))
 	}
+	{
+		const prefix string = ",\"scope\":"
+		out.RawString(prefix)
+		out.String(string(in.Scope))
+	}
 	{
 		const prefix string = ",\"fields\":"
 		out.RawString(prefix)
@@ -141,6 +148,16 @@ func easyjson7da70205DecodeKonamiBackendInternalPkgModels1(in *jlexer.Lexer, out
 				}
 				*out.Like = bool(in.Bool())
 			}
+		case "isWaiting":
+			if in.IsNull() {
+				in.Skip()
+				out.Wait = nil
+			} else {
+				if out.Wait == nil {
+					out.Wait = new(bool)
+				}
+				*out.Wait = bool(in.Bool())
+			}
 		case "card":
 			if in.IsNull() {
 				in.Skip()
@@ -183,6 +200,15 @@ func easyjson7da70205EncodeKonamiBackendInternalPkgModels1(out *jwriter.Writer,
 			out.Bool(bool(*in.Like))
 		}
 	}
+	{
+		const prefix string = ",\"isWaiting\":"
+		out.RawString(prefix)
+		if in.Wait == nil {
+			out.RawString("null")
+		} else {
+			out.Bool(bool(*in.Wait))
+		}
+	}
 	{
 		const prefix string = ",\"card\":"
 		out.RawString(prefix)
@@ -326,6 +352,46 @@ func easyjson7da70205DecodeKonamiBackendInternalPkgModels2(in *jlexer.Lexer, out
 				}
 				*out.SeatsLeft = int(in.Int())
 			}
+		case "approvalRequired":
+			if in.IsNull() {
+				in.Skip()
+				out.Approval = nil
+			} else {
+				if out.Approval == nil {
+					out.Approval = new(bool)
+				}
+				*out.Approval = bool(in.Bool())
+			}
+		case "recurrence":
+			if in.IsNull() {
+				in.Skip()
+				out.Recurrence = nil
+			} else {
+				if out.Recurrence == nil {
+					out.Recurrence = new(string)
+				}
+				*out.Recurrence = string(in.String())
+			}
+		case "lat":
+			if in.IsNull() {
+				in.Skip()
+				out.Lat = nil
+			} else {
+				if out.Lat == nil {
+					out.Lat = new(float64)
+				}
+				*out.Lat = float64(in.Float64())
+			}
+		case "lon":
+			if in.IsNull() {
+				in.Skip()
+				out.Lon = nil
+			} else {
+				if out.Lon == nil {
+					out.Lon = new(float64)
+				}
+				*out.Lon = float64(in.Float64())
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -437,6 +503,42 @@ func easyjson7da70205EncodeKonamiBackendInternalPkgModels2(out *jwriter.Writer,
 			out.Int(int(*in.SeatsLeft))
 		}
 	}
+	{
+		const prefix string = ",\"approvalRequired\":"
+		out.RawString(prefix)
+		if in.Approval == nil {
+			out.RawString("null")
+		} else {
+			out.Bool(bool(*in.Approval))
+		}
+	}
+	{
+		const prefix string = ",\"recurrence\":"
+		out.RawString(prefix)
+		if in.Recurrence == nil {
+			out.RawString("null")
+		} else {
+			out.String(string(*in.Recurrence))
+		}
+	}
+	{
+		const prefix string = ",\"lat\":"
+		out.RawString(prefix)
+		if in.Lat == nil {
+			out.RawString("null")
+		} else {
+			out.Float64(float64(*in.Lat))
+		}
+	}
+	{
+		const prefix string = ",\"lon\":"
+		out.RawString(prefix)
+		if in.Lon == nil {
+			out.RawString("null")
+		} else {
+			out.Float64(float64(*in.Lon))
+		}
+	}
 	out.RawByte('}')
 }
 
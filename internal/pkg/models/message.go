@@ -3,9 +3,31 @@ package models
 
 //easyjson:json
 type Message struct {
-	Id        int    `json:"id"`
-	AuthorId  int    `json:"authorId"`
-	MeetingId int    `json:"meetId"`
-	Text      string `json:"text"`
-	Timestamp string `json:"timestamp"`
+	Id        int        `json:"id"`
+	AuthorId  int        `json:"authorId"`
+	MeetingId int        `json:"meetId"`
+	Text      string     `json:"text"`
+	Timestamp string     `json:"timestamp"`
+	Edited    bool       `json:"edited"`
+	Deleted   bool       `json:"deleted"`
+	Reactions []Reaction `json:"reactions"`
+}
+
+type Reaction struct {
+	Emoji   string `json:"emoji"`
+	UserIds []int  `json:"userIds"`
+}
+
+//easyjson:json
+type MessageEdit struct {
+	Id   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+//easyjson:json
+type MessageReaction struct {
+	MessageId int    `json:"messageId"`
+	MeetId    int    `json:"meetId"`
+	UserId    int    `json:"userId"`
+	Emoji     string `json:"emoji"`
 }
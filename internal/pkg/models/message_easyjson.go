@@ -17,7 +17,167 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson4086215fDecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out *Message) {
+func easyjson4086215fDecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out *MessageReaction) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "messageId":
+			out.MessageId = int(in.Int())
+		case "meetId":
+			out.MeetId = int(in.Int())
+		case "userId":
+			out.UserId = int(in.Int())
+		case "emoji":
+			out.Emoji = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson4086215fEncodeKonamiBackendInternalPkgModels(out *jwriter.Writer, in MessageReaction) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"messageId\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.MessageId))
+	}
+	{
+		const prefix string = ",\"meetId\":"
+		out.RawString(prefix)
+		out.Int(int(in.MeetId))
+	}
+	{
+		const prefix string = ",\"userId\":"
+		out.RawString(prefix)
+		out.Int(int(in.UserId))
+	}
+	{
+		const prefix string = ",\"emoji\":"
+		out.RawString(prefix)
+		out.String(string(in.Emoji))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v MessageReaction) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson4086215fEncodeKonamiBackendInternalPkgModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v MessageReaction) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson4086215fEncodeKonamiBackendInternalPkgModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *MessageReaction) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson4086215fDecodeKonamiBackendInternalPkgModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *MessageReaction) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson4086215fDecodeKonamiBackendInternalPkgModels(l, v)
+}
+func easyjson4086215fDecodeKonamiBackendInternalPkgModels1(in *jlexer.Lexer, out *MessageEdit) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = int(in.Int())
+		case "text":
+			out.Text = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson4086215fEncodeKonamiBackendInternalPkgModels1(out *jwriter.Writer, in MessageEdit) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Id))
+	}
+	{
+		const prefix string = ",\"text\":"
+		out.RawString(prefix)
+		out.String(string(in.Text))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v MessageEdit) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson4086215fEncodeKonamiBackendInternalPkgModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v MessageEdit) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson4086215fEncodeKonamiBackendInternalPkgModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *MessageEdit) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson4086215fDecodeKonamiBackendInternalPkgModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *MessageEdit) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson4086215fDecodeKonamiBackendInternalPkgModels1(l, v)
+}
+func easyjson4086215fDecodeKonamiBackendInternalPkgModels2(in *jlexer.Lexer, out *Message) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -46,6 +206,33 @@ func easyjson4086215fDecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out
 			out.Text = string(in.String())
 		case "timestamp":
 			out.Timestamp = string(in.String())
+		case "edited":
+			out.Edited = bool(in.Bool())
+		case "deleted":
+			out.Deleted = bool(in.Bool())
+		case "reactions":
+			if in.IsNull() {
+				in.Skip()
+				out.Reactions = nil
+			} else {
+				in.Delim('[')
+				if out.Reactions == nil {
+					if !in.IsDelim(']') {
+						out.Reactions = make([]Reaction, 0, 1)
+					} else {
+						out.Reactions = []Reaction{}
+					}
+				} else {
+					out.Reactions = (out.Reactions)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 Reaction
+					easyjson4086215fDecodeKonamiBackendInternalPkgModels3(in, &v1)
+					out.Reactions = append(out.Reactions, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -56,7 +243,7 @@ func easyjson4086215fDecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out
 		in.Consumed()
 	}
 }
-func easyjson4086215fEncodeKonamiBackendInternalPkgModels(out *jwriter.Writer, in Message) {
+func easyjson4086215fEncodeKonamiBackendInternalPkgModels2(out *jwriter.Writer, in Message) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -85,29 +272,136 @@ func easyjson4086215fEncodeKonamiBackendInternalPkgModels(out *jwriter.Writer, i
 		out.RawString(prefix)
 		out.String(string(in.Timestamp))
 	}
+	{
+		const prefix string = ",\"edited\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Edited))
+	}
+	{
+		const prefix string = ",\"deleted\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Deleted))
+	}
+	{
+		const prefix string = ",\"reactions\":"
+		out.RawString(prefix)
+		if in.Reactions == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Reactions {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				easyjson4086215fEncodeKonamiBackendInternalPkgModels3(out, v3)
+			}
+			out.RawByte(']')
+		}
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v Message) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson4086215fEncodeKonamiBackendInternalPkgModels(&w, v)
+	easyjson4086215fEncodeKonamiBackendInternalPkgModels2(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Message) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson4086215fEncodeKonamiBackendInternalPkgModels(w, v)
+	easyjson4086215fEncodeKonamiBackendInternalPkgModels2(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Message) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson4086215fDecodeKonamiBackendInternalPkgModels(&r, v)
+	easyjson4086215fDecodeKonamiBackendInternalPkgModels2(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Message) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson4086215fDecodeKonamiBackendInternalPkgModels(l, v)
+	easyjson4086215fDecodeKonamiBackendInternalPkgModels2(l, v)
+}
+func easyjson4086215fDecodeKonamiBackendInternalPkgModels3(in *jlexer.Lexer, out *Reaction) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "emoji":
+			out.Emoji = string(in.String())
+		case "userIds":
+			if in.IsNull() {
+				in.Skip()
+				out.UserIds = nil
+			} else {
+				in.Delim('[')
+				if out.UserIds == nil {
+					if !in.IsDelim(']') {
+						out.UserIds = make([]int, 0, 8)
+					} else {
+						out.UserIds = []int{}
+					}
+				} else {
+					out.UserIds = (out.UserIds)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 int
+					v4 = int(in.Int())
+					out.UserIds = append(out.UserIds, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson4086215fEncodeKonamiBackendInternalPkgModels3(out *jwriter.Writer, in Reaction) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"emoji\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Emoji))
+	}
+	{
+		const prefix string = ",\"userIds\":"
+		out.RawString(prefix)
+		if in.UserIds == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v5, v6 := range in.UserIds {
+				if v5 > 0 {
+					out.RawByte(',')
+				}
+				out.Int(int(v6))
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
 }
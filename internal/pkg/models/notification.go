@@ -0,0 +1,28 @@
+//go:generate easyjson notification.go
+package models
+
+//easyjson:json
+type Notification struct {
+	Id        int    `json:"id"`
+	Type      string `json:"type"`
+	ActorId   int    `json:"actorId"`
+	MeetId    int    `json:"meetId,omitempty"`
+	Read      bool   `json:"read"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int            `json:"unread"`
+	HasMore       bool           `json:"hasMore"`
+}
+
+//easyjson:json
+type NotificationRead struct {
+	Ids []int `json:"ids"`
+}
+
+//easyjson:json
+type NotificationSettings struct {
+	Muted []string `json:"muted"`
+}
@@ -0,0 +1,24 @@
+package models
+
+type MeetingPage struct {
+	Meetings   []Meeting `json:"meetings"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+	HasMore    bool      `json:"hasMore"`
+}
+
+type ProfilePage struct {
+	Profiles   []ProfileCard `json:"profiles"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+	HasMore    bool          `json:"hasMore"`
+}
+
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+	HasMore    bool      `json:"hasMore"`
+}
+
+type DirectMessagePage struct {
+	Messages []DirectMessage `json:"messages"`
+	HasMore  bool            `json:"hasMore"`
+}
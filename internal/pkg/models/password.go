@@ -0,0 +1,19 @@
+//go:generate easyjson password.go
+package models
+
+//easyjson:json
+type PasswordChange struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+//easyjson:json
+type PasswordResetRequest struct {
+	Login string `json:"login"`
+}
+
+//easyjson:json
+type PasswordResetConfirm struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,231 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out *PasswordResetRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "login":
+			out.Login = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels(out *jwriter.Writer, in PasswordResetRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"login\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Login))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PasswordResetRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PasswordResetRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PasswordResetRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PasswordResetRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels(l, v)
+}
+func easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels1(in *jlexer.Lexer, out *PasswordResetConfirm) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "token":
+			out.Token = string(in.String())
+		case "password":
+			out.Password = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels1(out *jwriter.Writer, in PasswordResetConfirm) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"token\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Token))
+	}
+	{
+		const prefix string = ",\"password\":"
+		out.RawString(prefix)
+		out.String(string(in.Password))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PasswordResetConfirm) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PasswordResetConfirm) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PasswordResetConfirm) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PasswordResetConfirm) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels1(l, v)
+}
+func easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels2(in *jlexer.Lexer, out *PasswordChange) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "oldPassword":
+			out.OldPassword = string(in.String())
+		case "newPassword":
+			out.NewPassword = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels2(out *jwriter.Writer, in PasswordChange) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"oldPassword\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.OldPassword))
+	}
+	{
+		const prefix string = ",\"newPassword\":"
+		out.RawString(prefix)
+		out.String(string(in.NewPassword))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PasswordChange) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PasswordChange) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonBf87f2d7EncodeKonamiBackendInternalPkgModels2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PasswordChange) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PasswordChange) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonBf87f2d7DecodeKonamiBackendInternalPkgModels2(l, v)
+}
@@ -7,6 +7,7 @@ type Profile struct {
 	City        string          `json:"city"`
 	Login       string          `json:"login"`
 	PwdHash     string          `json:"-"`
+	Email       string          `json:"-"`
 	Telegram    string          `json:"telegram"`
 	Vk          string          `json:"vk"`
 	Education   string          `json:"education"`
@@ -0,0 +1,20 @@
+//go:generate easyjson read_receipt.go
+package models
+
+//easyjson:json
+type ReadReceipt struct {
+	MeetId    int `json:"meetId"`
+	UserId    int `json:"userId"`
+	MessageId int `json:"messageId"`
+}
+
+type UnreadCount struct {
+	MeetId     int `json:"meetId"`
+	Unread     int `json:"unread"`
+	LastReadId int `json:"lastReadId"`
+}
+
+type Typing struct {
+	MeetId int `json:"meetId"`
+	UserId int `json:"userId"`
+}
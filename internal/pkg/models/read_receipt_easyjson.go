@@ -0,0 +1,99 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson862a9e59DecodeKonamiBackendInternalPkgModels(in *jlexer.Lexer, out *ReadReceipt) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "meetId":
+			out.MeetId = int(in.Int())
+		case "userId":
+			out.UserId = int(in.Int())
+		case "messageId":
+			out.MessageId = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson862a9e59EncodeKonamiBackendInternalPkgModels(out *jwriter.Writer, in ReadReceipt) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"meetId\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.MeetId))
+	}
+	{
+		const prefix string = ",\"userId\":"
+		out.RawString(prefix)
+		out.Int(int(in.UserId))
+	}
+	{
+		const prefix string = ",\"messageId\":"
+		out.RawString(prefix)
+		out.Int(int(in.MessageId))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ReadReceipt) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson862a9e59EncodeKonamiBackendInternalPkgModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ReadReceipt) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson862a9e59EncodeKonamiBackendInternalPkgModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ReadReceipt) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson862a9e59DecodeKonamiBackendInternalPkgModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ReadReceipt) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson862a9e59DecodeKonamiBackendInternalPkgModels(l, v)
+}
@@ -0,0 +1,8 @@
+//go:generate easyjson reg_request.go
+package models
+
+//easyjson:json
+type RegRequest struct {
+	MeetId int `json:"meetId"`
+	UserId int `json:"userId"`
+}
@@ -0,0 +1,16 @@
+//go:generate easyjson room_command.go
+package models
+
+const (
+	RoomSubscribe   = "subscribe"
+	RoomUnsubscribe = "unsubscribe"
+	RoomTyping      = "typing"
+	RoomRead        = "read"
+)
+
+//easyjson:json
+type RoomCommand struct {
+	Type      string `json:"type"`
+	MeetId    int    `json:"meetId"`
+	MessageId int    `json:"messageId,omitempty"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type UserSession struct {
+	Id         int64  `json:"id"`
+	UserAgent  string `json:"userAgent"`
+	Ip         string `json:"ip"`
+	CreatedAt  string `json:"createdAt"`
+	LastSeenAt string `json:"lastSeenAt"`
+	Current    bool   `json:"current"`
+}
@@ -0,0 +1,14 @@
+//go:generate easyjson waitlist.go
+package models
+
+type WaitlistEntry struct {
+	Position int           `json:"position"`
+	User     *ProfileLabel `json:"user"`
+}
+
+//easyjson:json
+type WaitlistMove struct {
+	MeetId   int `json:"meetId"`
+	UserId   int `json:"userId"`
+	Position int `json:"position"`
+}
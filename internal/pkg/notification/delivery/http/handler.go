@@ -0,0 +1,127 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"konami_backend/internal/pkg/middleware"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
+	hu "konami_backend/internal/pkg/utils/http_utils"
+	"konami_backend/logger"
+	"net/http"
+	"strconv"
+)
+
+type NotificationHandler struct {
+	NotificationUC notification.UseCase
+	Log            *logger.Logger
+	MaxReqSize     int64
+}
+
+const (
+	DefNotificationLimit = 20
+	MaxNotificationLimit = 100
+)
+
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	params := notification.FilterParams{UserId: userId}
+	params.PrevId, _ = strconv.Atoi(r.URL.Query().Get("prevId"))
+	params.UnreadOnly = r.URL.Query().Get("unread") == "true"
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = DefNotificationLimit
+	}
+	if limit > MaxNotificationLimit {
+		limit = MaxNotificationLimit
+	}
+	params.CountLimit = limit
+	page, err := h.NotificationUC.GetNotifications(params)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	if page.Notifications == nil {
+		page.Notifications = []models.Notification{}
+	}
+	hu.WriteJson(w, page)
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	req := &models.NotificationRead{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = req.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = h.NotificationUC.MarkRead(userId, req.Ids)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *NotificationHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	settings, err := h.NotificationUC.GetSettings(userId)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	hu.WriteJson(w, settings)
+}
+
+func (h *NotificationHandler) SetSettings(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	settings := &models.NotificationSettings{}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = settings.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = h.NotificationUC.SetSettings(userId, *settings)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, notification.ErrUnknownType):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+	default:
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+	}
+}
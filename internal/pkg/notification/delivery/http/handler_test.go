@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"konami_backend/internal/pkg/message"
+	"konami_backend/internal/pkg/message/broker"
+	"konami_backend/internal/pkg/middleware"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
+	"konami_backend/logger"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNotifications(t *testing.T) {
+	log := logger.NewLogger(ioutil.Discard)
+	var authArgs []middleware.RouteArgs
+	authArgs = append(authArgs, middleware.RouteArgs{Key: middleware.UserID, Value: 3})
+	authArgs = append(authArgs, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+	t.Run("GetNotifications", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := notification.NewMockUseCase(ctrl)
+		h := NotificationHandler{NotificationUC: m, Log: log}
+
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "prevId", Value: "9"})
+		args = append(args, middleware.QueryArgs{Key: "limit", Value: "500"})
+		args = append(args, middleware.QueryArgs{Key: "unread", Value: "true"})
+
+		page := models.NotificationPage{Notifications: []models.Notification{
+			{Id: 8, Type: notification.TypeLiked, ActorId: 4, MeetId: 1, CreatedAt: "2020-12-05T10:00:00.000Z"}},
+			Unread: 1}
+		m.EXPECT().GetNotifications(notification.FilterParams{UserId: 3, PrevId: 9,
+			CountLimit: MaxNotificationLimit, UnreadOnly: true}).Return(page, nil)
+		pageJSON, _ := json.Marshal(page)
+
+		apitest.New("GetNotifications").
+			Handler(middleware.SetVarsAndMux(h.GetNotifications, args, authArgs)).
+			Method("Get").
+			URL("/notifications").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(pageJSON)).
+			End()
+
+		m.EXPECT().GetNotifications(notification.FilterParams{UserId: 3, CountLimit: DefNotificationLimit}).
+			Return(models.NotificationPage{}, nil)
+		apitest.New("GetNotificationsEmpty").
+			Handler(middleware.SetVarsAndMux(h.GetNotifications, nil, authArgs)).
+			Method("Get").
+			URL("/notifications").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(`{"notifications": [], "unread": 0, "hasMore": false}`).
+			End()
+
+		apitest.New("GetNotificationsUnauthorized").
+			Handler(middleware.SetMuxVars(h.GetNotifications, nil)).
+			Method("Get").
+			URL("/notifications").
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("MarkRead", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := notification.NewMockUseCase(ctrl)
+		h := NotificationHandler{NotificationUC: m, Log: log, MaxReqSize: 10000}
+
+		m.EXPECT().MarkRead(3, []int{5, 8}).Return(nil)
+		apitest.New("MarkRead").
+			Handler(middleware.SetMuxVars(h.MarkRead, authArgs)).
+			Method("Post").
+			URL("/notifications/read").
+			Body(`{"ids": [5, 8]}`).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		m.EXPECT().MarkRead(3, nil).Return(errors.New("db error"))
+		apitest.New("MarkAllReadFails").
+			Handler(middleware.SetMuxVars(h.MarkRead, authArgs)).
+			Method("Post").
+			URL("/notifications/read").
+			Body(`{}`).
+			Expect(t).
+			Status(http.StatusInternalServerError).
+			End()
+
+		apitest.New("MarkReadNoCSRF").
+			Handler(middleware.SetMuxVars(h.MarkRead, authArgs[:1])).
+			Method("Post").
+			URL("/notifications/read").
+			Body(`{}`).
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := notification.NewMockUseCase(ctrl)
+		h := NotificationHandler{NotificationUC: m, Log: log, MaxReqSize: 10000}
+
+		m.EXPECT().GetSettings(3).Return(models.NotificationSettings{Muted: []string{notification.TypeLiked}}, nil)
+		apitest.New("GetSettings").
+			Handler(middleware.SetMuxVars(h.GetSettings, authArgs)).
+			Method("Get").
+			URL("/notifications/settings").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(`{"muted": ["liked"]}`).
+			End()
+
+		m.EXPECT().SetSettings(3, models.NotificationSettings{Muted: []string{notification.TypeChatMessage}}).Return(nil)
+		apitest.New("SetSettings").
+			Handler(middleware.SetMuxVars(h.SetSettings, authArgs)).
+			Method("Put").
+			URL("/notifications/settings").
+			Body(`{"muted": ["chatMessage"]}`).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		m.EXPECT().SetSettings(3, gomock.Any()).Return(notification.ErrUnknownType)
+		apitest.New("SetSettingsUnknownType").
+			Handler(middleware.SetMuxVars(h.SetSettings, authArgs)).
+			Method("Put").
+			URL("/notifications/settings").
+			Body(`{"muted": ["spam"]}`).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		apitest.New("SetSettingsBadBody").
+			Handler(middleware.SetMuxVars(h.SetSettings, authArgs)).
+			Method("Put").
+			URL("/notifications/settings").
+			Body(`{"muted": `).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("Push", func(t *testing.T) {
+		shared := broker.NewMemoryBroker()
+		events := make(chan message.Event, 1)
+		require.NoError(t, shared.Subscribe(func(e message.Event) { events <- e }))
+		p := BrokerPusher{Broker: shared, Log: log}
+
+		n := models.Notification{Id: 8, Type: notification.TypeSubscribed, ActorId: 4}
+		p.Push(3, n)
+		select {
+		case e := <-events:
+			var frame struct {
+				Payload models.Notification `json:"payload"`
+				MsgType string              `json:"type"`
+			}
+			require.NoError(t, json.Unmarshal(e.Data, &frame))
+			require.Equal(t, 3, e.UserId)
+			require.Equal(t, "notification", frame.MsgType)
+			require.Equal(t, n, frame.Payload)
+		case <-time.After(time.Second):
+			t.Fatal("notification was not pushed")
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"konami_backend/internal/pkg/message"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/logger"
+)
+
+// BrokerPusher sends notifications over the chat websocket of the user
+type BrokerPusher struct {
+	Broker message.Broker
+	Log    *logger.Logger
+}
+
+func (p *BrokerPusher) Push(userId int, n models.Notification) {
+	data, err := message.EncodeFrame("notification", n)
+	if err == nil {
+		err = p.Broker.Publish(message.Event{UserId: userId, Data: data})
+	}
+	if err != nil {
+		p.Log.LogError("notification/delivery/http", "Push", err)
+	}
+}
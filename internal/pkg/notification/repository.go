@@ -0,0 +1,26 @@
+//go:generate mockgen -source=repository.go -destination=./repositoty_mock.go -package=notification
+package notification
+
+import (
+	"errors"
+	"konami_backend/internal/pkg/models"
+)
+
+var ErrUnknownType = errors.New("unknown notification type")
+
+type FilterParams struct {
+	UserId     int
+	PrevId     int
+	CountLimit int
+	UnreadOnly bool
+}
+
+type Repository interface {
+	Create(userId int, n models.Notification) (int, error)
+	GetNotifications(params FilterParams) ([]models.Notification, error)
+	CountUnread(userId int) (int, error)
+	// MarkRead marks the listed notifications read, or all of them when ids is empty
+	MarkRead(userId int, ids []int) error
+	GetMuted(userId int) ([]string, error)
+	SetMuted(userId int, types []string) error
+}
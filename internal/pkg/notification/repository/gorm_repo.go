@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
+	"time"
+)
+
+type NotificationGormRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationGormRepo(db *gorm.DB) notification.Repository {
+	return &NotificationGormRepo{db: db}
+}
+
+type Notification struct {
+	Id        int `gorm:"primaryKey;autoIncrement;"`
+	UserId    int `gorm:"index"`
+	Type      string
+	ActorId   int
+	MeetId    int
+	Read      bool
+	CreatedAt time.Time
+}
+
+func (n *Notification) TableName() string {
+	return "notifications"
+}
+
+// Mute turns off one notification type for the user
+type Mute struct {
+	Id     int    `gorm:"primaryKey;autoIncrement;"`
+	UserId int    `gorm:"uniqueIndex:mute_user_type;"`
+	Type   string `gorm:"uniqueIndex:mute_user_type;"`
+}
+
+func (m *Mute) TableName() string {
+	return "notification_mutes"
+}
+
+func ToModel(obj Notification) models.Notification {
+	return models.Notification{
+		Id:        obj.Id,
+		Type:      obj.Type,
+		ActorId:   obj.ActorId,
+		MeetId:    obj.MeetId,
+		Read:      obj.Read,
+		CreatedAt: obj.CreatedAt.UTC().Format("2006-01-02T15:04:05.000Z0700"),
+	}
+}
+
+func (h *NotificationGormRepo) Create(userId int, n models.Notification) (int, error) {
+	obj := Notification{
+		UserId:  userId,
+		Type:    n.Type,
+		ActorId: n.ActorId,
+		MeetId:  n.MeetId,
+	}
+	var err error
+	obj.CreatedAt, err = time.Parse("2006-01-02T15:04:05.000Z0700", n.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	err = h.db.Create(&obj).Error
+	if err != nil {
+		return 0, err
+	}
+	return obj.Id, nil
+}
+
+func (h *NotificationGormRepo) GetNotifications(params notification.FilterParams) ([]models.Notification, error) {
+	var notifications []Notification
+	bd := h.db.Where("user_id = ?", params.UserId)
+	if params.PrevId > 0 {
+		bd = bd.Where("id < ?", params.PrevId)
+	}
+	if params.UnreadOnly {
+		bd = bd.Where("read = ?", false)
+	}
+	if params.CountLimit > 0 {
+		bd = bd.Limit(params.CountLimit)
+	}
+	err := bd.Order("id DESC").Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.Notification, len(notifications))
+	for i, n := range notifications {
+		res[i] = ToModel(n)
+	}
+	return res, nil
+}
+
+func (h *NotificationGormRepo) CountUnread(userId int) (int, error) {
+	var count int64
+	err := h.db.Model(&Notification{}).
+		Where("user_id = ?", userId).
+		Where("read = ?", false).
+		Count(&count).Error
+	return int(count), err
+}
+
+func (h *NotificationGormRepo) MarkRead(userId int, ids []int) error {
+	bd := h.db.Model(&Notification{}).
+		Where("user_id = ?", userId).
+		Where("read = ?", false)
+	if len(ids) > 0 {
+		bd = bd.Where("id IN ?", ids)
+	}
+	return bd.Update("read", true).Error
+}
+
+func (h *NotificationGormRepo) GetMuted(userId int) ([]string, error) {
+	var mutes []Mute
+	err := h.db.Where("user_id = ?", userId).Order("id ASC").Find(&mutes).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, len(mutes))
+	for i, m := range mutes {
+		res[i] = m.Type
+	}
+	return res, nil
+}
+
+// SetMuted replaces the whole list of muted types
+func (h *NotificationGormRepo) SetMuted(userId int, types []string) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userId).Delete(&Mute{}).Error
+		if err != nil || len(types) == 0 {
+			return err
+		}
+		mutes := make([]Mute, len(types))
+		for i, t := range types {
+			mutes[i] = Mute{UserId: userId, Type: t}
+		}
+		return tx.Create(&mutes).Error
+	})
+}
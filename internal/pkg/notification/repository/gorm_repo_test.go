@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
+	"testing"
+	"time"
+)
+
+type Suite struct {
+	suite.Suite
+	DB   *gorm.DB
+	mock sqlmock.Sqlmock
+
+	bdError error
+}
+
+func (s *Suite) SetupSuite() {
+	var db *sql.DB
+	var err error
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(postgres.New(postgres.Config{
+		DriverName:           "postgres",
+		DSN:                  "sqlmock_db_0",
+		PreferSimpleProtocol: true,
+		Conn:                 db,
+	}), &gorm.Config{})
+	require.NoError(s.T(), err)
+
+	s.bdError = errors.New("some bd error")
+}
+
+func (s *Suite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestNotifications(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestCreate() {
+	repo := NewNotificationGormRepo(s.DB)
+	n := models.Notification{Type: notification.TypeLiked, ActorId: 4, MeetId: 1,
+		CreatedAt: "2020-12-05T10:00:00.000Z"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"notifications\"").
+		WithArgs(3, notification.TypeLiked, 4, 1, false, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	s.mock.ExpectCommit()
+	id, err := repo.Create(3, n)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 10, id)
+
+	n.CreatedAt = "bad"
+	_, err = repo.Create(3, n)
+	require.Error(s.T(), err)
+}
+
+func (s *Suite) TestGetNotifications() {
+	repo := NewNotificationGormRepo(s.DB)
+	ts := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery("SELECT \\* FROM \"notifications\" WHERE user_id = (.+) AND id < (.+) "+
+		"AND read = (.+) ORDER BY id DESC LIMIT 3").
+		WithArgs(3, 9, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "actor_id", "created_at"}).
+			AddRow(8, notification.TypeSubscribed, 4, ts).AddRow(5, notification.TypeLiked, 6, ts))
+
+	list, err := repo.GetNotifications(notification.FilterParams{UserId: 3, PrevId: 9, CountLimit: 3, UnreadOnly: true})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []models.Notification{
+		{Id: 8, Type: notification.TypeSubscribed, ActorId: 4, CreatedAt: "2020-12-05T10:00:00.000Z"},
+		{Id: 5, Type: notification.TypeLiked, ActorId: 6, CreatedAt: "2020-12-05T10:00:00.000Z"},
+	}, list)
+
+	s.mock.ExpectQuery("SELECT \\* FROM \"notifications\"").
+		WillReturnError(s.bdError)
+	_, err = repo.GetNotifications(notification.FilterParams{UserId: 3})
+	require.Equal(s.T(), s.bdError, err)
+}
+
+func (s *Suite) TestUnread() {
+	repo := NewNotificationGormRepo(s.DB)
+	s.mock.ExpectQuery("SELECT count\\(1\\) FROM \"notifications\" WHERE user_id = (.+) AND read = (.+)").
+		WithArgs(3, false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	count, err := repo.CountUnread(3)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, count)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"notifications\" SET \"read\"=(.+) WHERE user_id = (.+) AND read = (.+) "+
+		"AND id IN \\((.+),(.+)\\)").
+		WithArgs(true, 3, false, 5, 8).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.MarkRead(3, []int{5, 8}))
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"notifications\" SET \"read\"=(.+) WHERE user_id = (.+) AND read = (.+)").
+		WithArgs(true, 3, false).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.MarkRead(3, nil))
+}
+
+func (s *Suite) TestMuted() {
+	repo := NewNotificationGormRepo(s.DB)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"notification_mutes\" WHERE user_id = (.+)").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery("INSERT INTO \"notification_mutes\"").
+		WithArgs(3, notification.TypeLiked, 3, notification.TypeChatMessage).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.SetMuted(3, []string{notification.TypeLiked, notification.TypeChatMessage}))
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"notification_mutes\"").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+	require.NoError(s.T(), repo.SetMuted(3, nil))
+
+	s.mock.ExpectQuery("SELECT \\* FROM \"notification_mutes\" WHERE user_id = (.+) ORDER BY id ASC").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type"}).AddRow(1, 3, notification.TypeLiked))
+	muted, err := repo.GetMuted(3)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []string{notification.TypeLiked}, muted)
+}
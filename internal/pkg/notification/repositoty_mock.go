@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+
+// Package notification is a generated GoMock package.
+package notification
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	models "konami_backend/internal/pkg/models"
+	reflect "reflect"
+)
+
+// MockRepository is a mock of Repository interface
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockRepository) Create(userId int, n models.Notification) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userId, n)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockRepositoryMockRecorder) Create(userId, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), userId, n)
+}
+
+// GetNotifications mocks base method
+func (m *MockRepository) GetNotifications(params FilterParams) ([]models.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", params)
+	ret0, _ := ret[0].([]models.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications
+func (mr *MockRepositoryMockRecorder) GetNotifications(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockRepository)(nil).GetNotifications), params)
+}
+
+// CountUnread mocks base method
+func (m *MockRepository) CountUnread(userId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnread", userId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnread indicates an expected call of CountUnread
+func (mr *MockRepositoryMockRecorder) CountUnread(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnread", reflect.TypeOf((*MockRepository)(nil).CountUnread), userId)
+}
+
+// MarkRead mocks base method
+func (m *MockRepository) MarkRead(userId int, ids []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", userId, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead
+func (mr *MockRepositoryMockRecorder) MarkRead(userId, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockRepository)(nil).MarkRead), userId, ids)
+}
+
+// GetMuted mocks base method
+func (m *MockRepository) GetMuted(userId int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMuted", userId)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMuted indicates an expected call of GetMuted
+func (mr *MockRepositoryMockRecorder) GetMuted(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMuted", reflect.TypeOf((*MockRepository)(nil).GetMuted), userId)
+}
+
+// SetMuted mocks base method
+func (m *MockRepository) SetMuted(userId int, types []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMuted", userId, types)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMuted indicates an expected call of SetMuted
+func (mr *MockRepositoryMockRecorder) SetMuted(userId, types interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMuted", reflect.TypeOf((*MockRepository)(nil).SetMuted), userId, types)
+}
@@ -0,0 +1,53 @@
+//go:generate mockgen -source=usecase.go -destination=./usecase_mock.go -package=notification
+package notification
+
+import (
+	"konami_backend/internal/pkg/meeting"
+	"konami_backend/internal/pkg/models"
+)
+
+const (
+	TypeSubscribed       = "subscribed"
+	TypeLiked            = meeting.EventLiked
+	TypeRegistered       = meeting.EventRegistered
+	TypeRegRequested     = meeting.EventRegRequested
+	TypeChatMessage      = "chatMessage"
+	TypeWaitlistPromoted = "waitlistPromoted"
+	TypeReminder         = "reminder"
+)
+
+var Types = []string{
+	TypeSubscribed,
+	TypeLiked,
+	TypeRegistered,
+	TypeRegRequested,
+	TypeChatMessage,
+	TypeWaitlistPromoted,
+	TypeReminder,
+}
+
+// Event is something ActorId did that UserId should hear about
+type Event struct {
+	Type    string
+	UserId  int
+	ActorId int
+	MeetId  int
+}
+
+// Emitter is what other use cases depend on, delivery failures are only logged
+type Emitter interface {
+	Emit(e Event)
+}
+
+// Pusher delivers a stored notification to the live connections of the user
+type Pusher interface {
+	Push(userId int, n models.Notification)
+}
+
+type UseCase interface {
+	Emitter
+	GetNotifications(params FilterParams) (models.NotificationPage, error)
+	MarkRead(userId int, ids []int) error
+	GetSettings(userId int) (models.NotificationSettings, error)
+	SetSettings(userId int, settings models.NotificationSettings) error
+}
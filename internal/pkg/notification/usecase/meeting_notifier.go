@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"konami_backend/internal/pkg/meeting"
+	"konami_backend/internal/pkg/notification"
+)
+
+// MeetingNotifier turns meeting use case callbacks into notifications
+type MeetingNotifier struct {
+	Emitter notification.Emitter
+}
+
+func NewMeetingNotifier(emitter notification.Emitter) meeting.Notifier {
+	return &MeetingNotifier{Emitter: emitter}
+}
+
+func (n *MeetingNotifier) WaitlistPromoted(meetId int, userIds []int) {
+	for _, userId := range userIds {
+		n.Emitter.Emit(notification.Event{
+			Type:   notification.TypeWaitlistPromoted,
+			UserId: userId,
+			MeetId: meetId,
+		})
+	}
+}
+
+func (n *MeetingNotifier) MeetingEvent(eventType string, meetId int, authorId int, userId int) {
+	n.Emitter.Emit(notification.Event{
+		Type:    eventType,
+		UserId:  authorId,
+		ActorId: userId,
+		MeetId:  meetId,
+	})
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
+	"konami_backend/logger"
+	"time"
+)
+
+type NotificationUseCase struct {
+	repo   notification.Repository
+	pusher notification.Pusher
+	log    *logger.Logger
+	now    func() time.Time
+}
+
+func NewNotificationUseCase(repo notification.Repository,
+	pusher notification.Pusher,
+	log *logger.Logger) notification.UseCase {
+
+	return NotificationUseCase{repo: repo, pusher: pusher, log: log, now: time.Now}
+}
+
+// Emit stores the notification unless the recipient is the actor or muted the type
+func (u NotificationUseCase) Emit(e notification.Event) {
+	if e.UserId == e.ActorId {
+		return
+	}
+	muted, err := u.repo.GetMuted(e.UserId)
+	if err != nil {
+		u.log.LogError("notification/usecase", "Emit", err)
+		return
+	}
+	for _, t := range muted {
+		if t == e.Type {
+			return
+		}
+	}
+	n := models.Notification{
+		Type:      e.Type,
+		ActorId:   e.ActorId,
+		MeetId:    e.MeetId,
+		CreatedAt: u.now().UTC().Format("2006-01-02T15:04:05.000Z0700"),
+	}
+	n.Id, err = u.repo.Create(e.UserId, n)
+	if err != nil {
+		u.log.LogError("notification/usecase", "Emit", err)
+		return
+	}
+	if u.pusher != nil {
+		u.pusher.Push(e.UserId, n)
+	}
+}
+
+// GetNotifications returns a page of the newest notifications before params.PrevId
+func (u NotificationUseCase) GetNotifications(params notification.FilterParams) (models.NotificationPage, error) {
+	limit := params.CountLimit
+	params.CountLimit++
+	list, err := u.repo.GetNotifications(params)
+	if err != nil {
+		return models.NotificationPage{}, err
+	}
+	page := models.NotificationPage{Notifications: list}
+	if len(list) > limit {
+		page.Notifications, page.HasMore = list[:limit], true
+	}
+	page.Unread, err = u.repo.CountUnread(params.UserId)
+	if err != nil {
+		return models.NotificationPage{}, err
+	}
+	return page, nil
+}
+
+func (u NotificationUseCase) MarkRead(userId int, ids []int) error {
+	return u.repo.MarkRead(userId, ids)
+}
+
+func (u NotificationUseCase) GetSettings(userId int) (models.NotificationSettings, error) {
+	muted, err := u.repo.GetMuted(userId)
+	if err != nil {
+		return models.NotificationSettings{}, err
+	}
+	return models.NotificationSettings{Muted: muted}, nil
+}
+
+func (u NotificationUseCase) SetSettings(userId int, settings models.NotificationSettings) error {
+	seen := map[string]bool{}
+	muted := make([]string, 0, len(settings.Muted))
+	for _, t := range settings.Muted {
+		if !isKnownType(t) {
+			return notification.ErrUnknownType
+		}
+		if !seen[t] {
+			seen[t] = true
+			muted = append(muted, t)
+		}
+	}
+	return u.repo.SetMuted(userId, muted)
+}
+
+func isKnownType(t string) bool {
+	for _, known := range notification.Types {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
+	"konami_backend/logger"
+	"testing"
+	"time"
+)
+
+type pushed struct {
+	userId int
+	n      models.Notification
+}
+
+type fakePusher struct {
+	pushed []pushed
+}
+
+func (p *fakePusher) Push(userId int, n models.Notification) {
+	p.pushed = append(p.pushed, pushed{userId: userId, n: n})
+}
+
+func TestNotification(t *testing.T) {
+	log := logger.NewLogger(ioutil.Discard)
+
+	t.Run("Emit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := notification.NewMockRepository(ctrl)
+		pusher := &fakePusher{}
+		uc := NotificationUseCase{repo: repo, pusher: pusher, log: log, now: func() time.Time {
+			return time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+		}}
+
+		n := models.Notification{Type: notification.TypeLiked, ActorId: 4, MeetId: 1,
+			CreatedAt: "2020-12-05T10:00:00.000Z"}
+		repo.EXPECT().GetMuted(3).Return([]string{notification.TypeChatMessage}, nil)
+		repo.EXPECT().Create(3, n).Return(10, nil)
+		uc.Emit(notification.Event{Type: notification.TypeLiked, UserId: 3, ActorId: 4, MeetId: 1})
+		n.Id = 10
+		assert.Equal(t, []pushed{{userId: 3, n: n}}, pusher.pushed)
+
+		repo.EXPECT().GetMuted(3).Return([]string{notification.TypeChatMessage}, nil)
+		uc.Emit(notification.Event{Type: notification.TypeChatMessage, UserId: 3, ActorId: 4, MeetId: 1})
+
+		uc.Emit(notification.Event{Type: notification.TypeLiked, UserId: 3, ActorId: 3, MeetId: 1})
+
+		repo.EXPECT().GetMuted(3).Return(nil, nil)
+		repo.EXPECT().Create(3, gomock.Any()).Return(0, errors.New("db error"))
+		uc.Emit(notification.Event{Type: notification.TypeSubscribed, UserId: 3, ActorId: 4})
+		assert.Len(t, pusher.pushed, 1)
+	})
+
+	t.Run("GetNotifications", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := notification.NewMockRepository(ctrl)
+		uc := NewNotificationUseCase(repo, nil, log)
+
+		list := []models.Notification{{Id: 8}, {Id: 5}, {Id: 2}}
+		repo.EXPECT().GetNotifications(notification.FilterParams{UserId: 3, CountLimit: 3}).Return(list, nil)
+		repo.EXPECT().CountUnread(3).Return(1, nil)
+		page, err := uc.GetNotifications(notification.FilterParams{UserId: 3, CountLimit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, models.NotificationPage{Notifications: list[:2], Unread: 1, HasMore: true}, page)
+
+		repo.EXPECT().GetNotifications(notification.FilterParams{UserId: 3, CountLimit: 5}).Return(list, nil)
+		repo.EXPECT().CountUnread(3).Return(1, nil)
+		page, err = uc.GetNotifications(notification.FilterParams{UserId: 3, CountLimit: 4})
+		assert.NoError(t, err)
+		assert.False(t, page.HasMore)
+		assert.Len(t, page.Notifications, 3)
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := notification.NewMockRepository(ctrl)
+		uc := NewNotificationUseCase(repo, nil, log)
+
+		repo.EXPECT().SetMuted(3, []string{notification.TypeLiked, notification.TypeChatMessage}).Return(nil)
+		err := uc.SetSettings(3, models.NotificationSettings{Muted: []string{
+			notification.TypeLiked, notification.TypeChatMessage, notification.TypeLiked}})
+		assert.NoError(t, err)
+
+		err = uc.SetSettings(3, models.NotificationSettings{Muted: []string{"spam"}})
+		assert.Equal(t, notification.ErrUnknownType, err)
+
+		repo.EXPECT().GetMuted(3).Return([]string{notification.TypeLiked}, nil)
+		settings, err := uc.GetSettings(3)
+		assert.NoError(t, err)
+		assert.Equal(t, models.NotificationSettings{Muted: []string{notification.TypeLiked}}, settings)
+
+		repo.EXPECT().MarkRead(3, []int{5}).Return(nil)
+		assert.NoError(t, uc.MarkRead(3, []int{5}))
+	})
+
+	t.Run("MeetingNotifier", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		emitter := notification.NewMockUseCase(ctrl)
+		n := NewMeetingNotifier(emitter)
+
+		emitter.EXPECT().Emit(notification.Event{Type: notification.TypeWaitlistPromoted, UserId: 5, MeetId: 1})
+		emitter.EXPECT().Emit(notification.Event{Type: notification.TypeWaitlistPromoted, UserId: 6, MeetId: 1})
+		n.WaitlistPromoted(1, []int{5, 6})
+
+		emitter.EXPECT().Emit(notification.Event{Type: notification.TypeRegistered, UserId: 3, ActorId: 4, MeetId: 1})
+		n.MeetingEvent(notification.TypeRegistered, 1, 3, 4)
+	})
+}
@@ -0,0 +1,174 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase.go
+
+// Package notification is a generated GoMock package.
+package notification
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	models "konami_backend/internal/pkg/models"
+	reflect "reflect"
+)
+
+// MockEmitter is a mock of Emitter interface
+type MockEmitter struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmitterMockRecorder
+}
+
+// MockEmitterMockRecorder is the mock recorder for MockEmitter
+type MockEmitterMockRecorder struct {
+	mock *MockEmitter
+}
+
+// NewMockEmitter creates a new mock instance
+func NewMockEmitter(ctrl *gomock.Controller) *MockEmitter {
+	mock := &MockEmitter{ctrl: ctrl}
+	mock.recorder = &MockEmitterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEmitter) EXPECT() *MockEmitterMockRecorder {
+	return m.recorder
+}
+
+// Emit mocks base method
+func (m *MockEmitter) Emit(e Event) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Emit", e)
+}
+
+// Emit indicates an expected call of Emit
+func (mr *MockEmitterMockRecorder) Emit(e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Emit", reflect.TypeOf((*MockEmitter)(nil).Emit), e)
+}
+
+// MockPusher is a mock of Pusher interface
+type MockPusher struct {
+	ctrl     *gomock.Controller
+	recorder *MockPusherMockRecorder
+}
+
+// MockPusherMockRecorder is the mock recorder for MockPusher
+type MockPusherMockRecorder struct {
+	mock *MockPusher
+}
+
+// NewMockPusher creates a new mock instance
+func NewMockPusher(ctrl *gomock.Controller) *MockPusher {
+	mock := &MockPusher{ctrl: ctrl}
+	mock.recorder = &MockPusherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPusher) EXPECT() *MockPusherMockRecorder {
+	return m.recorder
+}
+
+// Push mocks base method
+func (m *MockPusher) Push(userId int, n models.Notification) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Push", userId, n)
+}
+
+// Push indicates an expected call of Push
+func (mr *MockPusherMockRecorder) Push(userId, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockPusher)(nil).Push), userId, n)
+}
+
+// MockUseCase is a mock of UseCase interface
+type MockUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockUseCaseMockRecorder
+}
+
+// MockUseCaseMockRecorder is the mock recorder for MockUseCase
+type MockUseCaseMockRecorder struct {
+	mock *MockUseCase
+}
+
+// NewMockUseCase creates a new mock instance
+func NewMockUseCase(ctrl *gomock.Controller) *MockUseCase {
+	mock := &MockUseCase{ctrl: ctrl}
+	mock.recorder = &MockUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Emit mocks base method
+func (m *MockUseCase) Emit(e Event) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Emit", e)
+}
+
+// Emit indicates an expected call of Emit
+func (mr *MockUseCaseMockRecorder) Emit(e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Emit", reflect.TypeOf((*MockUseCase)(nil).Emit), e)
+}
+
+// GetNotifications mocks base method
+func (m *MockUseCase) GetNotifications(params FilterParams) (models.NotificationPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", params)
+	ret0, _ := ret[0].(models.NotificationPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications
+func (mr *MockUseCaseMockRecorder) GetNotifications(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockUseCase)(nil).GetNotifications), params)
+}
+
+// MarkRead mocks base method
+func (m *MockUseCase) MarkRead(userId int, ids []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", userId, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead
+func (mr *MockUseCaseMockRecorder) MarkRead(userId, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockUseCase)(nil).MarkRead), userId, ids)
+}
+
+// GetSettings mocks base method
+func (m *MockUseCase) GetSettings(userId int) (models.NotificationSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", userId)
+	ret0, _ := ret[0].(models.NotificationSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings
+func (mr *MockUseCaseMockRecorder) GetSettings(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockUseCase)(nil).GetSettings), userId)
+}
+
+// SetSettings mocks base method
+func (m *MockUseCase) SetSettings(userId int, settings models.NotificationSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSettings", userId, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSettings indicates an expected call of SetSettings
+func (mr *MockUseCaseMockRecorder) SetSettings(userId, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSettings", reflect.TypeOf((*MockUseCase)(nil).SetSettings), userId, settings)
+}
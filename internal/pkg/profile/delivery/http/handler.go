@@ -4,20 +4,41 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	sessionPkg "konami_backend/auth/pkg/session"
 	"konami_backend/internal/pkg/middleware"
 	"konami_backend/internal/pkg/models"
 	"konami_backend/internal/pkg/profile"
+	"konami_backend/internal/pkg/utils/cursor"
 	hu "konami_backend/internal/pkg/utils/http_utils"
 	"konami_backend/proto/auth"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type ProfileHandler struct {
 	ProfileUC  profile.UseCase
 	AuthClient auth.AuthCheckerClient
 	MaxReqSize int64
+	Cursors    *cursor.Signer
+}
+
+const (
+	CursorById = "id"
+	// MinPasswordLen applies to every password a user sets
+	MinPasswordLen = 6
+)
+
+func validPassword(password string) bool {
+	return strings.TrimSpace(password) != "" && utf8.RuneCountInString(password) >= MinPasswordLen
+}
+
+func writeWeakPassword(w http.ResponseWriter) {
+	hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "password is too short"})
 }
 
 func GetQueryParams(r *http.Request) profile.FilterParams {
@@ -39,6 +60,50 @@ func GetQueryParams(r *http.Request) profile.FilterParams {
 	return res
 }
 
+// GetPageParams applies the cursor on top of prevId. Without a limit the
+// whole list is returned, as before; otherwise one extra item is requested
+// to find out whether there is a next page.
+func (h *ProfileHandler) GetPageParams(r *http.Request) (profile.FilterParams, error) {
+	params := GetQueryParams(r)
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		c, err := h.Cursors.Decode(token, CursorById)
+		if err != nil {
+			return params, err
+		}
+		params.PrevId = c.Id
+	}
+	if params.CountLimit > 0 {
+		params.CountLimit++
+	}
+	return params, nil
+}
+
+func (h *ProfileHandler) Page(users []models.ProfileCard, params profile.FilterParams) models.ProfilePage {
+	page := models.ProfilePage{Profiles: users}
+	if page.Profiles == nil {
+		page.Profiles = []models.ProfileCard{}
+	}
+	if params.CountLimit == 0 || len(users) < params.CountLimit {
+		return page
+	}
+	page.Profiles = users[:params.CountLimit-1]
+	page.HasMore = true
+	last := page.Profiles[len(page.Profiles)-1]
+	page.NextCursor = h.Cursors.Encode(cursor.Cursor{Kind: CursorById, Id: last.Label.Id})
+	return page
+}
+
+// WritePage keeps answering legacy clients with a bare array
+func (h *ProfileHandler) WritePage(w http.ResponseWriter, r *http.Request,
+	users []models.ProfileCard, params profile.FilterParams) {
+	page := h.Page(users, params)
+	if hu.PageRequested(r) {
+		hu.WriteJson(w, page)
+		return
+	}
+	hu.WriteJson(w, page.Profiles)
+}
+
 func (h *ProfileHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 	var creds models.Credentials
 	buf := new(bytes.Buffer)
@@ -50,17 +115,29 @@ func (h *ProfileHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
 		return
 	}
+	if !validPassword(creds.Password) {
+		writeWeakPassword(w)
+		return
+	}
 	_, err = h.ProfileUC.Validate(creds)
 	if err != profile.ErrUserNonExistent {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusConflict, ErrMsg: "login has already been taken"})
 		return
 	}
 	userId, err := h.ProfileUC.SignUp(creds)
+	if errors.Is(err, profile.ErrLoginTaken) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusConflict, ErrMsg: "login has already been taken"})
+		return
+	}
+	if errors.Is(err, profile.ErrEmailTaken) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusConflict, ErrMsg: "email has already been taken"})
+		return
+	}
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
 		return
 	}
-	session, err := h.AuthClient.Create(context.Background(), &auth.Session{UserId: int64(userId)})
+	session, err := h.AuthClient.Create(context.Background(), h.newSession(r, userId))
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
@@ -100,7 +177,7 @@ func (h *ProfileHandler) LogIn(w http.ResponseWriter, r *http.Request) {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	session, err := h.AuthClient.Create(context.Background(), &auth.Session{UserId: int64(userId)})
+	session, err := h.AuthClient.Create(context.Background(), h.newSession(r, userId))
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
@@ -124,6 +201,280 @@ func (h *ProfileHandler) LogOut(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *ProfileHandler) newSession(r *http.Request, userId int) *auth.Session {
+	return &auth.Session{UserId: int64(userId), UserAgent: r.UserAgent(), Ip: hu.ClientIP(r)}
+}
+
+func (h *ProfileHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthToken).(string)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	list, err := h.AuthClient.List(context.Background(), &auth.SessionToken{Token: token})
+	if status.Code(err) == codes.NotFound {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	res := make([]models.UserSession, len(list.Sessions))
+	for i, s := range list.Sessions {
+		res[i] = models.UserSession{
+			Id:         s.Id,
+			UserAgent:  s.UserAgent,
+			Ip:         s.Ip,
+			CreatedAt:  time.Unix(s.CreatedAt, 0).UTC().Format("2006-01-02T15:04:05.000Z0700"),
+			LastSeenAt: time.Unix(s.LastSeenAt, 0).UTC().Format("2006-01-02T15:04:05.000Z0700"),
+			Current:    s.Current,
+		}
+	}
+	hu.WriteJson(w, res)
+}
+
+func (h *ProfileHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	sessionId, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	_, err = h.AuthClient.Revoke(context.Background(),
+		&auth.RevokeRequest{UserId: int64(userId), SessionId: sessionId})
+	if status.Code(err) == codes.NotFound {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
+		return
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// RevokeOtherSessions logs the user out everywhere except the current session
+func (h *ProfileHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	token, _ := r.Context().Value(middleware.AuthToken).(string)
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	_, err := h.AuthClient.RevokeAll(context.Background(),
+		&auth.RevokeAllRequest{UserId: int64(userId), ExceptToken: token})
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ChangePassword requires the current password and logs out every other session
+func (h *ProfileHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	token, _ := r.Context().Value(middleware.AuthToken).(string)
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	var change models.PasswordChange
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = change.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	if !validPassword(change.NewPassword) {
+		writeWeakPassword(w)
+		return
+	}
+	err = h.ProfileUC.ChangePassword(userId, change.OldPassword, change.NewPassword)
+	if errors.Is(err, profile.ErrInvalidCredentials) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "invalid credentials"})
+		return
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	_, err = h.AuthClient.RevokeAll(context.Background(),
+		&auth.RevokeAllRequest{UserId: int64(userId), ExceptToken: token})
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// RequestPasswordReset answers the same way whether the login exists or not
+func (h *ProfileHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetRequest
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = req.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil || req.Login == "" {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = h.ProfileUC.RequestPasswordReset(req.Login)
+	if err != nil && !errors.Is(err, profile.ErrUserNonExistent) && !errors.Is(err, profile.ErrNoResetAddress) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ProfileHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var confirm models.PasswordResetConfirm
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = confirm.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	if !validPassword(confirm.Password) {
+		writeWeakPassword(w)
+		return
+	}
+	userId, err := h.ProfileUC.ResetPassword(confirm.Token, confirm.Password)
+	if errors.Is(err, profile.ErrInvalidResetToken) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "invalid or expired token"})
+		return
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	_, err = h.AuthClient.RevokeAll(context.Background(), &auth.RevokeAllRequest{UserId: int64(userId)})
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ProfileHandler) GetEmail(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	res, err := h.ProfileUC.GetEmail(userId)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	hu.WriteJson(w, res)
+}
+
+func (h *ProfileHandler) SetEmail(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	var update models.EmailUpdate
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = update.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = h.ProfileUC.SetEmail(userId, update.Email)
+	h.writeEmailError(w, err)
+}
+
+func (h *ProfileHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middleware.UserID).(int)
+	if !ok {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized})
+		return
+	}
+	tokenValid, ok := r.Context().Value(middleware.CSRFValid).(bool)
+	if !ok || !tokenValid {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusUnauthorized, ErrMsg: "Invalid CSRF token"})
+		return
+	}
+	err := h.ProfileUC.ResendVerification(userId)
+	h.writeEmailError(w, err)
+}
+
+func (h *ProfileHandler) writeEmailError(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, profile.ErrInvalidEmail):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "invalid email"})
+	case errors.Is(err, profile.ErrEmailTaken):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusConflict, ErrMsg: "email has already been taken"})
+	case errors.Is(err, profile.ErrEmailVerified):
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusConflict, ErrMsg: "email is already verified"})
+	default:
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+	}
+}
+
+// VerifyEmail needs no session, the link may be opened on another device
+func (h *ProfileHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var verify models.EmailVerify
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.MaxReqSize))
+	if err == nil {
+		err = verify.UnmarshalJSON(buf.Bytes())
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest})
+		return
+	}
+	err = h.ProfileUC.VerifyEmail(verify.Token)
+	if errors.Is(err, profile.ErrInvalidVerificationToken) {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: "invalid or expired token"})
+		return
+	}
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func (h *ProfileHandler) UploadUserPic(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(middleware.UserID).(int)
 	if !ok {
@@ -173,23 +524,31 @@ func (h *ProfileHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProfileHandler) GetPeople(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	users, err := h.ProfileUC.GetAll(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusInternalServerError})
 		return
 	}
-	hu.WriteJson(w, users)
+	h.WritePage(w, r, users, params)
 }
 
 func (h *ProfileHandler) GetUserSubscriptions(w http.ResponseWriter, r *http.Request) {
-	params := GetQueryParams(r)
+	params, err := h.GetPageParams(r)
+	if err != nil {
+		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusBadRequest, ErrMsg: err.Error()})
+		return
+	}
 	users, err := h.ProfileUC.GetUserSubscriptions(params)
 	if err != nil {
 		hu.WriteError(w, &hu.ErrResponse{RespCode: http.StatusNotFound})
 		return
 	}
-	hu.WriteJson(w, users)
+	h.WritePage(w, r, users, params)
 }
 
 func (h *ProfileHandler) CreateUserSubscription(w http.ResponseWriter, r *http.Request) {
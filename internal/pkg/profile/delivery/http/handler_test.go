@@ -6,10 +6,14 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/steinfletcher/apitest"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"konami_backend/auth/pkg/session"
 	"konami_backend/internal/pkg/middleware"
 	"konami_backend/internal/pkg/models"
 	"konami_backend/internal/pkg/profile"
+	"konami_backend/internal/pkg/utils/cursor"
+	"konami_backend/proto/auth"
 	"net/http"
 	"testing"
 )
@@ -417,6 +421,34 @@ func TestSessions(t *testing.T) {
 			End()
 	})
 
+	t.Run("SignUpShortPassword", func(t *testing.T) {
+		var args []middleware.RouteArgs
+
+		handler := middleware.SetMuxVars(testHandler.SignUp, args)
+
+		testCred := models.Credentials{
+			Login:    "qwerty",
+			Password: "qwe",
+		}
+		testUpdJSON, _ := json.Marshal(testCred)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+
+		apitest.New("SignUp").
+			Handler(handler).
+			Method("POST").
+			URL("/user").
+			Body(string(testUpdJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			Body(`{"error":"password is too short"}`).
+			End()
+	})
+
 	t.Run("SignUpBad3", func(t *testing.T) {
 		var args []middleware.RouteArgs
 
@@ -958,4 +990,565 @@ func TestSessions(t *testing.T) {
 			Status(http.StatusUnauthorized).
 			End()
 	})
+
+	t.Run("GetPeopleCursor", func(t *testing.T) {
+		testHandler.Cursors = cursor.NewSigner([]byte("test"))
+		var args []middleware.QueryArgs
+		args = append(args, middleware.QueryArgs{Key: "limit", Value: "1"})
+		args = append(args, middleware.QueryArgs{Key: "cursor", Value: ""})
+		handler := middleware.SetVarsAndMux(testHandler.GetPeople, args, nil)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+
+		testUsers := []models.ProfileCard{
+			{Label: &models.ProfileLabel{Id: 3}},
+			{Label: &models.ProfileLabel{Id: 8}},
+		}
+		p.EXPECT().GetAll(profile.FilterParams{CountLimit: 2, ReqAuthorId: -1}).Return(testUsers, nil)
+
+		var page models.ProfilePage
+		apitest.New("GetPeopleCursor").
+			Handler(handler).
+			Method("Get").
+			URL("/people").
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(&page)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, testUsers[:1], page.Profiles)
+
+		args[1].Value = page.NextCursor
+		handler = middleware.SetVarsAndMux(testHandler.GetPeople, args, nil)
+		p.EXPECT().GetAll(profile.FilterParams{PrevId: 3, CountLimit: 2, ReqAuthorId: -1}).
+			Return(testUsers[1:], nil)
+		testPageJSON, _ := json.Marshal(models.ProfilePage{Profiles: testUsers[1:]})
+
+		apitest.New("GetPeopleCursor").
+			Handler(handler).
+			Method("Get").
+			URL("/people").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testPageJSON)).
+			End()
+
+		args[1].Value = "bad"
+		handler = middleware.SetVarsAndMux(testHandler.GetPeople, args, nil)
+		apitest.New("GetPeopleCursor").
+			Handler(handler).
+			Method("Get").
+			URL("/people").
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		// legacy clients keep getting a bare array
+		handler = middleware.SetVarsAndMux(testHandler.GetPeople, args[:1], nil)
+		p.EXPECT().GetAll(profile.FilterParams{CountLimit: 2, ReqAuthorId: -1}).Return(testUsers, nil)
+		testJSON, _ := json.Marshal(testUsers[:1])
+
+		apitest.New("GetPeopleLegacy").
+			Handler(handler).
+			Method("Get").
+			URL("/people").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testJSON)).
+			End()
+	})
+}
+
+func TestUserSessions(t *testing.T) {
+	t.Run("GetSessions", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.AuthToken, Value: "TOK"})
+
+		handler := middleware.SetMuxVars(testHandler.GetSessions, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := session.NewMockAuthCheckerClient(ctrl)
+		testHandler.AuthClient = m
+
+		m.EXPECT().List(gomock.Any(), &auth.SessionToken{Token: "TOK"}).Return(&auth.SessionList{
+			Sessions: []*auth.SessionInfo{
+				{Id: 1, UserAgent: "Firefox", Ip: "10.0.0.1", CreatedAt: 1600000000, LastSeenAt: 1600000060, Current: true},
+			},
+		}, nil)
+		testJSON, _ := json.Marshal([]models.UserSession{{
+			Id:         1,
+			UserAgent:  "Firefox",
+			Ip:         "10.0.0.1",
+			CreatedAt:  "2020-09-13T12:26:40.000Z",
+			LastSeenAt: "2020-09-13T12:27:40.000Z",
+			Current:    true,
+		}})
+
+		apitest.New("GetSessions").
+			Handler(handler).
+			Method("GET").
+			URL("/sessions").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testJSON)).
+			End()
+
+		m.EXPECT().List(gomock.Any(), &auth.SessionToken{Token: "TOK"}).
+			Return(nil, status.Error(codes.NotFound, "expired"))
+
+		apitest.New("GetSessionsExpired").
+			Handler(handler).
+			Method("GET").
+			URL("/sessions").
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("RevokeSession", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := session.NewMockAuthCheckerClient(ctrl)
+		testHandler.AuthClient = m
+
+		handler := middleware.SetVarsAndMux(testHandler.RevokeSession,
+			[]middleware.QueryArgs{{Key: "id", Value: "2"}}, args)
+		m.EXPECT().Revoke(gomock.Any(), &auth.RevokeRequest{UserId: 4, SessionId: 2}).
+			Return(&auth.Nothing{Dummy: true}, nil)
+
+		apitest.New("RevokeSession").
+			Handler(handler).
+			Method("DELETE").
+			URL("/session").
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		handler = middleware.SetVarsAndMux(testHandler.RevokeSession,
+			[]middleware.QueryArgs{{Key: "id", Value: "3"}}, args)
+		m.EXPECT().Revoke(gomock.Any(), &auth.RevokeRequest{UserId: 4, SessionId: 3}).
+			Return(nil, status.Error(codes.NotFound, "not found"))
+
+		apitest.New("RevokeSessionNotFound").
+			Handler(handler).
+			Method("DELETE").
+			URL("/session").
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+
+		handler = middleware.SetVarsAndMux(testHandler.RevokeSession,
+			[]middleware.QueryArgs{{Key: "id", Value: "bad"}}, args)
+
+		apitest.New("RevokeSessionBadId").
+			Handler(handler).
+			Method("DELETE").
+			URL("/session").
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		handler = middleware.SetMuxVars(testHandler.RevokeSession, args[:1])
+
+		apitest.New("RevokeSessionNoCSRF").
+			Handler(handler).
+			Method("DELETE").
+			URL("/session").
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("RevokeOtherSessions", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.AuthToken, Value: "TOK"})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+		handler := middleware.SetMuxVars(testHandler.RevokeOtherSessions, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := session.NewMockAuthCheckerClient(ctrl)
+		testHandler.AuthClient = m
+
+		m.EXPECT().RevokeAll(gomock.Any(), &auth.RevokeAllRequest{UserId: 4, ExceptToken: "TOK"}).
+			Return(&auth.Nothing{Dummy: true}, nil)
+
+		apitest.New("RevokeOtherSessions").
+			Handler(handler).
+			Method("DELETE").
+			URL("/sessions").
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		m.EXPECT().RevokeAll(gomock.Any(), &auth.RevokeAllRequest{UserId: 4, ExceptToken: "TOK"}).
+			Return(nil, errors.New("err"))
+
+		apitest.New("RevokeOtherSessionsErr").
+			Handler(handler).
+			Method("DELETE").
+			URL("/sessions").
+			Expect(t).
+			Status(http.StatusInternalServerError).
+			End()
+	})
+}
+
+func TestPassword(t *testing.T) {
+	testHandler.MaxReqSize = 10000
+
+	t.Run("ChangePassword", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.AuthToken, Value: "TOK"})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+		handler := middleware.SetMuxVars(testHandler.ChangePassword, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+		m := session.NewMockAuthCheckerClient(ctrl)
+		testHandler.AuthClient = m
+
+		testJSON, _ := json.Marshal(models.PasswordChange{OldPassword: "old", NewPassword: "newpass"})
+
+		p.EXPECT().ChangePassword(4, "old", "newpass").Return(nil)
+		m.EXPECT().RevokeAll(gomock.Any(), &auth.RevokeAllRequest{UserId: 4, ExceptToken: "TOK"}).
+			Return(&auth.Nothing{Dummy: true}, nil)
+
+		apitest.New("ChangePassword").
+			Handler(handler).
+			Method("POST").
+			URL("/password").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		p.EXPECT().ChangePassword(4, "old", "newpass").Return(profile.ErrInvalidCredentials)
+
+		apitest.New("ChangePasswordWrongOld").
+			Handler(handler).
+			Method("POST").
+			URL("/password").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		emptyJSON, _ := json.Marshal(models.PasswordChange{OldPassword: "old"})
+
+		apitest.New("ChangePasswordEmpty").
+			Handler(handler).
+			Method("POST").
+			URL("/password").
+			Body(string(emptyJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		shortJSON, _ := json.Marshal(models.PasswordChange{OldPassword: "old", NewPassword: "new"})
+
+		apitest.New("ChangePasswordShort").
+			Handler(handler).
+			Method("POST").
+			URL("/password").
+			Body(string(shortJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		handler = middleware.SetMuxVars(testHandler.ChangePassword, args[:2])
+
+		apitest.New("ChangePasswordNoCSRF").
+			Handler(handler).
+			Method("POST").
+			URL("/password").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+
+	t.Run("RequestPasswordReset", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+
+		testJSON, _ := json.Marshal(models.PasswordResetRequest{Login: "user@mail.ru"})
+
+		// unknown logins get the same answer
+		p.EXPECT().RequestPasswordReset("user@mail.ru").Return(nil)
+		p.EXPECT().RequestPasswordReset("user@mail.ru").Return(profile.ErrUserNonExistent)
+		for i := 0; i < 2; i++ {
+			apitest.New("RequestPasswordReset").
+				Handler(http.HandlerFunc(testHandler.RequestPasswordReset)).
+				Method("POST").
+				URL("/password/reset").
+				Body(string(testJSON)).
+				Expect(t).
+				Status(http.StatusOK).
+				End()
+		}
+
+		p.EXPECT().RequestPasswordReset("user@mail.ru").Return(errors.New("err"))
+
+		apitest.New("RequestPasswordResetErr").
+			Handler(http.HandlerFunc(testHandler.RequestPasswordReset)).
+			Method("POST").
+			URL("/password/reset").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusInternalServerError).
+			End()
+	})
+
+	t.Run("ConfirmPasswordReset", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+		m := session.NewMockAuthCheckerClient(ctrl)
+		testHandler.AuthClient = m
+
+		testJSON, _ := json.Marshal(models.PasswordResetConfirm{Token: "abc", Password: "newpass"})
+
+		p.EXPECT().ResetPassword("abc", "newpass").Return(4, nil)
+		m.EXPECT().RevokeAll(gomock.Any(), &auth.RevokeAllRequest{UserId: 4}).
+			Return(&auth.Nothing{Dummy: true}, nil)
+
+		apitest.New("ConfirmPasswordReset").
+			Handler(http.HandlerFunc(testHandler.ConfirmPasswordReset)).
+			Method("POST").
+			URL("/password/reset/confirm").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		p.EXPECT().ResetPassword("abc", "newpass").Return(0, profile.ErrInvalidResetToken)
+
+		apitest.New("ConfirmPasswordResetInvalid").
+			Handler(http.HandlerFunc(testHandler.ConfirmPasswordReset)).
+			Method("POST").
+			URL("/password/reset/confirm").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		shortJSON, _ := json.Marshal(models.PasswordResetConfirm{Token: "abc", Password: "      "})
+
+		apitest.New("ConfirmPasswordResetBlank").
+			Handler(http.HandlerFunc(testHandler.ConfirmPasswordReset)).
+			Method("POST").
+			URL("/password/reset/confirm").
+			Body(string(shortJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+}
+
+func TestEmail(t *testing.T) {
+	testHandler.MaxReqSize = 10000
+
+	t.Run("SignUpEmailTaken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+
+		testCred := models.Credentials{Login: "ivan", Password: "qwerty", Email: "ivan@mail.ru"}
+		testJSON, _ := json.Marshal(testCred)
+
+		p.EXPECT().Validate(testCred).Return(0, profile.ErrUserNonExistent)
+		p.EXPECT().SignUp(testCred).Return(0, profile.ErrEmailTaken)
+
+		apitest.New("SignUpEmailTaken").
+			Handler(http.HandlerFunc(testHandler.SignUp)).
+			Method("POST").
+			URL("/signup").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusConflict).
+			End()
+
+		loginCred := models.Credentials{Login: "ivan@mail.ru", Password: "qwerty"}
+		loginJSON, _ := json.Marshal(loginCred)
+		p.EXPECT().Validate(loginCred).Return(0, profile.ErrUserNonExistent)
+		p.EXPECT().SignUp(loginCred).Return(0, profile.ErrLoginTaken)
+
+		apitest.New("SignUpLoginIsEmail").
+			Handler(http.HandlerFunc(testHandler.SignUp)).
+			Method("POST").
+			URL("/signup").
+			Body(string(loginJSON)).
+			Expect(t).
+			Status(http.StatusConflict).
+			Body(`{"error": "login has already been taken"}`).
+			End()
+	})
+
+	t.Run("GetEmail", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+
+		handler := middleware.SetMuxVars(testHandler.GetEmail, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+
+		status := models.EmailStatus{Email: "ivan@mail.ru", Verified: true}
+		testJSON, _ := json.Marshal(status)
+		p.EXPECT().GetEmail(4).Return(status, nil)
+
+		apitest.New("GetEmail").
+			Handler(handler).
+			Method("GET").
+			URL("/email").
+			Expect(t).
+			Status(http.StatusOK).
+			Body(string(testJSON)).
+			End()
+	})
+
+	t.Run("SetEmail", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+		handler := middleware.SetMuxVars(testHandler.SetEmail, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+
+		testJSON, _ := json.Marshal(models.EmailUpdate{Email: "ivan@mail.ru"})
+
+		for _, c := range []struct {
+			err    error
+			status int
+		}{
+			{nil, http.StatusOK},
+			{profile.ErrInvalidEmail, http.StatusBadRequest},
+			{profile.ErrEmailTaken, http.StatusConflict},
+			{errors.New("err"), http.StatusInternalServerError},
+		} {
+			p.EXPECT().SetEmail(4, "ivan@mail.ru").Return(c.err)
+
+			apitest.New("SetEmail").
+				Handler(handler).
+				Method("PUT").
+				URL("/email").
+				Body(string(testJSON)).
+				Expect(t).
+				Status(c.status).
+				End()
+		}
+	})
+
+	t.Run("ResendVerification", func(t *testing.T) {
+		var args []middleware.RouteArgs
+		args = append(args, middleware.RouteArgs{Key: middleware.UserID, Value: 4})
+		args = append(args, middleware.RouteArgs{Key: middleware.CSRFValid, Value: true})
+
+		handler := middleware.SetMuxVars(testHandler.ResendVerification, args)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+
+		p.EXPECT().ResendVerification(4).Return(nil)
+
+		apitest.New("ResendVerification").
+			Handler(handler).
+			Method("POST").
+			URL("/email/resend").
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		p.EXPECT().ResendVerification(4).Return(profile.ErrEmailVerified)
+
+		apitest.New("ResendVerificationVerified").
+			Handler(handler).
+			Method("POST").
+			URL("/email/resend").
+			Expect(t).
+			Status(http.StatusConflict).
+			End()
+	})
+
+	t.Run("VerifyEmail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := profile.NewMockUseCase(ctrl)
+		testHandler.ProfileUC = p
+
+		testJSON, _ := json.Marshal(models.EmailVerify{Token: "abc"})
+
+		p.EXPECT().VerifyEmail("abc").Return(nil)
+
+		apitest.New("VerifyEmail").
+			Handler(http.HandlerFunc(testHandler.VerifyEmail)).
+			Method("POST").
+			URL("/email/verify").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+
+		p.EXPECT().VerifyEmail("abc").Return(profile.ErrInvalidVerificationToken)
+
+		apitest.New("VerifyEmailInvalid").
+			Handler(http.HandlerFunc(testHandler.VerifyEmail)).
+			Method("POST").
+			URL("/email/verify").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+
+		testHandler.MaxReqSize = 10
+		defer func() { testHandler.MaxReqSize = 10000 }()
+
+		apitest.New("VerifyEmailTooLarge").
+			Handler(http.HandlerFunc(testHandler.VerifyEmail)).
+			Method("POST").
+			URL("/email/verify").
+			Body(string(testJSON)).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
 }
@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"konami_backend/internal/pkg/mail"
+	"konami_backend/internal/pkg/profile"
+	"net/url"
+	"strings"
+)
+
+// MailResetNotifier queues reset links through the mail subsystem,
+// LinkBase is the frontend page that takes the token as its last query parameter
+type MailResetNotifier struct {
+	Mail     mail.UseCase
+	Lang     string
+	LinkBase string
+}
+
+func (n *MailResetNotifier) SendPasswordReset(address, name, token string) error {
+	if !strings.Contains(address, "@") {
+		return profile.ErrNoResetAddress
+	}
+	return n.Mail.Send(address, n.Lang, mail.TemplatePasswordReset, mail.LinkData{
+		Name: name,
+		Link: n.LinkBase + url.QueryEscape(token),
+	})
+}
+
+// MailVerificationNotifier queues email verification links through the mail subsystem
+type MailVerificationNotifier struct {
+	Mail     mail.UseCase
+	Lang     string
+	LinkBase string
+}
+
+func (n *MailVerificationNotifier) SendVerification(email, name, token string) error {
+	return n.Mail.Send(email, n.Lang, mail.TemplateVerification, mail.LinkData{
+		Name: name,
+		Link: n.LinkBase + url.QueryEscape(token),
+	})
+}
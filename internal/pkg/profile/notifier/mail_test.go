@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"konami_backend/internal/pkg/mail"
+	"konami_backend/internal/pkg/profile"
+	"testing"
+)
+
+func TestMailResetNotifier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mail.NewMockUseCase(ctrl)
+	n := &MailResetNotifier{Mail: m, Lang: mail.LangEn, LinkBase: "https://onmeet.ru/reset?token="}
+
+	m.EXPECT().Send("user@mail.ru", mail.LangEn, mail.TemplatePasswordReset, mail.LinkData{
+		Name: "Ivan",
+		Link: "https://onmeet.ru/reset?token=abc",
+	}).Return(nil)
+	require.NoError(t, n.SendPasswordReset("user@mail.ru", "Ivan", "abc"))
+
+	err := n.SendPasswordReset("ivan", "Ivan", "abc")
+	require.Equal(t, profile.ErrNoResetAddress, err)
+}
+
+func TestMailVerificationNotifier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mail.NewMockUseCase(ctrl)
+	n := &MailVerificationNotifier{Mail: m, Lang: mail.LangRu, LinkBase: "https://onmeet.ru/verify?token="}
+
+	m.EXPECT().Send("user@mail.ru", mail.LangRu, mail.TemplateVerification, mail.LinkData{
+		Name: "Ivan",
+		Link: "https://onmeet.ru/verify?token=abc",
+	}).Return(nil)
+	require.NoError(t, n.SendVerification("user@mail.ru", "Ivan", "abc"))
+}
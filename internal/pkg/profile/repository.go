@@ -4,9 +4,12 @@ package profile
 import (
 	"errors"
 	"konami_backend/internal/pkg/models"
+	"time"
 )
 
 var ErrUserNonExistent = errors.New("user non existent")
+var ErrInvalidResetToken = errors.New("invalid password reset token")
+var ErrInvalidVerificationToken = errors.New("invalid email verification token")
 
 type FilterParams struct {
 	PrevId      int
@@ -25,7 +28,22 @@ type Repository interface {
 	EditProfile(update models.Profile) error
 	EditProfilePic(userId int, imgSrc string) error
 	Create(p models.Profile) (userId int, err error)
+	// GetCredentials looks the user up by login or by verified email, the login wins
 	GetCredentials(login string) (userId int, pwdHash string, err error)
 	GetLabel(userId int) (models.ProfileLabel, error)
 	GetTagSubscriptions(userId int) (tagIds []int, err error)
+	GetPwdHash(userId int) (string, error)
+	SetPwdHash(userId int, pwdHash string) error
+	// CreateResetToken replaces any reset token the user requested before
+	CreateResetToken(userId int, tokenHash string, expiresAt time.Time) error
+	// ResetPassword consumes an unexpired reset token and sets the new hash
+	ResetPassword(tokenHash string, pwdHash string, now time.Time) (userId int, err error)
+	GetEmail(userId int) (email string, verified bool, err error)
+	SetEmail(userId int, email string) error
+	// IsEmailTaken reports whether another user has the address as email or as login in any case
+	IsEmailTaken(email string, exceptUserId int) (bool, error)
+	// CreateVerificationToken replaces any verification token the user requested before
+	CreateVerificationToken(userId int, email string, tokenHash string, expiresAt time.Time) error
+	// VerifyEmail consumes an unexpired token issued for the current email of the user
+	VerifyEmail(tokenHash string, now time.Time) (userId int, err error)
 }
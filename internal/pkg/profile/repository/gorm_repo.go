@@ -22,25 +22,27 @@ func NewProfileGormRepo(db *gorm.DB) profile.Repository {
 }
 
 type Profile struct {
-	Id           int `gorm:"primaryKey;autoIncrement;"`
-	Name         string
-	ImgSrc       string
-	Job          string
-	MeetingTags  []tagRepo.Tag `gorm:"many2many:profile_meetingTags;"`
-	InterestTags []InterestTag `gorm:"many2many:profile_interestTags;"`
-	SkillTags    []SkillTag    `gorm:"many2many:profile_skillTags;"`
-	Gender       string
-	Birthday     time.Time
-	City         string
-	Login        string `gorm:"unique;"`
-	PwdHash      string
-	Telegram     string
-	Vk           string
-	Education    string
-	Aims         string
-	Interests    string
-	Skills       string
-	Meetings     []meetingRepo.Meeting `gorm:"foreignKey:AuthorId;"`
+	Id            int `gorm:"primaryKey;autoIncrement;"`
+	Name          string
+	ImgSrc        string
+	Job           string
+	MeetingTags   []tagRepo.Tag `gorm:"many2many:profile_meetingTags;"`
+	InterestTags  []InterestTag `gorm:"many2many:profile_interestTags;"`
+	SkillTags     []SkillTag    `gorm:"many2many:profile_skillTags;"`
+	Gender        string
+	Birthday      time.Time
+	City          string
+	Login         string `gorm:"unique;"`
+	PwdHash       string
+	Email         *string `gorm:"unique;"`
+	EmailVerified bool
+	Telegram      string
+	Vk            string
+	Education     string
+	Aims          string
+	Interests     string
+	Skills        string
+	Meetings      []meetingRepo.Meeting `gorm:"foreignKey:AuthorId;"`
 }
 
 type InterestTag struct {
@@ -71,6 +73,31 @@ func (t *Subscription) TableName() string {
 	return "Subscriptions"
 }
 
+// PasswordReset keeps only a hash of the token that was sent to the user
+type PasswordReset struct {
+	Id        int    `gorm:"primaryKey;autoIncrement;"`
+	UserId    int    `gorm:"index"`
+	TokenHash string `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+}
+
+func (r *PasswordReset) TableName() string {
+	return "password_resets"
+}
+
+// EmailVerification is bound to the address it was sent to
+type EmailVerification struct {
+	Id        int `gorm:"primaryKey;autoIncrement;"`
+	UserId    int `gorm:"index"`
+	Email     string
+	TokenHash string `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+}
+
+func (v *EmailVerification) TableName() string {
+	return "email_verifications"
+}
+
 func (h *ProfileGormRepo) GetUserSubscriptionIds(params profile.FilterParams) ([]int, error) {
 	var subs []Subscription
 	db := h.db.Where("author_id = ?", params.ReqAuthorId)
@@ -282,6 +309,9 @@ func (h *ProfileGormRepo) ToProfile(obj Profile, authorId int) (models.Profile,
 		Interests: obj.Interests,
 		Skills:    obj.Skills,
 	}
+	if obj.Email != nil {
+		p.Email = *obj.Email
+	}
 	if obj.Birthday.Unix() != (time.Time{}).Unix() {
 		p.Birthday = obj.Birthday.Format("2006-01-02")
 	}
@@ -315,6 +345,10 @@ func (h *ProfileGormRepo) ToDbObject(p models.Profile) (Profile, error) {
 		Interests: p.Interests,
 		Skills:    p.Skills,
 	}
+	if p.Email != "" {
+		email := p.Email
+		obj.Email = &email
+	}
 	obj.MeetingTags = make([]tagRepo.Tag, len(p.MeetingTags))
 	for i, el := range p.MeetingTags {
 		obj.MeetingTags[i] = tagRepo.Tag{Id: el.TagId, Name: el.Name}
@@ -391,7 +425,8 @@ func (h ProfileGormRepo) EditProfile(update models.Profile) error {
 		return err
 	}
 	target := Profile{Id: update.Card.Label.Id}
-	db := h.db.Omit(clause.Associations).Save(&updatedObj)
+	// credentials are changed only through their own methods
+	db := h.db.Omit(clause.Associations, "PwdHash", "Email", "EmailVerified").Save(&updatedObj)
 
 	if db.Error == nil {
 		err = h.db.Model(&target).Association("MeetingTags").Replace(updatedObj.MeetingTags)
@@ -450,17 +485,25 @@ func (h ProfileGormRepo) Create(p models.Profile) (int, error) {
 }
 
 func (h ProfileGormRepo) GetCredentials(login string) (int, string, error) {
-	var obj Profile
+	var objs []Profile
 	db := h.db.
 		Where("Login = ?", login).
-		First(&obj)
+		Or("email = LOWER(?) AND email_verified = ?", login, true).
+		Limit(2).
+		Find(&objs)
 	err := db.Error
-	if err == gorm.ErrRecordNotFound {
-		return 0, "", profile.ErrUserNonExistent
-	}
 	if err != nil {
 		return 0, "", err
 	}
+	if len(objs) == 0 {
+		return 0, "", profile.ErrUserNonExistent
+	}
+	obj := objs[0]
+	for _, o := range objs {
+		if o.Login == login {
+			obj = o
+		}
+	}
 	return obj.Id, obj.PwdHash, nil
 }
 
@@ -470,6 +513,9 @@ func (h *ProfileGormRepo) GetLabel(userId int) (models.ProfileLabel, error) {
 		Where("id = ?", userId).
 		First(&p)
 	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ProfileLabel{}, profile.ErrUserNonExistent
+	}
 	if err != nil {
 		return models.ProfileLabel{}, err
 	}
@@ -497,3 +543,160 @@ func (h *ProfileGormRepo) GetTagSubscriptions(userId int) (tagIds []int, err err
 	}
 	return tagIds, nil
 }
+
+func (h *ProfileGormRepo) GetPwdHash(userId int) (string, error) {
+	var obj Profile
+	db := h.db.
+		Select("pwd_hash").
+		Where("id = ?", userId).
+		First(&obj)
+	if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return "", profile.ErrUserNonExistent
+	}
+	if db.Error != nil {
+		return "", db.Error
+	}
+	return obj.PwdHash, nil
+}
+
+func (h *ProfileGormRepo) SetPwdHash(userId int, pwdHash string) error {
+	db := h.db.Model(&Profile{}).
+		Where("id = ?", userId).
+		Update("pwd_hash", pwdHash)
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return profile.ErrUserNonExistent
+	}
+	return nil
+}
+
+func (h *ProfileGormRepo) CreateResetToken(userId int, tokenHash string, expiresAt time.Time) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userId).Delete(&PasswordReset{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&PasswordReset{UserId: userId, TokenHash: tokenHash, ExpiresAt: expiresAt}).Error
+	})
+}
+
+func (h *ProfileGormRepo) ResetPassword(tokenHash string, pwdHash string, now time.Time) (int, error) {
+	var r PasswordReset
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Where("token_hash = ?", tokenHash).
+			Where("expires_at > ?", now).
+			First(&r)
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return profile.ErrInvalidResetToken
+		}
+		if db.Error != nil {
+			return db.Error
+		}
+		// of two concurrent requests with the same token only one deletes it
+		db = tx.Where("id = ?", r.Id).Delete(&PasswordReset{})
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return profile.ErrInvalidResetToken
+		}
+		return tx.Model(&Profile{}).
+			Where("id = ?", r.UserId).
+			Update("pwd_hash", pwdHash).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return r.UserId, nil
+}
+
+func (h *ProfileGormRepo) GetEmail(userId int) (string, bool, error) {
+	var obj Profile
+	db := h.db.
+		Select("email", "email_verified").
+		Where("id = ?", userId).
+		First(&obj)
+	if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return "", false, profile.ErrUserNonExistent
+	}
+	if db.Error != nil {
+		return "", false, db.Error
+	}
+	if obj.Email == nil {
+		return "", false, nil
+	}
+	return *obj.Email, obj.EmailVerified, nil
+}
+
+func (h *ProfileGormRepo) SetEmail(userId int, email string) error {
+	db := h.db.Model(&Profile{}).
+		Where("id = ?", userId).
+		Updates(map[string]interface{}{"email": email, "email_verified": false})
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return profile.ErrUserNonExistent
+	}
+	return nil
+}
+
+func (h *ProfileGormRepo) IsEmailTaken(email string, exceptUserId int) (bool, error) {
+	var count int64
+	err := h.db.Model(&Profile{}).
+		Where("email = ? OR LOWER(login) = ?", email, email).
+		Where("id <> ?", exceptUserId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (h *ProfileGormRepo) CreateVerificationToken(userId int, email string, tokenHash string, expiresAt time.Time) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userId).Delete(&EmailVerification{}).Error
+		if err != nil {
+			return err
+		}
+		v := EmailVerification{UserId: userId, Email: email, TokenHash: tokenHash, ExpiresAt: expiresAt}
+		return tx.Create(&v).Error
+	})
+}
+
+func (h *ProfileGormRepo) VerifyEmail(tokenHash string, now time.Time) (int, error) {
+	var v EmailVerification
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Where("token_hash = ?", tokenHash).
+			Where("expires_at > ?", now).
+			First(&v)
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return profile.ErrInvalidVerificationToken
+		}
+		if db.Error != nil {
+			return db.Error
+		}
+		db = tx.Where("id = ?", v.Id).Delete(&EmailVerification{})
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return profile.ErrInvalidVerificationToken
+		}
+		// the link is useless once the user has switched to another address
+		db = tx.Model(&Profile{}).
+			Where("id = ?", v.UserId).
+			Where("email = ?", v.Email).
+			Update("email_verified", true)
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return profile.ErrInvalidVerificationToken
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return v.UserId, nil
+}
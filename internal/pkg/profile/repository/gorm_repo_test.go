@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 	"konami_backend/internal/pkg/profile"
 	"testing"
+	"time"
 )
 
 type Suite struct {
@@ -156,6 +157,204 @@ func (s *Suite) TestCreateSubscription() {
 	require.NoError(s.T(), err)
 }
 
+func (s *Suite) TestGetPwdHash() {
+	s.mock.ExpectQuery("SELECT \"pwd_hash\" FROM \"profiles\"").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"pwd_hash"}).AddRow("hash"))
+
+	hash, err := s.repository.GetPwdHash(1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "hash", hash)
+
+	s.mock.ExpectQuery("SELECT").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	_, err = s.repository.GetPwdHash(2)
+	require.Equal(s.T(), profile.ErrUserNonExistent, err)
+}
+
+func (s *Suite) TestSetPwdHash() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"profiles\" SET \"pwd_hash\"").
+		WithArgs("hash", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.SetPwdHash(1, "hash")
+	require.NoError(s.T(), err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE").
+		WithArgs("hash", 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err = s.repository.SetPwdHash(2, "hash")
+	require.Equal(s.T(), profile.ErrUserNonExistent, err)
+}
+
+func (s *Suite) TestCreateResetToken() {
+	expires := time.Now().Add(time.Hour)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"password_resets\"").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery("INSERT INTO \"password_resets\"").
+		WithArgs(1, "tokenhash", expires).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.CreateResetToken(1, "tokenhash", expires)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestResetPassword() {
+	now := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM \"password_resets\"").
+		WithArgs("tokenhash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(3, 1))
+	s.mock.ExpectExec("DELETE FROM \"password_resets\"").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE \"profiles\" SET \"pwd_hash\"").
+		WithArgs("hash", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	userId, err := s.repository.ResetPassword("tokenhash", "hash", now)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, userId)
+
+	// the token was consumed by a concurrent request
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT").
+		WithArgs("tokenhash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(3, 1))
+	s.mock.ExpectExec("DELETE FROM").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	_, err = s.repository.ResetPassword("tokenhash", "hash", now)
+	require.Equal(s.T(), profile.ErrInvalidResetToken, err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT").
+		WithArgs("expired", now).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+	s.mock.ExpectRollback()
+
+	_, err = s.repository.ResetPassword("expired", "hash", now)
+	require.Equal(s.T(), profile.ErrInvalidResetToken, err)
+}
+
+func (s *Suite) TestGetCredByEmail() {
+	s.mock.ExpectQuery("SELECT (.+) WHERE Login = (.+) OR \\(email = LOWER(.+) AND email_verified = (.+)\\) LIMIT 2").
+		WithArgs("ivan@mail.ru", "ivan@mail.ru", true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "login", "pwd_hash"}).
+			AddRow(1, "ivan", "emailhash").
+			AddRow(2, "ivan@mail.ru", "loginhash"))
+
+	// a matching login wins over a verified email
+	userId, pwdHash, err := s.repository.GetCredentials("ivan@mail.ru")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, userId)
+	require.Equal(s.T(), "loginhash", pwdHash)
+}
+
+func (s *Suite) TestGetEmail() {
+	s.mock.ExpectQuery("SELECT \"email\",\"email_verified\" FROM \"profiles\"").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "email_verified"}).AddRow("ivan@mail.ru", true))
+
+	email, verified, err := s.repository.GetEmail(1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "ivan@mail.ru", email)
+	require.True(s.T(), verified)
+
+	s.mock.ExpectQuery("SELECT").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "email_verified"}).AddRow(nil, false))
+
+	email, verified, err = s.repository.GetEmail(2)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), email)
+	require.False(s.T(), verified)
+}
+
+func (s *Suite) TestSetEmail() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"profiles\" SET \"email\"=(.+),\"email_verified\"=").
+		WithArgs("ivan@mail.ru", false, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.SetEmail(1, "ivan@mail.ru")
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestIsEmailTaken() {
+	s.mock.ExpectQuery("SELECT count\\(1\\) FROM \"profiles\" WHERE \\(email = \\$1 OR LOWER\\(login\\) = \\$2\\)").
+		WithArgs("ivan@mail.ru", "ivan@mail.ru", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	taken, err := s.repository.IsEmailTaken("ivan@mail.ru", 1)
+	require.NoError(s.T(), err)
+	require.True(s.T(), taken)
+}
+
+func (s *Suite) TestCreateVerificationToken() {
+	expires := time.Now().Add(time.Hour)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM \"email_verifications\"").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("INSERT INTO \"email_verifications\"").
+		WithArgs(1, "ivan@mail.ru", "tokenhash", expires).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.CreateVerificationToken(1, "ivan@mail.ru", "tokenhash", expires)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) TestVerifyEmail() {
+	now := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM \"email_verifications\"").
+		WithArgs("tokenhash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email"}).AddRow(3, 1, "ivan@mail.ru"))
+	s.mock.ExpectExec("DELETE FROM \"email_verifications\"").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE \"profiles\" SET \"email_verified\"").
+		WithArgs(true, 1, "ivan@mail.ru").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	userId, err := s.repository.VerifyEmail("tokenhash", now)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, userId)
+
+	// the user has switched to another address since the link was sent
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT").
+		WithArgs("tokenhash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email"}).AddRow(3, 1, "old@mail.ru"))
+	s.mock.ExpectExec("DELETE FROM").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE").
+		WithArgs(true, 1, "old@mail.ru").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	_, err = s.repository.VerifyEmail("tokenhash", now)
+	require.Equal(s.T(), profile.ErrInvalidVerificationToken, err)
+}
+
 func (s *Suite) AfterTest(_, _ string) {
 	require.NoError(s.T(), s.mock.ExpectationsWereMet())
 }
@@ -8,6 +8,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	models "konami_backend/internal/pkg/models"
 	reflect "reflect"
+	time "time"
 )
 
 // MockRepository is a mock of Repository interface
@@ -225,3 +226,135 @@ func (mr *MockRepositoryMockRecorder) GetTagSubscriptions(userId interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagSubscriptions", reflect.TypeOf((*MockRepository)(nil).GetTagSubscriptions), userId)
 }
+
+// GetPwdHash mocks base method
+func (m *MockRepository) GetPwdHash(userId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPwdHash", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPwdHash indicates an expected call of GetPwdHash
+func (mr *MockRepositoryMockRecorder) GetPwdHash(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPwdHash", reflect.TypeOf((*MockRepository)(nil).GetPwdHash), userId)
+}
+
+// SetPwdHash mocks base method
+func (m *MockRepository) SetPwdHash(userId int, pwdHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPwdHash", userId, pwdHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPwdHash indicates an expected call of SetPwdHash
+func (mr *MockRepositoryMockRecorder) SetPwdHash(userId, pwdHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPwdHash", reflect.TypeOf((*MockRepository)(nil).SetPwdHash), userId, pwdHash)
+}
+
+// CreateResetToken mocks base method
+func (m *MockRepository) CreateResetToken(userId int, tokenHash string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateResetToken", userId, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateResetToken indicates an expected call of CreateResetToken
+func (mr *MockRepositoryMockRecorder) CreateResetToken(userId, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResetToken", reflect.TypeOf((*MockRepository)(nil).CreateResetToken), userId, tokenHash, expiresAt)
+}
+
+// ResetPassword mocks base method
+func (m *MockRepository) ResetPassword(tokenHash, pwdHash string, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", tokenHash, pwdHash, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword
+func (mr *MockRepositoryMockRecorder) ResetPassword(tokenHash, pwdHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockRepository)(nil).ResetPassword), tokenHash, pwdHash, now)
+}
+
+// GetEmail mocks base method
+func (m *MockRepository) GetEmail(userId int) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmail", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetEmail indicates an expected call of GetEmail
+func (mr *MockRepositoryMockRecorder) GetEmail(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmail", reflect.TypeOf((*MockRepository)(nil).GetEmail), userId)
+}
+
+// SetEmail mocks base method
+func (m *MockRepository) SetEmail(userId int, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmail", userId, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmail indicates an expected call of SetEmail
+func (mr *MockRepositoryMockRecorder) SetEmail(userId, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmail", reflect.TypeOf((*MockRepository)(nil).SetEmail), userId, email)
+}
+
+// IsEmailTaken mocks base method
+func (m *MockRepository) IsEmailTaken(email string, exceptUserId int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEmailTaken", email, exceptUserId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsEmailTaken indicates an expected call of IsEmailTaken
+func (mr *MockRepositoryMockRecorder) IsEmailTaken(email, exceptUserId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmailTaken", reflect.TypeOf((*MockRepository)(nil).IsEmailTaken), email, exceptUserId)
+}
+
+// CreateVerificationToken mocks base method
+func (m *MockRepository) CreateVerificationToken(userId int, email, tokenHash string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVerificationToken", userId, email, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateVerificationToken indicates an expected call of CreateVerificationToken
+func (mr *MockRepositoryMockRecorder) CreateVerificationToken(userId, email, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVerificationToken", reflect.TypeOf((*MockRepository)(nil).CreateVerificationToken), userId, email, tokenHash, expiresAt)
+}
+
+// VerifyEmail mocks base method
+func (m *MockRepository) VerifyEmail(tokenHash string, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", tokenHash, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail
+func (mr *MockRepositoryMockRecorder) VerifyEmail(tokenHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockRepository)(nil).VerifyEmail), tokenHash, now)
+}
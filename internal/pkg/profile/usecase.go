@@ -8,6 +8,21 @@ import (
 )
 
 var ErrInvalidCredentials = errors.New("invalid credentials")
+var ErrNoResetAddress = errors.New("no address to deliver the reset token to")
+var ErrInvalidEmail = errors.New("invalid email")
+var ErrEmailTaken = errors.New("email has already been taken")
+var ErrLoginTaken = errors.New("login has already been taken")
+var ErrEmailVerified = errors.New("email is already verified")
+
+// ResetNotifier delivers a password reset token to the user out of band
+type ResetNotifier interface {
+	SendPasswordReset(address, name, token string) error
+}
+
+// VerificationNotifier delivers an email verification token to the address being verified
+type VerificationNotifier interface {
+	SendVerification(email, name, token string) error
+}
 
 type UseCase interface {
 	GetAll(params FilterParams) ([]models.ProfileCard, error)
@@ -19,4 +34,16 @@ type UseCase interface {
 	UploadProfilePic(userId int, filename string, img io.Reader) error
 	SignUp(cred models.Credentials) (userId int, err error)
 	Validate(cred models.Credentials) (userId int, err error)
+	// ResolveLogin finds the account a login or a verified email belongs to
+	ResolveLogin(login string) (userId int, err error)
+	ChangePassword(userId int, oldPassword, newPassword string) error
+	RequestPasswordReset(login string) error
+	// ResetPassword consumes the reset token and returns the owner of the account
+	ResetPassword(token, newPassword string) (userId int, err error)
+	GetEmail(userId int) (models.EmailStatus, error)
+	// SetEmail replaces the address with an unverified one and sends a verification link to it
+	SetEmail(userId int, email string) error
+	ResendVerification(userId int) error
+	VerifyEmail(token string) error
+	IsEmailVerified(userId int) (bool, error)
 }
@@ -1,29 +1,49 @@
 package usecase
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"golang.org/x/crypto/bcrypt"
 	"io"
 	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
 	"konami_backend/internal/pkg/profile"
 	"konami_backend/internal/pkg/tag"
 	"konami_backend/internal/pkg/utils/uploads_handler"
+	"net/mail"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	// ResetTokenTTL is how long a password reset link stays valid
+	ResetTokenTTL = time.Hour
+	// VerificationTokenTTL is how long an email verification link stays valid
+	VerificationTokenTTL = 24 * time.Hour
 )
 
 type ProfileUseCase struct {
 	ProfileRepo    profile.Repository
 	UploadsHandler uploads_handler.UploadsHandler
 	TagRepo        tag.Repository
+	Notifier       notification.Emitter
+	ResetNotifier  profile.ResetNotifier
+	VerifyNotifier profile.VerificationNotifier
 	ProfilePicsDir string
 	defaultImgSrc  string
+	Now            func() time.Time
 }
 
 func NewProfileUseCase(ProfileRepo profile.Repository,
 	UploadsHandler uploads_handler.UploadsHandler,
 	TagRepo tag.Repository,
+	Notifier notification.Emitter,
+	ResetNotifier profile.ResetNotifier,
+	VerifyNotifier profile.VerificationNotifier,
 	ProfilePicsDir string,
 	defaultImgSrc string) profile.UseCase {
 
@@ -31,8 +51,12 @@ func NewProfileUseCase(ProfileRepo profile.Repository,
 		ProfileRepo:    ProfileRepo,
 		UploadsHandler: UploadsHandler,
 		TagRepo:        TagRepo,
+		Notifier:       Notifier,
+		ResetNotifier:  ResetNotifier,
+		VerifyNotifier: VerifyNotifier,
 		ProfilePicsDir: ProfilePicsDir,
 		defaultImgSrc:  defaultImgSrc,
+		Now:            time.Now,
 	}
 }
 
@@ -70,7 +94,15 @@ func (h ProfileUseCase) GetUserSubscriptions(params profile.FilterParams) ([]mod
 }
 
 func (h ProfileUseCase) CreateSubscription(authorId int, targetId int) (int, error) {
-	return h.ProfileRepo.CreateSubscription(authorId, targetId)
+	id, err := h.ProfileRepo.CreateSubscription(authorId, targetId)
+	if err == nil && h.Notifier != nil {
+		h.Notifier.Emit(notification.Event{
+			Type:    notification.TypeSubscribed,
+			UserId:  targetId,
+			ActorId: authorId,
+		})
+	}
+	return id, err
 }
 
 func (h ProfileUseCase) RemoveSubscription(authorId int, targetId int) error {
@@ -142,7 +174,41 @@ func (h ProfileUseCase) UploadProfilePic(userId int, filename string, img io.Rea
 	return h.ProfileRepo.EditProfilePic(userId, imgPath)
 }
 
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", profile.ErrInvalidEmail
+	}
+	return email, nil
+}
+
 func (h ProfileUseCase) SignUp(cred models.Credentials) (int, error) {
+	// a login that is someone's email would make sign in by that email ambiguous
+	if strings.Contains(cred.Login, "@") {
+		taken, err := h.ProfileRepo.IsEmailTaken(strings.ToLower(strings.TrimSpace(cred.Login)), 0)
+		if err != nil {
+			return 0, err
+		}
+		if taken {
+			return 0, profile.ErrLoginTaken
+		}
+	}
+	var email string
+	if cred.Email != "" {
+		var err error
+		email, err = normalizeEmail(cred.Email)
+		if err != nil {
+			return 0, err
+		}
+		taken, err := h.ProfileRepo.IsEmailTaken(email, 0)
+		if err != nil {
+			return 0, err
+		}
+		if taken {
+			return 0, profile.ErrEmailTaken
+		}
+	}
 	hashed, err := bcrypt.GenerateFromPassword([]byte(cred.Password), bcrypt.MinCost)
 	if err != nil {
 		return 0, err
@@ -158,10 +224,17 @@ func (h ProfileUseCase) SignUp(cred models.Credentials) (int, error) {
 		},
 		Login:       cred.Login,
 		PwdHash:     string(hashed),
+		Email:       email,
 		MeetingTags: []*models.Tag{},
 		Meetings:    []*models.MeetingLabel{},
 	}
-	return h.ProfileRepo.Create(p)
+	userId, err := h.ProfileRepo.Create(p)
+	if err != nil || email == "" {
+		return userId, err
+	}
+	// the account exists anyway, the user can ask for another link
+	_ = h.sendVerification(userId, email, p.Card.Label.Name)
+	return userId, nil
 }
 
 func (h ProfileUseCase) Validate(cred models.Credentials) (int, error) {
@@ -175,3 +248,164 @@ func (h ProfileUseCase) Validate(cred models.Credentials) (int, error) {
 	}
 	return userId, nil
 }
+
+func (h ProfileUseCase) ResolveLogin(login string) (int, error) {
+	userId, _, err := h.ProfileRepo.GetCredentials(login)
+	return userId, err
+}
+
+func (h ProfileUseCase) ChangePassword(userId int, oldPassword, newPassword string) error {
+	pwdHash, err := h.ProfileRepo.GetPwdHash(userId)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(pwdHash), []byte(oldPassword)) != nil {
+		return profile.ErrInvalidCredentials
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+	return h.ProfileRepo.SetPwdHash(userId, string(hashed))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns a random token for the user and the hash to store instead of it
+func newToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(raw)
+	return token, hashToken(token), nil
+}
+
+func (h ProfileUseCase) RequestPasswordReset(login string) error {
+	if h.ResetNotifier == nil {
+		return profile.ErrNoResetAddress
+	}
+	userId, _, err := h.ProfileRepo.GetCredentials(login)
+	if err != nil {
+		return err
+	}
+	// only a verified email is known to belong to the user, a login that looks
+	// like an address may be someone else's mailbox
+	email, verified, err := h.ProfileRepo.GetEmail(userId)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return profile.ErrNoResetAddress
+	}
+	label, err := h.ProfileRepo.GetLabel(userId)
+	if err != nil {
+		return err
+	}
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return err
+	}
+	err = h.ProfileRepo.CreateResetToken(userId, tokenHash, h.Now().Add(ResetTokenTTL))
+	if err != nil {
+		return err
+	}
+	return h.ResetNotifier.SendPasswordReset(email, label.Name, token)
+}
+
+func (h ProfileUseCase) ResetPassword(token, newPassword string) (int, error) {
+	if token == "" {
+		return 0, profile.ErrInvalidResetToken
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.MinCost)
+	if err != nil {
+		return 0, err
+	}
+	return h.ProfileRepo.ResetPassword(hashToken(token), string(hashed), h.Now())
+}
+
+func (h ProfileUseCase) sendVerification(userId int, email, name string) error {
+	if h.VerifyNotifier == nil {
+		return nil
+	}
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return err
+	}
+	err = h.ProfileRepo.CreateVerificationToken(userId, email, tokenHash, h.Now().Add(VerificationTokenTTL))
+	if err != nil {
+		return err
+	}
+	return h.VerifyNotifier.SendVerification(email, name, token)
+}
+
+func (h ProfileUseCase) GetEmail(userId int) (models.EmailStatus, error) {
+	email, verified, err := h.ProfileRepo.GetEmail(userId)
+	if err != nil {
+		return models.EmailStatus{}, err
+	}
+	return models.EmailStatus{Email: email, Verified: verified}, nil
+}
+
+func (h ProfileUseCase) SetEmail(userId int, email string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	current, verified, err := h.ProfileRepo.GetEmail(userId)
+	if err != nil {
+		return err
+	}
+	if current == email && verified {
+		return profile.ErrEmailVerified
+	}
+	taken, err := h.ProfileRepo.IsEmailTaken(email, userId)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return profile.ErrEmailTaken
+	}
+	if err = h.ProfileRepo.SetEmail(userId, email); err != nil {
+		return err
+	}
+	label, err := h.ProfileRepo.GetLabel(userId)
+	if err != nil {
+		return err
+	}
+	return h.sendVerification(userId, email, label.Name)
+}
+
+func (h ProfileUseCase) ResendVerification(userId int) error {
+	email, verified, err := h.ProfileRepo.GetEmail(userId)
+	if err != nil {
+		return err
+	}
+	if email == "" {
+		return profile.ErrInvalidEmail
+	}
+	if verified {
+		return profile.ErrEmailVerified
+	}
+	label, err := h.ProfileRepo.GetLabel(userId)
+	if err != nil {
+		return err
+	}
+	return h.sendVerification(userId, email, label.Name)
+}
+
+func (h ProfileUseCase) VerifyEmail(token string) error {
+	if token == "" {
+		return profile.ErrInvalidVerificationToken
+	}
+	_, err := h.ProfileRepo.VerifyEmail(hashToken(token), h.Now())
+	return err
+}
+
+func (h ProfileUseCase) IsEmailVerified(userId int) (bool, error) {
+	_, verified, err := h.ProfileRepo.GetEmail(userId)
+	return verified, err
+}
@@ -5,11 +5,13 @@ import (
 	"github.com/golang/mock/gomock"
 	"golang.org/x/crypto/bcrypt"
 	"konami_backend/internal/pkg/models"
+	"konami_backend/internal/pkg/notification"
 	"konami_backend/internal/pkg/profile"
 	"konami_backend/internal/pkg/tag"
 	uploadsHandlerPkg "konami_backend/internal/pkg/utils/uploads_handler"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestTag(t *testing.T) {
@@ -21,7 +23,7 @@ func TestTag(t *testing.T) {
 
 		tagRepo := tag.NewMockRepository(ctrl)
 
-		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, "", "")
+		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, nil, nil, nil, "", "")
 
 		hashed, _ := bcrypt.GenerateFromPassword([]byte("qwerty"), bcrypt.MinCost)
 
@@ -43,7 +45,7 @@ func TestTag(t *testing.T) {
 
 		tagRepo := tag.NewMockRepository(ctrl)
 
-		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, "", "")
+		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, nil, nil, nil, "", "")
 
 		hashed, _ := bcrypt.GenerateFromPassword([]byte("qwerty"), bcrypt.MinCost)
 
@@ -65,7 +67,7 @@ func TestTag(t *testing.T) {
 
 		tagRepo := tag.NewMockRepository(ctrl)
 
-		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, "", "")
+		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, nil, nil, nil, "", "")
 
 		proRepo.EXPECT().
 			GetCredentials("qwerty").
@@ -77,6 +79,23 @@ func TestTag(t *testing.T) {
 		})
 	})
 
+	t.Run("ResolveLogin", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		proRepo := profile.NewMockRepository(ctrl)
+		p := NewProfileUseCase(proRepo, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), nil, nil, nil, nil, "", "")
+
+		proRepo.EXPECT().GetCredentials("ivan@mail.ru").Return(7, "hash", nil)
+		if userId, err := p.ResolveLogin("ivan@mail.ru"); err != nil || userId != 7 {
+			t.Errorf("unexpected result: %d, %v", userId, err)
+		}
+
+		proRepo.EXPECT().GetCredentials("petr").Return(0, "", profile.ErrUserNonExistent)
+		if _, err := p.ResolveLogin("petr"); err != profile.ErrUserNonExistent {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
 	t.Run("TestUploadErr", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -85,7 +104,7 @@ func TestTag(t *testing.T) {
 
 		tagRepo := tag.NewMockRepository(ctrl)
 
-		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, "", "")
+		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, nil, nil, nil, "", "")
 
 		r := strings.NewReader("abcde")
 
@@ -100,7 +119,7 @@ func TestTag(t *testing.T) {
 
 		tagRepo := tag.NewMockRepository(ctrl)
 
-		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, "", "")
+		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, nil, nil, nil, "", "")
 
 		testProfile := models.Profile{
 			Card:        nil,
@@ -146,7 +165,7 @@ func TestTag(t *testing.T) {
 
 		tagRepo := tag.NewMockRepository(ctrl)
 
-		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, "", "")
+		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, nil, nil, nil, "", "")
 
 		testProfile := models.Profile{
 			Card:        nil,
@@ -202,7 +221,7 @@ func TestTag(t *testing.T) {
 
 		tagRepo := tag.NewMockRepository(ctrl)
 
-		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, "", "")
+		p := NewProfileUseCase(proRepo, uploadsHandler, tagRepo, nil, nil, nil, "", "")
 
 		proRepo.EXPECT().
 			GetAll(profile.FilterParams{}).
@@ -230,4 +249,199 @@ func TestTag(t *testing.T) {
 		_, _ = p.CreateSubscription(3, 4)
 		_ = p.RemoveSubscription(3, 4)
 	})
+
+	t.Run("SubscriptionNotifies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		proRepo := profile.NewMockRepository(ctrl)
+		notifier := notification.NewMockUseCase(ctrl)
+		p := NewProfileUseCase(proRepo, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), nil, notifier, nil, nil, "", "")
+
+		proRepo.EXPECT().CreateSubscription(3, 4).Return(1, nil)
+		notifier.EXPECT().Emit(notification.Event{Type: notification.TypeSubscribed, UserId: 4, ActorId: 3})
+		if _, err := p.CreateSubscription(3, 4); err != nil {
+			t.Error(err)
+		}
+
+		proRepo.EXPECT().CreateSubscription(3, 5).Return(0, profile.ErrUserNonExistent)
+		if _, err := p.CreateSubscription(3, 5); err != profile.ErrUserNonExistent {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ChangePassword", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		proRepo := profile.NewMockRepository(ctrl)
+		p := NewProfileUseCase(proRepo, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), nil, nil, nil, nil, "", "")
+
+		hashed, _ := bcrypt.GenerateFromPassword([]byte("qwerty"), bcrypt.MinCost)
+		proRepo.EXPECT().GetPwdHash(1).Return(string(hashed), nil).Times(2)
+		if err := p.ChangePassword(1, "wrong", "new"); err != profile.ErrInvalidCredentials {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		proRepo.EXPECT().SetPwdHash(1, gomock.Any()).DoAndReturn(func(_ int, pwdHash string) error {
+			if bcrypt.CompareHashAndPassword([]byte(pwdHash), []byte("new")) != nil {
+				t.Error("new password is not hashed")
+			}
+			return nil
+		})
+		if err := p.ChangePassword(1, "qwerty", "new"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("PasswordReset", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		proRepo := profile.NewMockRepository(ctrl)
+		resetNotifier := profile.NewMockResetNotifier(ctrl)
+		p := NewProfileUseCase(proRepo, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), nil, nil, resetNotifier, nil, "", "")
+		now := time.Date(2020, 12, 10, 12, 0, 0, 0, time.UTC)
+		p.(*ProfileUseCase).Now = func() time.Time { return now }
+
+		proRepo.EXPECT().GetCredentials("nobody").Return(0, "", profile.ErrUserNonExistent)
+		if err := p.RequestPasswordReset("nobody"); err != profile.ErrUserNonExistent {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// a login that looks like an address was never verified
+		proRepo.EXPECT().GetCredentials("user@mail.ru").Return(1, "", nil)
+		proRepo.EXPECT().GetEmail(1).Return("", false, nil)
+		if err := p.RequestPasswordReset("user@mail.ru"); err != profile.ErrNoResetAddress {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		var storedHash, sentToken string
+		proRepo.EXPECT().GetCredentials("ivan").Return(1, "", nil)
+		proRepo.EXPECT().GetEmail(1).Return("ivan@mail.ru", true, nil)
+		proRepo.EXPECT().GetLabel(1).Return(models.ProfileLabel{Id: 1, Name: "Ivan"}, nil)
+		proRepo.EXPECT().CreateResetToken(1, gomock.Any(), now.Add(ResetTokenTTL)).
+			DoAndReturn(func(_ int, tokenHash string, _ time.Time) error {
+				storedHash = tokenHash
+				return nil
+			})
+		resetNotifier.EXPECT().SendPasswordReset("ivan@mail.ru", "Ivan", gomock.Any()).
+			DoAndReturn(func(_, _, token string) error {
+				sentToken = token
+				return nil
+			})
+		if err := p.RequestPasswordReset("ivan"); err != nil {
+			t.Error(err)
+		}
+		if sentToken == "" || sentToken == storedHash || hashToken(sentToken) != storedHash {
+			t.Error("only the hash of the sent token must be stored")
+		}
+
+		proRepo.EXPECT().ResetPassword(storedHash, gomock.Any(), now).Return(1, nil)
+		userId, err := p.ResetPassword(sentToken, "new")
+		if err != nil || userId != 1 {
+			t.Errorf("unexpected result: %d, %v", userId, err)
+		}
+
+		if _, err = p.ResetPassword("", "new"); err != profile.ErrInvalidResetToken {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("SignUpWithEmail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		proRepo := profile.NewMockRepository(ctrl)
+		verifyNotifier := profile.NewMockVerificationNotifier(ctrl)
+		p := NewProfileUseCase(proRepo, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), nil, nil, nil, verifyNotifier, "", "")
+		now := time.Date(2020, 12, 10, 12, 0, 0, 0, time.UTC)
+		p.(*ProfileUseCase).Now = func() time.Time { return now }
+
+		if _, err := p.SignUp(models.Credentials{Login: "ivan", Password: "qwerty", Email: "not an email"}); err != profile.ErrInvalidEmail {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		proRepo.EXPECT().IsEmailTaken("ivan@mail.ru", 0).Return(true, nil)
+		if _, err := p.SignUp(models.Credentials{Login: "ivan", Password: "qwerty", Email: "Ivan@Mail.ru"}); err != profile.ErrEmailTaken {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		proRepo.EXPECT().IsEmailTaken("ivan@mail.ru", 0).Return(true, nil)
+		if _, err := p.SignUp(models.Credentials{Login: "Ivan@Mail.ru", Password: "qwerty"}); err != profile.ErrLoginTaken {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		var storedHash string
+		proRepo.EXPECT().IsEmailTaken("ivan@mail.ru", 0).Return(false, nil)
+		proRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(prof models.Profile) (int, error) {
+			if prof.Email != "ivan@mail.ru" || prof.Login != "ivan" {
+				t.Errorf("unexpected profile: %v", prof)
+			}
+			return 1, nil
+		})
+		proRepo.EXPECT().CreateVerificationToken(1, "ivan@mail.ru", gomock.Any(), now.Add(VerificationTokenTTL)).
+			DoAndReturn(func(_ int, _ string, tokenHash string, _ time.Time) error {
+				storedHash = tokenHash
+				return nil
+			})
+		verifyNotifier.EXPECT().SendVerification("ivan@mail.ru", "Пользователь", gomock.Any()).
+			DoAndReturn(func(_, _, token string) error {
+				if hashToken(token) != storedHash {
+					t.Error("only the hash of the sent token must be stored")
+				}
+				return errors.New("queue is down")
+			})
+		userId, err := p.SignUp(models.Credentials{Login: "ivan", Password: "qwerty", Email: " Ivan@Mail.ru"})
+		if err != nil || userId != 1 {
+			t.Errorf("unexpected result: %d, %v", userId, err)
+		}
+	})
+
+	t.Run("Email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		proRepo := profile.NewMockRepository(ctrl)
+		verifyNotifier := profile.NewMockVerificationNotifier(ctrl)
+		p := NewProfileUseCase(proRepo, uploadsHandlerPkg.NewUploadsHandler("uploadsDir"), nil, nil, nil, verifyNotifier, "", "")
+		now := time.Date(2020, 12, 10, 12, 0, 0, 0, time.UTC)
+		p.(*ProfileUseCase).Now = func() time.Time { return now }
+
+		proRepo.EXPECT().GetEmail(1).Return("ivan@mail.ru", true, nil)
+		if err := p.SetEmail(1, "ivan@mail.ru"); err != profile.ErrEmailVerified {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		proRepo.EXPECT().GetEmail(1).Return("ivan@mail.ru", true, nil)
+		proRepo.EXPECT().IsEmailTaken("new@mail.ru", 1).Return(false, nil)
+		proRepo.EXPECT().SetEmail(1, "new@mail.ru").Return(nil)
+		proRepo.EXPECT().GetLabel(1).Return(models.ProfileLabel{Id: 1, Name: "Ivan"}, nil)
+		proRepo.EXPECT().CreateVerificationToken(1, "new@mail.ru", gomock.Any(), now.Add(VerificationTokenTTL)).Return(nil)
+		verifyNotifier.EXPECT().SendVerification("new@mail.ru", "Ivan", gomock.Any()).Return(nil)
+		if err := p.SetEmail(1, "new@mail.ru"); err != nil {
+			t.Error(err)
+		}
+
+		proRepo.EXPECT().GetEmail(1).Return("", false, nil)
+		if err := p.ResendVerification(1); err != profile.ErrInvalidEmail {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		proRepo.EXPECT().GetEmail(1).Return("new@mail.ru", false, nil)
+		proRepo.EXPECT().GetLabel(1).Return(models.ProfileLabel{Id: 1, Name: "Ivan"}, nil)
+		proRepo.EXPECT().CreateVerificationToken(1, "new@mail.ru", gomock.Any(), now.Add(VerificationTokenTTL)).Return(nil)
+		verifyNotifier.EXPECT().SendVerification("new@mail.ru", "Ivan", gomock.Any()).Return(nil)
+		if err := p.ResendVerification(1); err != nil {
+			t.Error(err)
+		}
+
+		proRepo.EXPECT().VerifyEmail(hashToken("abc"), now).Return(1, nil)
+		if err := p.VerifyEmail("abc"); err != nil {
+			t.Error(err)
+		}
+		if err := p.VerifyEmail(""); err != profile.ErrInvalidVerificationToken {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		proRepo.EXPECT().GetEmail(1).Return("new@mail.ru", false, nil)
+		if verified, err := p.IsEmailVerified(1); err != nil || verified {
+			t.Errorf("unexpected result: %v, %v", verified, err)
+		}
+	})
 }
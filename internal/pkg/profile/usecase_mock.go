@@ -11,6 +11,80 @@ import (
 	reflect "reflect"
 )
 
+// MockResetNotifier is a mock of ResetNotifier interface
+type MockResetNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockResetNotifierMockRecorder
+}
+
+// MockResetNotifierMockRecorder is the mock recorder for MockResetNotifier
+type MockResetNotifierMockRecorder struct {
+	mock *MockResetNotifier
+}
+
+// NewMockResetNotifier creates a new mock instance
+func NewMockResetNotifier(ctrl *gomock.Controller) *MockResetNotifier {
+	mock := &MockResetNotifier{ctrl: ctrl}
+	mock.recorder = &MockResetNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockResetNotifier) EXPECT() *MockResetNotifierMockRecorder {
+	return m.recorder
+}
+
+// SendPasswordReset mocks base method
+func (m *MockResetNotifier) SendPasswordReset(address, name, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendPasswordReset", address, name, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendPasswordReset indicates an expected call of SendPasswordReset
+func (mr *MockResetNotifierMockRecorder) SendPasswordReset(address, name, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPasswordReset", reflect.TypeOf((*MockResetNotifier)(nil).SendPasswordReset), address, name, token)
+}
+
+// MockVerificationNotifier is a mock of VerificationNotifier interface
+type MockVerificationNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockVerificationNotifierMockRecorder
+}
+
+// MockVerificationNotifierMockRecorder is the mock recorder for MockVerificationNotifier
+type MockVerificationNotifierMockRecorder struct {
+	mock *MockVerificationNotifier
+}
+
+// NewMockVerificationNotifier creates a new mock instance
+func NewMockVerificationNotifier(ctrl *gomock.Controller) *MockVerificationNotifier {
+	mock := &MockVerificationNotifier{ctrl: ctrl}
+	mock.recorder = &MockVerificationNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVerificationNotifier) EXPECT() *MockVerificationNotifierMockRecorder {
+	return m.recorder
+}
+
+// SendVerification mocks base method
+func (m *MockVerificationNotifier) SendVerification(email, name, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerification", email, name, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerification indicates an expected call of SendVerification
+func (mr *MockVerificationNotifierMockRecorder) SendVerification(email, name, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerification", reflect.TypeOf((*MockVerificationNotifier)(nil).SendVerification), email, name, token)
+}
+
 // MockUseCase is a mock of UseCase interface
 type MockUseCase struct {
 	ctrl     *gomock.Controller
@@ -165,3 +239,133 @@ func (mr *MockUseCaseMockRecorder) Validate(cred interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockUseCase)(nil).Validate), cred)
 }
+
+// ResolveLogin mocks base method
+func (m *MockUseCase) ResolveLogin(login string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveLogin", login)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveLogin indicates an expected call of ResolveLogin
+func (mr *MockUseCaseMockRecorder) ResolveLogin(login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveLogin", reflect.TypeOf((*MockUseCase)(nil).ResolveLogin), login)
+}
+
+// ChangePassword mocks base method
+func (m *MockUseCase) ChangePassword(userId int, oldPassword, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", userId, oldPassword, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword
+func (mr *MockUseCaseMockRecorder) ChangePassword(userId, oldPassword, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUseCase)(nil).ChangePassword), userId, oldPassword, newPassword)
+}
+
+// RequestPasswordReset mocks base method
+func (m *MockUseCase) RequestPasswordReset(login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset
+func (mr *MockUseCaseMockRecorder) RequestPasswordReset(login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockUseCase)(nil).RequestPasswordReset), login)
+}
+
+// ResetPassword mocks base method
+func (m *MockUseCase) ResetPassword(token, newPassword string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", token, newPassword)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword
+func (mr *MockUseCaseMockRecorder) ResetPassword(token, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUseCase)(nil).ResetPassword), token, newPassword)
+}
+
+// GetEmail mocks base method
+func (m *MockUseCase) GetEmail(userId int) (models.EmailStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmail", userId)
+	ret0, _ := ret[0].(models.EmailStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmail indicates an expected call of GetEmail
+func (mr *MockUseCaseMockRecorder) GetEmail(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmail", reflect.TypeOf((*MockUseCase)(nil).GetEmail), userId)
+}
+
+// SetEmail mocks base method
+func (m *MockUseCase) SetEmail(userId int, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmail", userId, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmail indicates an expected call of SetEmail
+func (mr *MockUseCaseMockRecorder) SetEmail(userId, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmail", reflect.TypeOf((*MockUseCase)(nil).SetEmail), userId, email)
+}
+
+// ResendVerification mocks base method
+func (m *MockUseCase) ResendVerification(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerification indicates an expected call of ResendVerification
+func (mr *MockUseCaseMockRecorder) ResendVerification(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockUseCase)(nil).ResendVerification), userId)
+}
+
+// VerifyEmail mocks base method
+func (m *MockUseCase) VerifyEmail(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail
+func (mr *MockUseCaseMockRecorder) VerifyEmail(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUseCase)(nil).VerifyEmail), token)
+}
+
+// IsEmailVerified mocks base method
+func (m *MockUseCase) IsEmailVerified(userId int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEmailVerified", userId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsEmailVerified indicates an expected call of IsEmailVerified
+func (mr *MockUseCaseMockRecorder) IsEmailVerified(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmailVerified", reflect.TypeOf((*MockUseCase)(nil).IsEmailVerified), userId)
+}
@@ -0,0 +1,49 @@
+package reminder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Reminder struct {
+	MeetId    int
+	UserId    int
+	Title     string
+	Address   string
+	StartDate time.Time
+	Offset    time.Duration
+	Name      string
+	Email     string
+	Telegram  string
+}
+
+type Sender interface {
+	Name() string
+	Send(r Reminder) error
+}
+
+// ParseOffsets reads a comma separated list of durations such as "24h,1h"
+func ParseOffsets(s string) ([]time.Duration, error) {
+	var offsets []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		offset, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		if offset <= 0 {
+			return nil, fmt.Errorf("reminder offset must be positive: %s", part)
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets, nil
+}
+
+func Text(r Reminder) string {
+	return fmt.Sprintf("Напоминание: встреча «%s» начнётся %s UTC, адрес: %s",
+		r.Title, r.StartDate.UTC().Format("02.01.2006 15:04"), r.Address)
+}
@@ -0,0 +1,13 @@
+//go:generate mockgen -source=repository.go -destination=./repositoty_mock.go -package=reminder
+package reminder
+
+import "time"
+
+type Repository interface {
+	// GetDue returns registrations for meetings that start in (from, to]
+	GetDue(from time.Time, to time.Time) ([]Reminder, error)
+	// Claim records the delivery, false means it was already claimed
+	Claim(meetId int, userId int, offset time.Duration) (bool, error)
+	// AcquireLease takes or extends the named lease unless another holder owns an unexpired one
+	AcquireLease(name string, holder string, now time.Time, until time.Time) (bool, error)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"konami_backend/internal/pkg/reminder"
+	"time"
+)
+
+type ReminderGormRepo struct {
+	db *gorm.DB
+}
+
+func NewReminderGormRepo(db *gorm.DB) reminder.Repository {
+	return &ReminderGormRepo{db: db}
+}
+
+// Delivery marks a reminder as sent so restarts and replicas skip it
+type Delivery struct {
+	Id        int   `gorm:"primaryKey;autoIncrement;"`
+	MeetingId int   `gorm:"uniqueIndex:reminder_delivery;"`
+	UserId    int   `gorm:"uniqueIndex:reminder_delivery;"`
+	Offset    int64 `gorm:"uniqueIndex:reminder_delivery;"`
+	SentAt    time.Time
+}
+
+func (d *Delivery) TableName() string {
+	return "reminder_deliveries"
+}
+
+type Lease struct {
+	Name      string `gorm:"primaryKey;"`
+	Holder    string
+	ExpiresAt time.Time
+}
+
+func (l *Lease) TableName() string {
+	return "scheduler_leases"
+}
+
+type dueRow struct {
+	MeetingId     int
+	UserId        int
+	Title         string
+	Address       string
+	StartDate     time.Time
+	Name          string
+	Email         *string
+	EmailVerified bool
+	Telegram      string
+}
+
+func (h *ReminderGormRepo) GetDue(from time.Time, to time.Time) ([]reminder.Reminder, error) {
+	var rows []dueRow
+	err := h.db.Table("registrations").
+		Select("registrations.meeting_id, registrations.user_id, meetings.title, meetings.address, "+
+			"meetings.start_date, profiles.name, profiles.email, profiles.email_verified, profiles.telegram").
+		Joins("JOIN meetings ON meetings.id = registrations.meeting_id").
+		Joins("JOIN profiles ON profiles.id = registrations.user_id").
+		Where("meetings.cancelled = ?", false).
+		Where("meetings.start_date > ?", from).
+		Where("meetings.start_date <= ?", to).
+		Order("meetings.start_date ASC, registrations.id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]reminder.Reminder, len(rows))
+	for i, row := range rows {
+		res[i] = reminder.Reminder{
+			MeetId:    row.MeetingId,
+			UserId:    row.UserId,
+			Title:     row.Title,
+			Address:   row.Address,
+			StartDate: row.StartDate,
+			Name:      row.Name,
+			Telegram:  row.Telegram,
+		}
+		if row.EmailVerified && row.Email != nil {
+			res[i].Email = *row.Email
+		}
+	}
+	return res, nil
+}
+
+func (h *ReminderGormRepo) Claim(meetId int, userId int, offset time.Duration) (bool, error) {
+	d := Delivery{MeetingId: meetId, UserId: userId, Offset: int64(offset / time.Second), SentAt: time.Now()}
+	db := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&d)
+	if db.Error != nil {
+		return false, db.Error
+	}
+	return db.RowsAffected == 1, nil
+}
+
+func (h *ReminderGormRepo) AcquireLease(name string, holder string, now time.Time, until time.Time) (bool, error) {
+	l := Lease{Name: name, Holder: holder, ExpiresAt: until}
+	db := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&l)
+	if db.Error != nil {
+		return false, db.Error
+	}
+	if db.RowsAffected == 1 {
+		return true, nil
+	}
+	db = h.db.Model(&Lease{}).
+		Where("name = ?", name).
+		Where("holder = ? OR expires_at < ?", holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": until})
+	if db.Error != nil {
+		return false, db.Error
+	}
+	return db.RowsAffected == 1, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+type Suite struct {
+	suite.Suite
+	DB   *gorm.DB
+	mock sqlmock.Sqlmock
+
+	bdError error
+}
+
+func (s *Suite) SetupSuite() {
+	var db *sql.DB
+	var err error
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(postgres.New(postgres.Config{
+		DriverName:           "postgres",
+		DSN:                  "sqlmock_db_0",
+		PreferSimpleProtocol: true,
+		Conn:                 db,
+	}), &gorm.Config{})
+	require.NoError(s.T(), err)
+
+	s.bdError = errors.New("some bd error")
+}
+
+func (s *Suite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestReminders(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestGetDue() {
+	repo := NewReminderGormRepo(s.DB)
+	from := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	s.mock.ExpectQuery("SELECT registrations.meeting_id, registrations.user_id, (.+) FROM \"registrations\" "+
+		"JOIN meetings ON (.+) JOIN profiles ON (.+) WHERE meetings.cancelled = (.+) "+
+		"AND meetings.start_date > (.+) AND meetings.start_date <= (.+) ORDER BY meetings.start_date ASC").
+		WithArgs(false, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"meeting_id", "user_id", "title", "start_date",
+			"email", "email_verified", "telegram"}).
+			AddRow(1, 4, "Go meetup", from.Add(time.Hour), "ann@example.com", true, "@ann").
+			AddRow(1, 5, "Go meetup", from.Add(time.Hour), "bob@example.com", false, "").
+			AddRow(1, 6, "Go meetup", from.Add(time.Hour), nil, false, ""))
+
+	due, err := repo.GetDue(from, to)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), due, 3)
+	require.Equal(s.T(), 1, due[0].MeetId)
+	require.Equal(s.T(), 4, due[0].UserId)
+	require.Equal(s.T(), "ann@example.com", due[0].Email)
+	require.Equal(s.T(), from.Add(time.Hour), due[0].StartDate)
+	require.Empty(s.T(), due[1].Email)
+	require.Empty(s.T(), due[2].Email)
+
+	s.mock.ExpectQuery("SELECT (.+) FROM \"registrations\"").
+		WillReturnError(s.bdError)
+	_, err = repo.GetDue(from, to)
+	require.True(s.T(), errors.Is(err, s.bdError))
+}
+
+func (s *Suite) TestClaim() {
+	repo := NewReminderGormRepo(s.DB)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"reminder_deliveries\" (.+) ON CONFLICT DO NOTHING").
+		WithArgs(1, 4, 3600, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+	claimed, err := repo.Claim(1, 4, time.Hour)
+	require.NoError(s.T(), err)
+	require.True(s.T(), claimed)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO \"reminder_deliveries\"").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+	claimed, err = repo.Claim(1, 4, time.Hour)
+	require.NoError(s.T(), err)
+	require.False(s.T(), claimed)
+}
+
+func (s *Suite) TestAcquireLease() {
+	repo := NewReminderGormRepo(s.DB)
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	until := now.Add(2 * time.Minute)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("INSERT INTO \"scheduler_leases\" (.+) ON CONFLICT DO NOTHING").
+		WithArgs("reminders", "a", until).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	acquired, err := repo.AcquireLease("reminders", "a", now, until)
+	require.NoError(s.T(), err)
+	require.True(s.T(), acquired)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("INSERT INTO \"scheduler_leases\"").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE \"scheduler_leases\" SET (.+) WHERE name = (.+) "+
+		"AND \\(holder = (.+) OR expires_at < (.+)\\)").
+		WithArgs(until, "b", "reminders", "b", now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+	acquired, err = repo.AcquireLease("reminders", "b", now, until)
+	require.NoError(s.T(), err)
+	require.False(s.T(), acquired)
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"github.com/google/uuid"
+	"konami_backend/internal/pkg/reminder"
+	"konami_backend/logger"
+	"os"
+	"sort"
+	"time"
+)
+
+const LeaseName = "meeting_reminders"
+
+// Scheduler sends meeting reminders from whichever replica holds the lease
+type Scheduler struct {
+	Repo     reminder.Repository
+	Senders  []reminder.Sender
+	Offsets  []time.Duration
+	Interval time.Duration
+	Holder   string
+	Log      *logger.Logger
+	Now      func() time.Time
+}
+
+func NewScheduler(repo reminder.Repository,
+	senders []reminder.Sender,
+	offsets []time.Duration,
+	interval time.Duration,
+	log *logger.Logger) *Scheduler {
+
+	host, _ := os.Hostname()
+	sorted := append([]time.Duration{}, offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &Scheduler{
+		Repo:     repo,
+		Senders:  senders,
+		Offsets:  sorted,
+		Interval: interval,
+		Holder:   host + "-" + uuid.New().String(),
+		Log:      log,
+		Now:      time.Now,
+	}
+}
+
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(); err != nil {
+			s.Log.LogError("reminder/scheduler", "Run", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick sends every reminder that became due; offsets must be sorted ascending.
+// A user who registers late only gets the closest reminder, not all passed ones.
+func (s *Scheduler) Tick() error {
+	if len(s.Offsets) == 0 {
+		return nil
+	}
+	now := s.Now()
+	acquired, err := s.Repo.AcquireLease(LeaseName, s.Holder, now, now.Add(2*s.Interval))
+	if err != nil || !acquired {
+		return err
+	}
+	due, err := s.Repo.GetDue(now, now.Add(s.Offsets[len(s.Offsets)-1]))
+	if err != nil {
+		return err
+	}
+	for _, r := range due {
+		r.Offset = s.offsetFor(r.StartDate.Sub(now))
+		claimed, err := s.Repo.Claim(r.MeetId, r.UserId, r.Offset)
+		if err != nil {
+			s.Log.LogError("reminder/scheduler", "Tick", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		for _, sender := range s.Senders {
+			if err := sender.Send(r); err != nil {
+				s.Log.LogWarning("reminder/scheduler", "Tick", sender.Name()+": "+err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) offsetFor(left time.Duration) time.Duration {
+	for _, offset := range s.Offsets {
+		if left <= offset {
+			return offset
+		}
+	}
+	return s.Offsets[len(s.Offsets)-1]
+}
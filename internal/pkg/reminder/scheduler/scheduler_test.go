@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"konami_backend/internal/pkg/reminder"
+	"konami_backend/logger"
+	"testing"
+	"time"
+)
+
+type fakeSender struct {
+	sent []reminder.Reminder
+	err  error
+}
+
+func (s *fakeSender) Name() string {
+	return "fake"
+}
+
+func (s *fakeSender) Send(r reminder.Reminder) error {
+	s.sent = append(s.sent, r)
+	return s.err
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestScheduler(t *testing.T) {
+	log := logger.NewLogger(ioutil.Discard)
+	start := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+
+	t.Run("SendsClosestOffset", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := reminder.NewMockRepository(ctrl)
+		inApp, email := &fakeSender{}, &fakeSender{err: errors.New("smtp down")}
+		clock := &fakeClock{now: start.Add(-20 * time.Hour)}
+		s := NewScheduler(repo, []reminder.Sender{inApp, email},
+			[]time.Duration{time.Hour, 24 * time.Hour}, time.Minute, log)
+		s.Now = clock.Now
+		s.Holder = "replica-1"
+
+		due := []reminder.Reminder{{MeetId: 1, UserId: 4, StartDate: start}}
+		repo.EXPECT().AcquireLease(LeaseName, "replica-1", clock.now, clock.now.Add(2*time.Minute)).Return(true, nil)
+		repo.EXPECT().GetDue(clock.now, clock.now.Add(24*time.Hour)).Return(due, nil)
+		repo.EXPECT().Claim(1, 4, 24*time.Hour).Return(true, nil)
+		assert.NoError(t, s.Tick())
+		assert.Len(t, inApp.sent, 1)
+		assert.Equal(t, 24*time.Hour, inApp.sent[0].Offset)
+		assert.Len(t, email.sent, 1)
+
+		// The 24h reminder is already claimed by now
+		clock.now = start.Add(-19 * time.Hour)
+		repo.EXPECT().AcquireLease(LeaseName, "replica-1", clock.now, gomock.Any()).Return(true, nil)
+		repo.EXPECT().GetDue(clock.now, gomock.Any()).Return(due, nil)
+		repo.EXPECT().Claim(1, 4, 24*time.Hour).Return(false, nil)
+		assert.NoError(t, s.Tick())
+		assert.Len(t, inApp.sent, 1)
+
+		clock.now = start.Add(-45 * time.Minute)
+		repo.EXPECT().AcquireLease(LeaseName, "replica-1", clock.now, gomock.Any()).Return(true, nil)
+		repo.EXPECT().GetDue(clock.now, gomock.Any()).Return(due, nil)
+		repo.EXPECT().Claim(1, 4, time.Hour).Return(true, nil)
+		assert.NoError(t, s.Tick())
+		assert.Len(t, inApp.sent, 2)
+		assert.Equal(t, time.Hour, inApp.sent[1].Offset)
+	})
+
+	t.Run("LeaseHeldElsewhere", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		repo := reminder.NewMockRepository(ctrl)
+		sender := &fakeSender{}
+		clock := &fakeClock{now: start.Add(-time.Hour)}
+		s := NewScheduler(repo, []reminder.Sender{sender}, []time.Duration{time.Hour}, time.Minute, log)
+		s.Now = clock.Now
+
+		repo.EXPECT().AcquireLease(LeaseName, s.Holder, clock.now, gomock.Any()).Return(false, nil)
+		assert.NoError(t, s.Tick())
+		assert.Empty(t, sender.sent)
+
+		dbErr := errors.New("db error")
+		repo.EXPECT().AcquireLease(LeaseName, s.Holder, clock.now, gomock.Any()).Return(true, nil)
+		repo.EXPECT().GetDue(clock.now, clock.now.Add(time.Hour)).Return(nil, dbErr)
+		assert.Equal(t, dbErr, s.Tick())
+	})
+
+	t.Run("NoOffsets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s := NewScheduler(reminder.NewMockRepository(ctrl), nil, nil, time.Minute, log)
+		assert.NoError(t, s.Tick())
+	})
+
+	t.Run("ParseOffsets", func(t *testing.T) {
+		offsets, err := reminder.ParseOffsets("24h, 1h,")
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Duration{24 * time.Hour, time.Hour}, offsets)
+		_, err = reminder.ParseOffsets("-1h")
+		assert.Error(t, err)
+		_, err = reminder.ParseOffsets("soon")
+		assert.Error(t, err)
+	})
+}
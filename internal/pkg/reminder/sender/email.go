@@ -0,0 +1,29 @@
+package sender
+
+import (
+	"konami_backend/internal/pkg/mail"
+	"konami_backend/internal/pkg/reminder"
+)
+
+// EmailSender queues reminders through the mail subsystem
+type EmailSender struct {
+	Mail mail.UseCase
+	Lang string
+}
+
+func (s *EmailSender) Name() string {
+	return "email"
+}
+
+// Send skips users without a verified email
+func (s *EmailSender) Send(r reminder.Reminder) error {
+	if r.Email == "" {
+		return nil
+	}
+	return s.Mail.Send(r.Email, s.Lang, mail.TemplateReminder, mail.ReminderData{
+		Name:    r.Name,
+		Title:   r.Title,
+		Address: r.Address,
+		Start:   r.StartDate.UTC().Format("02.01.2006 15:04") + " UTC",
+	})
+}
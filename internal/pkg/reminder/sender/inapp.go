@@ -0,0 +1,24 @@
+package sender
+
+import (
+	"konami_backend/internal/pkg/notification"
+	"konami_backend/internal/pkg/reminder"
+)
+
+// InAppSender stores reminders in the notification center
+type InAppSender struct {
+	Emitter notification.Emitter
+}
+
+func (s *InAppSender) Name() string {
+	return "inapp"
+}
+
+func (s *InAppSender) Send(r reminder.Reminder) error {
+	s.Emitter.Emit(notification.Event{
+		Type:   notification.TypeReminder,
+		UserId: r.UserId,
+		MeetId: r.MeetId,
+	})
+	return nil
+}
@@ -0,0 +1,70 @@
+package sender
+
+import (
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"konami_backend/internal/pkg/mail"
+	"konami_backend/internal/pkg/notification"
+	"konami_backend/internal/pkg/reminder"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSenders(t *testing.T) {
+	r := reminder.Reminder{MeetId: 1, UserId: 4, Title: "Go meetup", Address: "Moscow",
+		StartDate: time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC), Offset: time.Hour,
+		Email: "ann@example.com", Telegram: "@ann"}
+
+	t.Run("InApp", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		emitter := notification.NewMockUseCase(ctrl)
+		s := &InAppSender{Emitter: emitter}
+
+		emitter.EXPECT().Emit(notification.Event{Type: notification.TypeReminder, UserId: 4, MeetId: 1})
+		assert.NoError(t, s.Send(r))
+	})
+
+	t.Run("Email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mail.NewMockUseCase(ctrl)
+		s := &EmailSender{Mail: m, Lang: mail.LangEn}
+
+		m.EXPECT().Send("ann@example.com", mail.LangEn, mail.TemplateReminder, mail.ReminderData{
+			Title: "Go meetup", Address: "Moscow", Start: "05.12.2020 10:00 UTC"}).Return(nil)
+		assert.NoError(t, s.Send(r))
+
+		noEmail := r
+		noEmail.Email = ""
+		assert.NoError(t, s.Send(noEmail))
+	})
+
+	t.Run("Webhook", func(t *testing.T) {
+		var got webhookMessage
+		status := http.StatusOK
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&got))
+			w.WriteHeader(status)
+		}))
+		defer srv.Close()
+		s := NewWebhookSender(srv.URL)
+
+		assert.NoError(t, s.Send(r))
+		assert.Equal(t, "@ann", got.Chat)
+		assert.Equal(t, 1, got.MeetId)
+		assert.Equal(t, reminder.Text(r), got.Text)
+
+		status = http.StatusBadGateway
+		assert.Error(t, s.Send(r))
+
+		got = webhookMessage{}
+		noTelegram := r
+		noTelegram.Telegram = ""
+		assert.NoError(t, s.Send(noTelegram))
+		assert.Equal(t, webhookMessage{}, got)
+	})
+}
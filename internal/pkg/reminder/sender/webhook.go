@@ -0,0 +1,51 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"konami_backend/internal/pkg/reminder"
+	"net/http"
+	"time"
+)
+
+// WebhookSender posts reminders to a Telegram style bot gateway
+type WebhookSender struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookSender(url string) *WebhookSender {
+	return &WebhookSender{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+type webhookMessage struct {
+	Chat   string `json:"chat"`
+	UserId int    `json:"userId"`
+	MeetId int    `json:"meetId"`
+	Text   string `json:"text"`
+}
+
+func (s *WebhookSender) Name() string {
+	return "webhook"
+}
+
+// Send skips users without a Telegram handle in their profile
+func (s *WebhookSender) Send(r reminder.Reminder) error {
+	if r.Telegram == "" {
+		return nil
+	}
+	body, err := json.Marshal(webhookMessage{Chat: r.Telegram, UserId: r.UserId, MeetId: r.MeetId, Text: reminder.Text(r)})
+	if err != nil {
+		return err
+	}
+	resp, err := s.Client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package auth_cache
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"konami_backend/proto/auth"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	userId  int64
+	expires time.Time
+}
+
+// CachedAuthClient answers repeated Check calls for the same token from memory
+// for a short time. Sessions revoked through this client are evicted at once,
+// revocations made through other instances take effect within the ttl.
+type CachedAuthClient struct {
+	auth.AuthCheckerClient
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[string]entry
+	lastSweep time.Time
+	Now       func() time.Time
+}
+
+func NewCachedAuthClient(client auth.AuthCheckerClient, ttl time.Duration) *CachedAuthClient {
+	return &CachedAuthClient{
+		AuthCheckerClient: client,
+		ttl:               ttl,
+		entries:           make(map[string]entry),
+		Now:               time.Now,
+	}
+}
+
+func (c *CachedAuthClient) Check(ctx context.Context, in *auth.SessionToken, opts ...grpc.CallOption) (
+	*auth.Session, error,
+) {
+	c.mu.Lock()
+	now := c.Now()
+	c.sweep(now)
+	e, ok := c.entries[in.Token]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return &auth.Session{UserId: e.userId}, nil
+	}
+	sess, err := c.AuthCheckerClient.Check(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[in.Token] = entry{userId: sess.UserId, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return sess, nil
+}
+
+func (c *CachedAuthClient) Delete(ctx context.Context, in *auth.SessionToken, opts ...grpc.CallOption) (
+	*auth.Nothing, error,
+) {
+	c.mu.Lock()
+	delete(c.entries, in.Token)
+	c.mu.Unlock()
+	return c.AuthCheckerClient.Delete(ctx, in, opts...)
+}
+
+func (c *CachedAuthClient) Revoke(ctx context.Context, in *auth.RevokeRequest, opts ...grpc.CallOption) (
+	*auth.Nothing, error,
+) {
+	// the token of the revoked session is unknown here
+	c.evictUser(in.UserId, "")
+	return c.AuthCheckerClient.Revoke(ctx, in, opts...)
+}
+
+func (c *CachedAuthClient) RevokeAll(ctx context.Context, in *auth.RevokeAllRequest, opts ...grpc.CallOption) (
+	*auth.Nothing, error,
+) {
+	c.evictUser(in.UserId, in.ExceptToken)
+	return c.AuthCheckerClient.RevokeAll(ctx, in, opts...)
+}
+
+func (c *CachedAuthClient) evictUser(userId int64, exceptToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for token, e := range c.entries {
+		if e.userId == userId && token != exceptToken {
+			delete(c.entries, token)
+		}
+	}
+}
+
+// sweep forgets expired entries, it is called with the lock held
+func (c *CachedAuthClient) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for token, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, token)
+		}
+	}
+}
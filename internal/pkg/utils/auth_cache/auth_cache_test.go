@@ -0,0 +1,82 @@
+package auth_cache
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"konami_backend/auth/pkg/session"
+	"konami_backend/proto/auth"
+	"testing"
+	"time"
+)
+
+func TestCachedAuthClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	m := session.NewMockAuthCheckerClient(ctrl)
+	c := NewCachedAuthClient(m, 10*time.Second)
+	c.Now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// only the first check reaches the auth service
+	m.EXPECT().Check(gomock.Any(), &auth.SessionToken{Token: "a"}).Return(&auth.Session{UserId: 4}, nil)
+	for i := 0; i < 3; i++ {
+		sess, err := c.Check(ctx, &auth.SessionToken{Token: "a"})
+		require.NoError(t, err)
+		require.Equal(t, int64(4), sess.UserId)
+	}
+
+	// failed checks are never cached
+	m.EXPECT().Check(gomock.Any(), &auth.SessionToken{Token: "b"}).
+		Return(nil, status.Error(codes.NotFound, "expired")).Times(2)
+	_, err := c.Check(ctx, &auth.SessionToken{Token: "b"})
+	require.Error(t, err)
+	_, err = c.Check(ctx, &auth.SessionToken{Token: "b"})
+	require.Error(t, err)
+
+	now = now.Add(11 * time.Second)
+	m.EXPECT().Check(gomock.Any(), &auth.SessionToken{Token: "a"}).Return(&auth.Session{UserId: 4}, nil)
+	_, err = c.Check(ctx, &auth.SessionToken{Token: "a"})
+	require.NoError(t, err)
+
+	m.EXPECT().Delete(gomock.Any(), &auth.SessionToken{Token: "a"}).Return(&auth.Nothing{Dummy: true}, nil)
+	_, err = c.Delete(ctx, &auth.SessionToken{Token: "a"})
+	require.NoError(t, err)
+	require.Empty(t, c.entries)
+}
+
+func TestCachedAuthClientRevoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := session.NewMockAuthCheckerClient(ctrl)
+	c := NewCachedAuthClient(m, time.Minute)
+	ctx := context.Background()
+
+	for _, token := range []string{"a", "b", "c"} {
+		m.EXPECT().Check(gomock.Any(), &auth.SessionToken{Token: token}).Return(&auth.Session{UserId: 4}, nil)
+		_, err := c.Check(ctx, &auth.SessionToken{Token: token})
+		require.NoError(t, err)
+	}
+	m.EXPECT().Check(gomock.Any(), &auth.SessionToken{Token: "d"}).Return(&auth.Session{UserId: 5}, nil)
+	_, err := c.Check(ctx, &auth.SessionToken{Token: "d"})
+	require.NoError(t, err)
+
+	m.EXPECT().RevokeAll(gomock.Any(), &auth.RevokeAllRequest{UserId: 4, ExceptToken: "a"}).
+		Return(&auth.Nothing{Dummy: true}, nil)
+	_, err = c.RevokeAll(ctx, &auth.RevokeAllRequest{UserId: 4, ExceptToken: "a"})
+	require.NoError(t, err)
+	require.Len(t, c.entries, 2)
+	require.Contains(t, c.entries, "a")
+
+	m.EXPECT().Revoke(gomock.Any(), &auth.RevokeRequest{UserId: 4, SessionId: 1}).
+		Return(&auth.Nothing{Dummy: true}, nil)
+	_, err = c.Revoke(ctx, &auth.RevokeRequest{UserId: 4, SessionId: 1})
+	require.NoError(t, err)
+	require.Len(t, c.entries, 1)
+	require.Contains(t, c.entries, "d")
+}
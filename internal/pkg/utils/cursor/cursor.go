@@ -0,0 +1,60 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor holds the sort key of the last item of a page.
+// Kind names the ordering the cursor was issued for.
+type Cursor struct {
+	Kind     string    `json:"k"`
+	Id       int       `json:"i"`
+	Likes    int       `json:"l,omitempty"`
+	Start    time.Time `json:"s"`
+	Distance float64   `json:"d,omitempty"`
+}
+
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Signer) Encode(c Cursor) string {
+	data, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + s.sign(payload)
+}
+
+// Decode verifies the signature and checks the cursor was issued for kind
+func (s *Signer) Decode(token string, kind string) (Cursor, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(s.sign(parts[0]))) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err = json.Unmarshal(data, &c); err != nil || c.Kind != kind {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
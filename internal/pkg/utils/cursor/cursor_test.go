@@ -0,0 +1,30 @@
+package cursor
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	s := NewSigner([]byte("secret"))
+	c := Cursor{Kind: "date", Id: 12, Start: time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)}
+	token := s.Encode(c)
+
+	res, err := s.Decode(token, "date")
+	assert.NoError(t, err)
+	assert.Equal(t, c.Id, res.Id)
+	assert.True(t, c.Start.Equal(res.Start))
+
+	_, err = s.Decode(token, "likes")
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	_, err = NewSigner([]byte("other")).Decode(token, "date")
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	_, err = s.Decode("a"+token, "date")
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	_, err = s.Decode("garbage", "date")
+	assert.Equal(t, ErrInvalidCursor, err)
+}
@@ -0,0 +1,51 @@
+package geocoder
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrNotFound = errors.New("location not found")
+
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+type Geocoder interface {
+	Geocode(city string, address string) (Point, error)
+}
+
+var DefaultCities = map[string]Point{
+	"москва":           {Lat: 55.7558, Lon: 37.6173},
+	"moscow":           {Lat: 55.7558, Lon: 37.6173},
+	"санкт-петербург":  {Lat: 59.9343, Lon: 30.3351},
+	"saint petersburg": {Lat: 59.9343, Lon: 30.3351},
+	"новосибирск":      {Lat: 55.0084, Lon: 82.9357},
+	"novosibirsk":      {Lat: 55.0084, Lon: 82.9357},
+	"екатеринбург":     {Lat: 56.8389, Lon: 60.6057},
+	"yekaterinburg":    {Lat: 56.8389, Lon: 60.6057},
+	"казань":           {Lat: 55.7887, Lon: 49.1221},
+	"kazan":            {Lat: 55.7887, Lon: 49.1221},
+	"нижний новгород":  {Lat: 56.2965, Lon: 43.9361},
+	"nizhny novgorod":  {Lat: 56.2965, Lon: 43.9361},
+}
+
+// CityGeocoder resolves meetings to their city center without network access
+type CityGeocoder struct {
+	Cities map[string]Point
+}
+
+func NewCityGeocoder(cities map[string]Point) Geocoder {
+	return &CityGeocoder{Cities: cities}
+}
+
+func (g *CityGeocoder) Geocode(city string, address string) (Point, error) {
+	key := strings.ToLower(strings.TrimSpace(city))
+	key = strings.TrimPrefix(key, "г. ")
+	p, ok := g.Cities[key]
+	if !ok {
+		return Point{}, ErrNotFound
+	}
+	return p, nil
+}
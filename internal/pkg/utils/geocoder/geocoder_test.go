@@ -0,0 +1,21 @@
+package geocoder
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCityGeocoder(t *testing.T) {
+	g := NewCityGeocoder(DefaultCities)
+
+	p, err := g.Geocode(" Москва", "Тверская 1")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCities["moscow"], p)
+
+	p, err = g.Geocode("г. Казань", "")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCities["kazan"], p)
+
+	_, err = g.Geocode("Atlantis", "")
+	assert.Equal(t, ErrNotFound, err)
+}
@@ -2,7 +2,10 @@ package http_utils
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -16,6 +19,13 @@ func WriteJson(w http.ResponseWriter, data interface{}) {
 	_ = json.NewEncoder(w).Encode(data)
 }
 
+// PageRequested tells clients of the cursor pagination, which send a cursor even if
+// it is empty, from legacy ones expecting a bare array
+func PageRequested(r *http.Request) bool {
+	_, ok := r.URL.Query()["cursor"]
+	return ok
+}
+
 func WriteError(w http.ResponseWriter, resp *ErrResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(resp.RespCode)
@@ -41,3 +51,80 @@ func RemoveAuthCookie(w http.ResponseWriter, value string) {
 	}
 	http.SetCookie(w, &cookie)
 }
+
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies takes the addresses or CIDRs of the balancers in front of the server,
+// only their X-Forwarded-For and X-Real-IP headers are believed
+func SetTrustedProxies(list []string) error {
+	var nets []*net.IPNet
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", item)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	trustedProxies = nets
+	return nil
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	for _, ipNet := range trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP is the peer address unless the peer is a trusted proxy, then the nearest
+// untrusted hop of X-Forwarded-For is taken, as the hops before it can be forged by the client
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil || !isTrustedProxy(peer) {
+		return host
+	}
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	var client net.IP
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !isTrustedProxy(ip) {
+			break
+		}
+	}
+	if client == nil {
+		client = net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	}
+	if client == nil {
+		return host
+	}
+	return client.String()
+}
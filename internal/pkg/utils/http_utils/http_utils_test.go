@@ -0,0 +1,35 @@
+package http_utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	defer func() { trustedProxies = nil }()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.5:4000"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1")
+	r.Header.Set("X-Real-IP", "1.1.1.1")
+	assert.Equal(t, "203.0.113.5", ClientIP(r))
+
+	assert.NoError(t, SetTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.1", ""}))
+	// headers from an untrusted peer are still ignored
+	assert.Equal(t, "203.0.113.5", ClientIP(r))
+
+	r.RemoteAddr = "10.0.0.2:4000"
+	r.Header.Set("X-Forwarded-For", "6.6.6.6, 198.51.100.7, 192.168.1.1")
+	assert.Equal(t, "198.51.100.7", ClientIP(r))
+
+	r.Header.Del("X-Forwarded-For")
+	r.Header.Set("X-Real-IP", "198.51.100.8")
+	assert.Equal(t, "198.51.100.8", ClientIP(r))
+
+	r.Header.Del("X-Real-IP")
+	assert.Equal(t, "10.0.0.2", ClientIP(r))
+
+	assert.Error(t, SetTrustedProxies([]string{"proxy"}))
+	assert.Error(t, SetTrustedProxies([]string{"10.0.0.0/33"}))
+}
@@ -0,0 +1,80 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+const timeLayout = "20060102T150405Z"
+
+// Lines longer than 75 octets must be folded (RFC 5545, 3.1)
+const maxLineLen = 75
+
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Cancelled   bool
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func Calendar(name string, events []Event, stamp time.Time) []byte {
+	buf := new(bytes.Buffer)
+	writeLine(buf, "BEGIN:VCALENDAR")
+	writeLine(buf, "VERSION:2.0")
+	writeLine(buf, "PRODID:-//Konami//Meetings//RU")
+	writeLine(buf, "CALSCALE:GREGORIAN")
+	writeLine(buf, "METHOD:PUBLISH")
+	writeLine(buf, "X-WR-CALNAME:"+textEscaper.Replace(name))
+	for _, e := range events {
+		writeLine(buf, "BEGIN:VEVENT")
+		writeLine(buf, "UID:"+e.UID)
+		writeLine(buf, "DTSTAMP:"+stamp.UTC().Format(timeLayout))
+		writeLine(buf, "DTSTART:"+e.Start.UTC().Format(timeLayout))
+		writeLine(buf, "DTEND:"+e.End.UTC().Format(timeLayout))
+		writeLine(buf, "SUMMARY:"+textEscaper.Replace(e.Summary))
+		if e.Description != "" {
+			writeLine(buf, "DESCRIPTION:"+textEscaper.Replace(e.Description))
+		}
+		if e.Location != "" {
+			writeLine(buf, "LOCATION:"+textEscaper.Replace(e.Location))
+		}
+		if e.Cancelled {
+			writeLine(buf, "STATUS:CANCELLED")
+		} else {
+			writeLine(buf, "STATUS:CONFIRMED")
+		}
+		writeLine(buf, "END:VEVENT")
+	}
+	writeLine(buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineLen
+	for len(line) > limit {
+		cut := limit
+		// Never split a multi-byte UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space which counts towards the limit
+		limit = maxLineLen - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
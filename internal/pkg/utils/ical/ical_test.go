@@ -0,0 +1,44 @@
+package ical
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar(t *testing.T) {
+	start := time.Date(2020, 12, 1, 22, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	stamp := time.Date(2020, 11, 30, 12, 0, 0, 0, time.UTC)
+	cal := string(Calendar("Konami", []Event{{
+		UID:         "meeting-1@konami",
+		Summary:     "Go; meetup, #1",
+		Description: "line1\nline2",
+		Location:    "Moscow",
+		Start:       start,
+		End:         start.Add(2 * time.Hour),
+		Cancelled:   true,
+	}}, stamp))
+
+	assert.True(t, strings.HasPrefix(cal, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(cal, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, cal, "DTSTAMP:20201130T120000Z\r\n")
+	assert.Contains(t, cal, "DTSTART:20201201T190000Z\r\n")
+	assert.Contains(t, cal, "DTEND:20201201T210000Z\r\n")
+	assert.Contains(t, cal, `SUMMARY:Go\; meetup\, #1`+"\r\n")
+	assert.Contains(t, cal, `DESCRIPTION:line1\nline2`+"\r\n")
+	assert.Contains(t, cal, "STATUS:CANCELLED\r\n")
+}
+
+func TestFolding(t *testing.T) {
+	cal := string(Calendar("Konami", []Event{{
+		Summary: strings.Repeat("встреча ", 30),
+	}}, time.Now()))
+
+	for _, line := range strings.Split(strings.TrimSuffix(cal, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineLen)
+		assert.True(t, strings.ToValidUTF8(line, "") == line)
+	}
+	unfolded := strings.ReplaceAll(cal, "\r\n ", "")
+	assert.Contains(t, unfolded, "SUMMARY:"+strings.Repeat("встреча ", 30))
+}
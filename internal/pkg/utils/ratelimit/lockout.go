@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Lockout blocks a key for a growing time after repeated failures
+type Lockout interface {
+	// Locked reports how long the key stays blocked, zero when it is free
+	Locked(key string) time.Duration
+	// Fail records a failure and returns the block it caused, if any
+	Fail(key string) time.Duration
+	Reset(key string)
+}
+
+// LockoutPolicy lets Threshold failures pass, every next one doubles the block
+// starting from Base up to Max. Failures are forgotten after Memory without new ones.
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	Memory    time.Duration
+}
+
+func (p LockoutPolicy) block(fails int) time.Duration {
+	if fails <= p.Threshold {
+		return 0
+	}
+	d := p.Base
+	for i := p.Threshold + 1; i < fails && d < p.Max; i++ {
+		d *= 2
+	}
+	if d > p.Max {
+		d = p.Max
+	}
+	return d
+}
+
+type lockState struct {
+	fails    int
+	until    time.Time
+	lastFail time.Time
+}
+
+type MemoryLockout struct {
+	mu        sync.Mutex
+	policy    LockoutPolicy
+	states    map[string]*lockState
+	lastSweep time.Time
+	Now       func() time.Time
+}
+
+func NewMemoryLockout(policy LockoutPolicy) *MemoryLockout {
+	return &MemoryLockout{
+		policy: policy,
+		states: make(map[string]*lockState),
+		Now:    time.Now,
+	}
+}
+
+func (l *MemoryLockout) Locked(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.Now()
+	l.sweep(now)
+	st, ok := l.states[key]
+	if !ok || !now.Before(st.until) {
+		return 0
+	}
+	return st.until.Sub(now)
+}
+
+func (l *MemoryLockout) Fail(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.Now()
+	l.sweep(now)
+	st, ok := l.states[key]
+	if !ok || now.Sub(st.lastFail) >= l.policy.Memory {
+		st = &lockState{}
+		l.states[key] = st
+	}
+	st.fails++
+	st.lastFail = now
+	d := l.policy.block(st.fails)
+	if d > 0 {
+		st.until = now.Add(d)
+	}
+	return d
+}
+
+func (l *MemoryLockout) Reset(key string) {
+	l.mu.Lock()
+	delete(l.states, key)
+	l.mu.Unlock()
+}
+
+// sweep forgets keys without recent failures, it is called with the lock held
+func (l *MemoryLockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.policy.Memory {
+		return
+	}
+	l.lastSweep = now
+	for key, st := range l.states {
+		if now.Sub(st.lastFail) >= l.policy.Memory && !now.Before(st.until) {
+			delete(l.states, key)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testPolicy = LockoutPolicy{Threshold: 3, Base: time.Minute, Max: 5 * time.Minute, Memory: time.Hour}
+
+func TestLockoutPolicy(t *testing.T) {
+	var res []time.Duration
+	for fails := 1; fails <= 7; fails++ {
+		res = append(res, testPolicy.block(fails))
+	}
+	require.Equal(t, []time.Duration{0, 0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute}, res)
+}
+
+func TestMemoryLockout(t *testing.T) {
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	l := NewMemoryLockout(testPolicy)
+	l.Now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		require.Zero(t, l.Fail("ivan"))
+	}
+	require.Zero(t, l.Locked("ivan"))
+	require.Equal(t, time.Minute, l.Fail("ivan"))
+	require.Equal(t, time.Minute, l.Locked("ivan"))
+	require.Zero(t, l.Locked("petr"))
+
+	now = now.Add(time.Minute)
+	require.Zero(t, l.Locked("ivan"))
+	require.Equal(t, 2*time.Minute, l.Fail("ivan"))
+
+	l.Reset("ivan")
+	require.Zero(t, l.Locked("ivan"))
+	require.Zero(t, l.Fail("ivan"))
+
+	// failures are forgotten after a quiet period
+	l.Fail("petr")
+	l.Fail("petr")
+	l.Fail("petr")
+	now = now.Add(2 * time.Hour)
+	require.Zero(t, l.Fail("petr"))
+	require.Len(t, l.states, 1)
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket spends one token of the key and reports how long to wait when none is left
+type Bucket interface {
+	Allow(key string) (bool, time.Duration)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is an in-process token bucket per key: up to burst events at once,
+// refilled at burst events per window
+type Limiter struct {
+	mu        sync.Mutex
+	burst     float64
+	window    time.Duration
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	Now       func() time.Time
+}
+
+func NewLimiter(burst int, window time.Duration) *Limiter {
+	return &Limiter{
+		burst:   float64(burst),
+		window:  window,
+		buckets: make(map[string]*bucket),
+		Now:     time.Now,
+	}
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * l.burst / l.window.Seconds()
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+}
+
+// Allow takes a token for the key; when none is left it reports how long to wait for the next one
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.Now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) * float64(l.window) / l.burst)
+	return false, wait
+}
+
+// sweep forgets keys whose buckets have refilled completely
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	l := NewLimiter(2, 10*time.Second)
+	l.Now = func() time.Time { return now }
+
+	ok, _ := l.Allow("4")
+	require.True(t, ok)
+	ok, _ = l.Allow("4")
+	require.True(t, ok)
+	ok, wait := l.Allow("4")
+	require.False(t, ok)
+	require.Equal(t, 5*time.Second, wait)
+
+	ok, _ = l.Allow("5")
+	require.True(t, ok)
+
+	now = now.Add(5 * time.Second)
+	ok, _ = l.Allow("4")
+	require.True(t, ok)
+	ok, _ = l.Allow("4")
+	require.False(t, ok)
+
+	now = now.Add(time.Minute)
+	ok, _ = l.Allow("6")
+	require.True(t, ok)
+	require.Len(t, l.buckets, 1)
+}
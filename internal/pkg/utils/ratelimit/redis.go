@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"github.com/gomodule/redigo/redis"
+	"time"
+)
+
+// bucketScript keeps a token bucket in a hash, a missing hash is a full bucket
+var bucketScript = redis.NewScript(1, `
+local burst = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if not tokens or not last then
+	tokens = burst
+	last = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - last) * burst / window)
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) * window / burst)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return wait
+`)
+
+// failScript counts failures that are forgotten after ARGV[1] ms without new ones
+var failScript = redis.NewScript(1, `
+local fails = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return fails
+`)
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// RedisLimiter shares its buckets between instances. While redis is unavailable
+// every instance falls back to its own in-process buckets.
+type RedisLimiter struct {
+	redisPool *redis.Pool
+	prefix    string
+	burst     int
+	window    time.Duration
+	Fallback  *Limiter
+	Now       func() time.Time
+}
+
+func NewRedisLimiter(pool *redis.Pool, prefix string, burst int, window time.Duration) *RedisLimiter {
+	return &RedisLimiter{
+		redisPool: pool,
+		prefix:    prefix,
+		burst:     burst,
+		window:    window,
+		Fallback:  NewLimiter(burst, window),
+		Now:       time.Now,
+	}
+}
+
+func (l *RedisLimiter) Allow(key string) (bool, time.Duration) {
+	conn := l.redisPool.Get()
+	defer conn.Close()
+	wait, err := redis.Int64(bucketScript.Do(conn, l.prefix+key,
+		l.burst, l.window.Milliseconds(), toMillis(l.Now())))
+	if err != nil {
+		return l.Fallback.Allow(key)
+	}
+	if wait > 0 {
+		return false, time.Duration(wait) * time.Millisecond
+	}
+	return true, 0
+}
+
+// RedisLockout shares lockouts between instances with an in-process fallback
+type RedisLockout struct {
+	redisPool *redis.Pool
+	prefix    string
+	policy    LockoutPolicy
+	Fallback  *MemoryLockout
+}
+
+func NewRedisLockout(pool *redis.Pool, prefix string, policy LockoutPolicy) *RedisLockout {
+	return &RedisLockout{
+		redisPool: pool,
+		prefix:    prefix,
+		policy:    policy,
+		Fallback:  NewMemoryLockout(policy),
+	}
+}
+
+func (l *RedisLockout) failsKey(key string) string {
+	return l.prefix + "fails:" + key
+}
+
+func (l *RedisLockout) lockKey(key string) string {
+	return l.prefix + "lock:" + key
+}
+
+func (l *RedisLockout) Locked(key string) time.Duration {
+	conn := l.redisPool.Get()
+	defer conn.Close()
+	ttl, err := redis.Int64(conn.Do("PTTL", l.lockKey(key)))
+	if err != nil {
+		return l.Fallback.Locked(key)
+	}
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Duration(ttl) * time.Millisecond
+}
+
+func (l *RedisLockout) Fail(key string) time.Duration {
+	conn := l.redisPool.Get()
+	defer conn.Close()
+	fails, err := redis.Int(failScript.Do(conn, l.failsKey(key), l.policy.Memory.Milliseconds()))
+	if err != nil {
+		return l.Fallback.Fail(key)
+	}
+	d := l.policy.block(fails)
+	if d > 0 {
+		if _, err = conn.Do("SET", l.lockKey(key), fails, "PX", d.Milliseconds()); err != nil {
+			return l.Fallback.Fail(key)
+		}
+	}
+	return d
+}
+
+func (l *RedisLockout) Reset(key string) {
+	conn := l.redisPool.Get()
+	defer conn.Close()
+	l.Fallback.Reset(key)
+	_, _ = conn.Do("DEL", l.failsKey(key), l.lockKey(key))
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T) (*miniredis.Miniredis, *redis.Pool) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	addr := s.Addr()
+	return s, &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr)
+		},
+	}
+}
+
+func TestRedisLimiter(t *testing.T) {
+	s, pool := newTestPool(t)
+	defer s.Close()
+
+	now := time.Date(2020, 12, 5, 10, 0, 0, 0, time.UTC)
+	l := NewRedisLimiter(pool, "rl:login:", 2, 10*time.Second)
+	l.Now = func() time.Time { return now }
+
+	ok, _ := l.Allow("4")
+	require.True(t, ok)
+	ok, _ = l.Allow("4")
+	require.True(t, ok)
+	ok, wait := l.Allow("4")
+	require.False(t, ok)
+	require.Equal(t, 5*time.Second, wait)
+
+	ok, _ = l.Allow("5")
+	require.True(t, ok)
+
+	now = now.Add(5 * time.Second)
+	ok, _ = l.Allow("4")
+	require.True(t, ok)
+	ok, _ = l.Allow("4")
+	require.False(t, ok)
+
+	// a full bucket is not kept
+	s.FastForward(10 * time.Second)
+	require.False(t, s.Exists("rl:login:4"))
+
+	// buckets are shared between instances
+	other := NewRedisLimiter(pool, "rl:login:", 2, 10*time.Second)
+	other.Now = l.Now
+	ok, _ = other.Allow("5")
+	require.True(t, ok)
+	ok, _ = other.Allow("5")
+	require.True(t, ok)
+	ok, _ = l.Allow("5")
+	require.False(t, ok)
+
+	s.Close()
+	ok, _ = l.Allow("5")
+	require.True(t, ok)
+	require.Len(t, l.Fallback.buckets, 1)
+}
+
+func TestRedisLockout(t *testing.T) {
+	s, pool := newTestPool(t)
+	defer s.Close()
+
+	l := NewRedisLockout(pool, "lockout:", testPolicy)
+
+	for i := 0; i < 3; i++ {
+		require.Zero(t, l.Fail("ivan"))
+	}
+	require.Zero(t, l.Locked("ivan"))
+	require.Equal(t, time.Minute, l.Fail("ivan"))
+	require.Equal(t, time.Minute, l.Locked("ivan"))
+	require.Zero(t, l.Locked("petr"))
+
+	s.FastForward(time.Minute)
+	require.Zero(t, l.Locked("ivan"))
+	require.Equal(t, 2*time.Minute, l.Fail("ivan"))
+
+	l.Reset("ivan")
+	require.Zero(t, l.Locked("ivan"))
+	require.False(t, s.Exists("lockout:fails:ivan"))
+
+	l.Fail("petr")
+	s.FastForward(2 * time.Hour)
+	require.False(t, s.Exists("lockout:fails:petr"))
+
+	s.Close()
+	for i := 0; i < 3; i++ {
+		require.Zero(t, l.Fail("ivan"))
+	}
+	require.Equal(t, time.Minute, l.Fail("ivan"))
+	require.InDelta(t, float64(time.Minute), float64(l.Locked("ivan")), float64(time.Second))
+}
@@ -0,0 +1,160 @@
+package recurrence
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// MaxOccurrences caps open-ended rules, occurrences are stored as separate meetings
+const MaxOccurrences = 52
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+type Rule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    []time.Weekday
+}
+
+// Parse accepts "daily", "weekly", "monthly" or an RRULE subset:
+// FREQ, INTERVAL, COUNT, UNTIL and BYDAY (weekly rules only).
+func Parse(rule string) (Rule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	r := Rule{Interval: 1}
+	switch strings.ToUpper(rule) {
+	case Daily, Weekly, Monthly:
+		r.Freq = strings.ToUpper(rule)
+		return r, nil
+	}
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return Rule{}, ErrInvalidRule
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		var err error
+		switch key {
+		case "FREQ":
+			if value != Daily && value != Weekly && value != Monthly {
+				return Rule{}, ErrInvalidRule
+			}
+			r.Freq = value
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return Rule{}, ErrInvalidRule
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return Rule{}, ErrInvalidRule
+			}
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+			if err != nil {
+				return Rule{}, ErrInvalidRule
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				wd, ok := weekdays[day]
+				if !ok {
+					return Rule{}, ErrInvalidRule
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		default:
+			return Rule{}, ErrInvalidRule
+		}
+	}
+	if r.Freq == "" || (len(r.ByDay) > 0 && r.Freq != Weekly) ||
+		(r.Count > 0 && !r.Until.IsZero()) {
+		return Rule{}, ErrInvalidRule
+	}
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		// Date-only UNTIL includes the whole day
+		return t.Add(24*time.Hour - time.Nanosecond), err
+	}
+	return time.Parse("20060102T150405Z", value)
+}
+
+// Occurrences returns start times of the series, the first one is always start
+func (r Rule) Occurrences(start time.Time) []time.Time {
+	limit := MaxOccurrences
+	if r.Count > 0 && r.Count < limit {
+		limit = r.Count
+	}
+	result := []time.Time{start}
+	// Period bound guards against rules that rarely match, e.g. monthly on the 31st
+	for period := 1; len(result) < limit && period <= limit*12; period++ {
+		for _, c := range r.period(start, period) {
+			if !r.Until.IsZero() && c.After(r.Until) {
+				return result
+			}
+			if !c.After(start) {
+				continue
+			}
+			result = append(result, c)
+			if len(result) == limit {
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (r Rule) period(start time.Time, n int) []time.Time {
+	switch r.Freq {
+	case Daily:
+		return []time.Time{start.AddDate(0, 0, n*r.Interval)}
+	case Monthly:
+		c := time.Date(start.Year(), start.Month()+time.Month(n*r.Interval), start.Day(),
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		// Months without the start day are skipped as in RFC 5545
+		if c.Day() != start.Day() {
+			return nil
+		}
+		return []time.Time{c}
+	}
+	if len(r.ByDay) == 0 {
+		return []time.Time{start.AddDate(0, 0, 7*n*r.Interval)}
+	}
+	// Weeks start on Monday, the first period is the week of start itself
+	offset := (int(start.Weekday()) + 6) % 7
+	weekStart := start.AddDate(0, 0, -offset+7*(n-1)*r.Interval)
+	days := make([]int, len(r.ByDay))
+	for i, wd := range r.ByDay {
+		days[i] = (int(wd) + 6) % 7
+	}
+	sort.Ints(days)
+	result := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		result = append(result, weekStart.AddDate(0, 0, d))
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package recurrence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse("weekly")
+	require.NoError(t, err)
+	assert.Equal(t, Rule{Freq: Weekly, Interval: 1}, r)
+
+	r, err = Parse("RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,TH")
+	require.NoError(t, err)
+	assert.Equal(t, Rule{Freq: Weekly, Interval: 2, Count: 4,
+		ByDay: []time.Weekday{time.Tuesday, time.Thursday}}, r)
+
+	for _, rule := range []string{"", "yearly", "FREQ=YEARLY", "FREQ=DAILY;BYDAY=MO",
+		"FREQ=DAILY;COUNT=0", "FREQ=DAILY;COUNT=2;UNTIL=20201231", "FREQ=DAILY;BYMONTH=1"} {
+		_, err = Parse(rule)
+		assert.Equal(t, ErrInvalidRule, err, rule)
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	start := time.Date(2020, 12, 1, 19, 0, 0, 0, time.UTC) // Tuesday
+
+	r, _ := Parse("FREQ=DAILY;INTERVAL=3;COUNT=3")
+	assert.Equal(t, []time.Time{start, start.AddDate(0, 0, 3), start.AddDate(0, 0, 6)},
+		r.Occurrences(start))
+
+	r, _ = Parse("FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20201210")
+	assert.Equal(t, []time.Time{
+		start,
+		time.Date(2020, 12, 3, 19, 0, 0, 0, time.UTC),
+		time.Date(2020, 12, 7, 19, 0, 0, 0, time.UTC),
+		time.Date(2020, 12, 10, 19, 0, 0, 0, time.UTC),
+	}, r.Occurrences(start))
+
+	jan31 := time.Date(2021, 1, 31, 10, 0, 0, 0, time.UTC)
+	r, _ = Parse("FREQ=MONTHLY;COUNT=3")
+	assert.Equal(t, []time.Time{
+		jan31,
+		time.Date(2021, 3, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2021, 5, 31, 10, 0, 0, 0, time.UTC),
+	}, r.Occurrences(jan31))
+
+	r, _ = Parse("daily")
+	assert.Len(t, r.Occurrences(start), MaxOccurrences)
+}
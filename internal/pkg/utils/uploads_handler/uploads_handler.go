@@ -84,3 +84,14 @@ func (h UploadsHandler) UploadImage(imgPath string, img io.Reader) (string, erro
 	}
 	return imgPath, nil
 }
+
+func (h UploadsHandler) RemoveImage(imgPath string) error {
+	if !strings.HasPrefix(imgPath, h.UploadsDir+"/") || strings.Contains(imgPath, "..") {
+		return errors.New("invalid image path")
+	}
+	err := os.Remove(imgPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}